@@ -79,9 +79,14 @@ func (c *Context) CanBotDeleteMessage() bool {
 }
 
 func (c *Context) IsAdmin() bool {
+	return c.IsChatAdmin(c.GetChatID(), c.GetUserID())
+}
+
+// IsChatAdmin 判断用户是否为会话 chatID 的群主或管理员
+func (c *Context) IsChatAdmin(chatID telego.ChatID, userID int64) bool {
 	member, err := c.Api.GetChatMember(c.Ctx, &telego.GetChatMemberParams{
-		ChatID: c.GetChatID(),
-		UserID: c.GetUserID(),
+		ChatID: chatID,
+		UserID: userID,
 	})
 	if err != nil {
 		return false
@@ -1,6 +1,8 @@
 package context
 
 const (
-	PluginName = "plugin_name"
-	PluginID   = "plugin_id"
+	PluginName  = "plugin_name"
+	PluginID    = "plugin_id"
+	Locale      = "locale"
+	ChatLocales = "chat_locales" // 本 Bot 的群组语言设置（*i18n.ChatLocales）
 )
@@ -0,0 +1,51 @@
+package context
+
+import (
+	"fmt"
+
+	"yueling_tg/pkg/i18n"
+)
+
+// Locale 获取当前更新使用的语言：群组设置 → 用户语言代码 → 默认语言
+func (c *Context) Locale() string {
+	if l, ok := c.GetString(Locale); ok {
+		return l
+	}
+	l := c.chatLocales().Resolve(c.GetChatID().ID, c.GetLanguageCode())
+	c.Set(Locale, l)
+	return l
+}
+
+// SetChatLocale 设置当前群组的语言，locale 为空时恢复自动检测；本次更新之后的回复立即使用新语言
+func (c *Context) SetChatLocale(locale string) error {
+	s := c.chatLocales()
+	if s == nil {
+		return fmt.Errorf("未配置群组语言设置")
+	}
+	if err := s.Set(c.GetChatID().ID, locale); err != nil {
+		return err
+	}
+	c.Delete(Locale)
+	return nil
+}
+
+// chatLocales 返回运行时注入的群组语言设置，未注入时为空
+func (c *Context) chatLocales() *i18n.ChatLocales {
+	s, _ := c.Get(ChatLocales)
+	locales, _ := s.(*i18n.ChatLocales)
+	return locales
+}
+
+// T 翻译当前插件命名空间下的消息，找不到时回退到公共命名空间
+//
+//	ctx.T("list.title")
+//	ctx.T("list.count", i18n.Args{"count": 3})
+func (c *Context) T(key string, args ...i18n.Args) string {
+	ns, _ := c.GetString(PluginID)
+	return i18n.Translate(c.Locale(), ns, key, args...)
+}
+
+// TNamespace 翻译指定命名空间下的消息
+func (c *Context) TNamespace(namespace, key string, args ...i18n.Args) string {
+	return i18n.Translate(c.Locale(), namespace, key, args...)
+}
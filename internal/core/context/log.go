@@ -0,0 +1,36 @@
+package context
+
+import (
+	"yueling_tg/internal/core/log"
+	"yueling_tg/internal/core/trace"
+
+	"github.com/rs/zerolog"
+)
+
+// LogFields 返回当前更新的固定日志字段：update_id、chat_id、user_id，以及正在处理的插件与追踪 ID
+func (c *Context) LogFields() map[string]any {
+	fields := map[string]any{
+		log.UpdateIDField: c.Update.UpdateID,
+	}
+	if chatID := c.GetChatID().ID; chatID != 0 {
+		fields[log.ChatIDField] = chatID
+	}
+	if userID := c.GetUserID(); userID != 0 {
+		fields[log.UserIDField] = userID
+	}
+	if id, ok := c.GetString(PluginID); ok && id != "" {
+		fields[log.PluginField] = id
+	}
+	if span := trace.SpanFromContext(c.Ctx); span != nil {
+		fields[log.TraceIDField] = span.TraceID().String()
+	}
+	return fields
+}
+
+// Logger 返回附加了当前更新字段的日志记录器
+//
+//	ctx.Logger(h.Log).Info().Msg("处理完成")
+func (c *Context) Logger(base zerolog.Logger) *zerolog.Logger {
+	l := base.With().Fields(c.LogFields()).Logger()
+	return &l
+}
@@ -209,17 +209,20 @@ func (c *Context) GetLastName() string {
 
 // GetFullName 获取用户全名
 func (c *Context) GetFullName() string {
-	user := c.GetUser()
-	if user != nil {
-		fullName := user.FirstName
-		if user.LastName != "" {
-			fullName += " " + user.LastName
-		}
-		return fullName
+	if user := c.GetUser(); user != nil {
+		return FullName(user)
 	}
 	return ""
 }
 
+// FullName 用户的显示名称：名与姓以空格连接
+func FullName(user *telego.User) string {
+	if user.LastName != "" {
+		return user.FirstName + " " + user.LastName
+	}
+	return user.FirstName
+}
+
 // GetLanguageCode 获取用户语言代码
 func (c *Context) GetLanguageCode() string {
 	user := c.GetUser()
@@ -807,3 +810,58 @@ func (c *Context) HasText() bool {
 func (c *Context) GetUpdateID() int {
 	return c.Update.UpdateID
 }
+
+// GetUpdateType 获取更新类型，与 AllowedUpdates 中的名称一致（如 message、callback_query）
+func (c *Context) GetUpdateType() string {
+	u := c.Update
+	switch {
+	case u.Message != nil:
+		return telego.MessageUpdates
+	case u.EditedMessage != nil:
+		return telego.EditedMessageUpdates
+	case u.ChannelPost != nil:
+		return telego.ChannelPostUpdates
+	case u.EditedChannelPost != nil:
+		return telego.EditedChannelPostUpdates
+	case u.BusinessConnection != nil:
+		return telego.BusinessConnectionUpdates
+	case u.BusinessMessage != nil:
+		return telego.BusinessMessageUpdates
+	case u.EditedBusinessMessage != nil:
+		return telego.EditedBusinessMessageUpdates
+	case u.DeletedBusinessMessages != nil:
+		return telego.DeletedBusinessMessagesUpdates
+	case u.MessageReaction != nil:
+		return telego.MessageReactionUpdates
+	case u.MessageReactionCount != nil:
+		return telego.MessageReactionCountUpdates
+	case u.InlineQuery != nil:
+		return telego.InlineQueryUpdates
+	case u.ChosenInlineResult != nil:
+		return telego.ChosenInlineResultUpdates
+	case u.CallbackQuery != nil:
+		return telego.CallbackQueryUpdates
+	case u.ShippingQuery != nil:
+		return telego.ShippingQueryUpdates
+	case u.PreCheckoutQuery != nil:
+		return telego.PreCheckoutQueryUpdates
+	case u.PurchasedPaidMedia != nil:
+		return telego.PurchasedPaidMediaUpdates
+	case u.Poll != nil:
+		return telego.PollUpdates
+	case u.PollAnswer != nil:
+		return telego.PollAnswerUpdates
+	case u.MyChatMember != nil:
+		return telego.MyChatMemberUpdates
+	case u.ChatMember != nil:
+		return telego.ChatMemberUpdates
+	case u.ChatJoinRequest != nil:
+		return telego.ChatJoinRequestUpdates
+	case u.ChatBoost != nil:
+		return telego.ChatBoostUpdates
+	case u.RemovedChatBoost != nil:
+		return telego.RemovedChatBoostUpdates
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,193 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// 输出格式
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+)
+
+// Config 日志配置（config.toml 中的 [log] 段）
+//
+//	[log]
+//	level = "info"
+//	format = "console"
+//
+//	[log.components]
+//	api = "warn"
+//
+//	[log.plugins]
+//	chat = "debug"
+//
+//	[log.file]
+//	path = "./logs/bot.log"
+//	max_size_mb = 100
+//	max_age_days = 7
+type Config struct {
+	Level      string            `mapstructure:"level" doc:"默认级别" validate:"oneof=trace debug info warn error fatal panic disabled"`
+	Format     string            `mapstructure:"format" doc:"控制台输出格式" validate:"oneof=console json"`
+	Components map[string]string `mapstructure:"components" doc:"按组件设置级别：组件 → 级别"`
+	Plugins    map[string]string `mapstructure:"plugins" doc:"按插件设置级别：插件 ID 或名称 → 级别"`
+	File       FileConfig        `mapstructure:"file" doc:"文件输出，path 为空时不写文件"`
+}
+
+// FileConfig 文件输出配置，Path 为空时不写文件
+type FileConfig struct {
+	Path       string `mapstructure:"path" doc:"日志文件路径"`
+	Format     string `mapstructure:"format" doc:"文件格式" validate:"oneof=console json"`
+	MaxSizeMB  int    `mapstructure:"max_size_mb" doc:"单个文件最大体积（MB），超过后轮转" validate:"min=0"`
+	MaxAgeDays int    `mapstructure:"max_age_days" doc:"历史文件保留天数" validate:"min=0"`
+	MaxBackups int    `mapstructure:"max_backups" doc:"历史文件最多保留个数" validate:"min=0"`
+	Daily      bool   `mapstructure:"daily" doc:"是否每天轮转一次"`
+}
+
+// DefaultConfig 返回默认配置（与未配置时的行为一致）
+func DefaultConfig() Config {
+	return Config{
+		Level:  "debug",
+		Format: FormatConsole,
+		File: FileConfig{
+			Format:     FormatJSON,
+			MaxSizeMB:  100,
+			MaxAgeDays: 7,
+			MaxBackups: 10,
+		},
+	}
+}
+
+// state 运行时生效的日志配置
+type state struct {
+	level      zerolog.Level
+	format     string
+	components map[Component]zerolog.Level
+	plugins    map[string]zerolog.Level
+	out        io.Writer
+	file       *rotateWriter
+	fileOut    io.Writer // 文件输出（按文件格式包装）
+}
+
+var (
+	current atomic.Pointer[state]
+	// swapMu 写入期间持有读锁，Configure 持有写锁替换配置，
+	// 保证替换后不再有写入使用旧的日志文件，之后才能关闭它
+	swapMu sync.RWMutex
+)
+
+func init() {
+	current.Store(&state{
+		level:      zerolog.DebugLevel,
+		format:     FormatConsole,
+		components: map[Component]zerolog.Level{},
+		plugins:    map[string]zerolog.Level{},
+		out:        os.Stderr,
+	})
+}
+
+// Configure 应用日志配置，已创建的日志记录器立即生效
+func Configure(cfg Config) error {
+	def := DefaultConfig()
+	if cfg.Level == "" {
+		cfg.Level = def.Level
+	}
+	if cfg.Format == "" {
+		cfg.Format = def.Format
+	}
+
+	level, err := parseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	st := &state{
+		level:      level,
+		format:     strings.ToLower(cfg.Format),
+		components: make(map[Component]zerolog.Level),
+		plugins:    make(map[string]zerolog.Level),
+		out:        os.Stderr,
+	}
+	if st.format != FormatConsole && st.format != FormatJSON {
+		return fmt.Errorf("未知的日志格式: %s", cfg.Format)
+	}
+
+	minLevel := level
+	for name, lv := range cfg.Components {
+		l, err := parseLevel(lv)
+		if err != nil {
+			return fmt.Errorf("组件 %s: %w", name, err)
+		}
+		st.components[Component(strings.ToLower(name))] = l
+		minLevel = min(minLevel, l)
+	}
+	for name, lv := range cfg.Plugins {
+		l, err := parseLevel(lv)
+		if err != nil {
+			return fmt.Errorf("插件 %s: %w", name, err)
+		}
+		st.plugins[strings.ToLower(name)] = l
+		minLevel = min(minLevel, l)
+	}
+
+	if cfg.File.Path != "" {
+		fc := cfg.File
+		if fc.Format == "" {
+			fc.Format = def.File.Format
+		}
+		if fc.MaxSizeMB == 0 {
+			fc.MaxSizeMB = def.File.MaxSizeMB
+		}
+		if st.file, err = newRotateWriter(fc); err != nil {
+			return err
+		}
+		st.fileOut = st.file
+		if strings.ToLower(fc.Format) == FormatConsole {
+			// 文件中不输出颜色
+			st.fileOut = plainWriter(st.file)
+		}
+	}
+
+	// 全局级别取所有配置中的最低级别，低于它的事件不会被构造
+	zerolog.SetGlobalLevel(minLevel)
+
+	swapMu.Lock()
+	old := current.Swap(st)
+	swapMu.Unlock()
+
+	if old != nil && old.file != nil {
+		old.file.Close()
+	}
+	return nil
+}
+
+// levelFor 返回日志来源对应的级别：插件 → 组件 → 默认
+func (st *state) levelFor(src *source) zerolog.Level {
+	if src.component == PluginComponent {
+		if l, ok := st.plugins[strings.ToLower(src.id)]; ok && src.id != "" {
+			return l
+		}
+		if l, ok := st.plugins[strings.ToLower(src.name)]; ok {
+			return l
+		}
+	}
+	if l, ok := st.components[src.component]; ok {
+		return l
+	}
+	return st.level
+}
+
+func parseLevel(s string) (zerolog.Level, error) {
+	l, err := zerolog.ParseLevel(strings.ToLower(strings.TrimSpace(s)))
+	if err != nil {
+		return zerolog.NoLevel, fmt.Errorf("未知的日志级别: %s", s)
+	}
+	return l, nil
+}
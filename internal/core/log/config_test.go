@@ -0,0 +1,78 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestConfigureKeepsWritesDuringReload(t *testing.T) {
+	// 控制台输出丢弃，只检查文件
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+	stderr := os.Stderr
+	os.Stderr = devNull
+	defer func() { os.Stderr = stderr }()
+
+	dir := t.TempDir()
+	configure := func(name string) {
+		t.Helper()
+		cfg := DefaultConfig()
+		cfg.Format = FormatJSON
+		cfg.File.Path = filepath.Join(dir, name)
+		if err := Configure(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	configure("0.log")
+	defer Configure(DefaultConfig())
+
+	const writers, perWriter = 8, 2000
+	logger := New(SystemComponent, "测试")
+
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range perWriter {
+				logger.Info().Msg("line")
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// 写入过程中反复切换日志文件，旧文件关闭前的写入不能丢失
+reload:
+	for i := 0; ; i++ {
+		select {
+		case <-done:
+			break reload
+		default:
+			configure(fmt.Sprintf("%d.log", i%4))
+		}
+	}
+
+	lines := 0
+	files, _ := filepath.Glob(filepath.Join(dir, "*.log"))
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines += bytes.Count(data, []byte("\n"))
+	}
+	if want := writers * perWriter; lines != want {
+		t.Errorf("lines = %d, want %d", lines, want)
+	}
+}
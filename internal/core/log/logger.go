@@ -24,13 +24,31 @@ const (
 	HandlerComponent         Component = "handler"
 )
 
-// 创建日志记录器
+// 创建日志记录器，级别与输出格式由 Configure 统一控制
 func New(component Component, name string) zerolog.Logger {
+	return newLogger(&source{component: component, name: name})
+}
+
+func newLogger(src *source) zerolog.Logger {
+	ctx := zerolog.New(newWriter(src)).
+		With().
+		Timestamp().
+		Str(ComponentField, string(src.component)).
+		Str(LoggerField, src.name)
+	if src.id != "" {
+		ctx = ctx.Str(PluginIDField, src.id)
+	}
+	return ctx.Logger()
+}
+
+// consoleWriter 创建带组件主题的彩色控制台输出
+func consoleWriter(component Component, name string) zerolog.ConsoleWriter {
 	theme := getComponentTheme(component)
 
-	consoleWriter := zerolog.ConsoleWriter{
-		Out:        os.Stderr,
-		TimeFormat: "2006-01-02 15:04:05",
+	return zerolog.ConsoleWriter{
+		Out:           os.Stderr,
+		TimeFormat:    "2006-01-02 15:04:05",
+		FieldsExclude: []string{ComponentField, LoggerField, PluginIDField},
 
 		FormatLevel: func(i interface{}) string {
 			// i 可以是 string 或 zerolog.Level 等，先格式化再大写
@@ -79,11 +97,6 @@ func New(component Component, name string) zerolog.Logger {
 			}
 		},
 	}
-
-	return zerolog.New(consoleWriter).
-		With().
-		Timestamp().
-		Logger()
 }
 
 // 组件主题结构
@@ -165,6 +178,11 @@ func NewPlugin(name string) zerolog.Logger {
 	return New(PluginComponent, name)
 }
 
+// NewPluginWithID 创建插件日志记录器，[log.plugins] 中可按 ID 或名称配置级别
+func NewPluginWithID(id, name string) zerolog.Logger {
+	return newLogger(&source{component: PluginComponent, name: name, id: id})
+}
+
 func NewMatcher(name string) zerolog.Logger {
 	return New(MatcherComponent, name)
 }
@@ -0,0 +1,160 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotateWriter 按体积与日期轮转的日志文件
+type rotateWriter struct {
+	cfg     FileConfig
+	file    *os.File
+	size    int64
+	openDay string
+	mu      sync.Mutex
+}
+
+func newRotateWriter(cfg FileConfig) (*rotateWriter, error) {
+	w := &rotateWriter{cfg: cfg}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open 打开（或追加）当前日志文件
+func (w *rotateWriter) open() error {
+	if dir := filepath.Dir(w.cfg.Path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建日志目录失败: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(w.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("读取日志文件信息失败: %w", err)
+	}
+
+	w.file = f
+	w.size = info.Size()
+	w.openDay = info.ModTime().Format("2006-01-02")
+	if w.size == 0 {
+		w.openDay = time.Now().Format("2006-01-02")
+	}
+	return nil
+}
+
+func (w *rotateWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotateWriter) shouldRotate(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.cfg.MaxSizeMB > 0 && w.size+int64(n) > int64(w.cfg.MaxSizeMB)*1024*1024 {
+		return true
+	}
+	return w.cfg.Daily && time.Now().Format("2006-01-02") != w.openDay
+}
+
+// rotate 将当前文件重命名为 <name>-<时间>.<ext> 并打开新文件
+func (w *rotateWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("关闭日志文件失败: %w", err)
+	}
+	w.file = nil
+
+	ext := filepath.Ext(w.cfg.Path)
+	prefix := strings.TrimSuffix(w.cfg.Path, ext)
+	backup := fmt.Sprintf("%s-%s%s", prefix, time.Now().Format("20060102-150405.000"), ext)
+	if err := os.Rename(w.cfg.Path, backup); err != nil {
+		// 轮转失败时继续写入原文件
+		if openErr := w.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("轮转日志文件失败: %w", err)
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	go w.cleanup(prefix, ext)
+	return nil
+}
+
+// cleanup 删除过期或超出数量的历史文件
+func (w *rotateWriter) cleanup(prefix, ext string) {
+	matches, err := filepath.Glob(prefix + "-*" + ext)
+	if err != nil || len(matches) == 0 {
+		return
+	}
+
+	type backup struct {
+		path    string
+		modTime time.Time
+	}
+
+	backups := make([]backup, 0, len(matches))
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		backups = append(backups, backup{m, info.ModTime()})
+	}
+
+	// 新的在前
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].modTime.After(backups[j].modTime)
+	})
+
+	deadline := time.Now().AddDate(0, 0, -w.cfg.MaxAgeDays)
+	for i, b := range backups {
+		expired := w.cfg.MaxAgeDays > 0 && b.modTime.Before(deadline)
+		overflow := w.cfg.MaxBackups > 0 && i >= w.cfg.MaxBackups
+		if expired || overflow {
+			os.Remove(b.path)
+		}
+	}
+}
+
+// Close 关闭日志文件
+func (w *rotateWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -0,0 +1,81 @@
+package log
+
+import (
+	"io"
+
+	"github.com/rs/zerolog"
+)
+
+// 日志来源字段，JSON 输出中用于区分组件与名称
+const (
+	ComponentField = "component"
+	LoggerField    = "logger"
+	PluginIDField  = "plugin_id"
+)
+
+// 请求日志字段，处理同一更新时的每条日志都会带上
+const (
+	UpdateIDField = "update_id"
+	ChatIDField   = "chat_id"
+	UserIDField   = "user_id"
+	PluginField   = "plugin"
+	TraceIDField  = "trace_id"
+)
+
+// source 日志来源
+type source struct {
+	component Component
+	name      string
+	id        string // 插件 ID（仅插件日志）
+}
+
+// writer 按当前配置过滤级别并分发到控制台与文件
+type writer struct {
+	src     *source
+	console io.Writer
+}
+
+func newWriter(src *source) *writer {
+	return &writer{
+		src:     src,
+		console: consoleWriter(src.component, src.name),
+	}
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (w *writer) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	swapMu.RLock()
+	defer swapMu.RUnlock()
+	st := current.Load()
+
+	if level != zerolog.NoLevel && level < st.levelFor(w.src) {
+		return len(p), nil
+	}
+
+	var err error
+	if st.format == FormatJSON {
+		_, err = st.out.Write(p)
+	} else {
+		_, err = w.console.Write(p)
+	}
+
+	if st.fileOut != nil {
+		_, _ = st.fileOut.Write(p)
+	}
+
+	return len(p), err
+}
+
+// plainWriter 无颜色的控制台格式
+func plainWriter(out io.Writer) io.Writer {
+	return zerolog.ConsoleWriter{
+		Out:           out,
+		NoColor:       true,
+		TimeFormat:    "2006-01-02 15:04:05",
+		PartsOrder:    []string{zerolog.TimestampFieldName, zerolog.LevelFieldName, LoggerField, zerolog.MessageFieldName},
+		FieldsExclude: []string{ComponentField, LoggerField},
+	}
+}
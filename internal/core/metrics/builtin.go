@@ -0,0 +1,37 @@
+package metrics
+
+// Default 全局指标注册中心，同一进程中的多个 Bot 共享，以 bot 标签区分
+var Default = NewRegistry()
+
+// 框架内置指标
+var (
+	// UpdatesTotal 收到的更新数，按 Bot 与更新类型区分
+	UpdatesTotal = Default.NewCounterVec("yueling_updates_total", "收到的更新数", "bot", "type")
+
+	// MatcherHits 匹配器命中次数，按 Bot 与插件区分
+	MatcherHits = Default.NewCounterVec("yueling_matcher_hits_total", "匹配器命中次数", "bot", "plugin")
+
+	// HandlerDuration 处理器耗时（秒），按 Bot 与插件区分
+	HandlerDuration = Default.NewHistogramVec("yueling_handler_duration_seconds", "处理器耗时（秒）", nil, "bot", "plugin")
+
+	// HandlerErrors 处理器返回错误的次数，按 Bot 与插件区分
+	HandlerErrors = Default.NewCounterVec("yueling_handler_errors_total", "处理器返回错误的次数", "bot", "plugin")
+
+	// HandlerPanics 处理器发生 panic 的次数，按 Bot 与插件区分
+	HandlerPanics = Default.NewCounterVec("yueling_handler_panics_total", "处理器发生 panic 的次数", "bot", "plugin")
+
+	// HandlerTimeouts 处理器超过截止时间的次数，按 Bot 与插件区分
+	HandlerTimeouts = Default.NewCounterVec("yueling_handler_timeouts_total", "处理器超过截止时间的次数", "bot", "plugin")
+
+	// APIRequests 发出的 Bot API 请求数，按 Bot 与方法区分
+	APIRequests = Default.NewCounterVec("yueling_api_requests_total", "发出的 Bot API 请求数", "bot", "method")
+
+	// APIErrors 失败的 Bot API 请求数，按 Bot 与方法区分
+	APIErrors = Default.NewCounterVec("yueling_api_errors_total", "失败的 Bot API 请求数", "bot", "method")
+
+	// OutboundQueueDepth 出站队列中等待发送的请求数，按 Bot 区分
+	OutboundQueueDepth = Default.NewGaugeFuncVec("yueling_outbound_queue_depth", "出站队列中等待发送的请求数", "bot")
+
+	// APIRateLimited 收到的 429 响应数，按 Bot 区分
+	APIRateLimited = Default.NewCounterFuncVec("yueling_api_rate_limited_total", "收到的 429 响应数", "bot")
+)
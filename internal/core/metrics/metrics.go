@@ -0,0 +1,401 @@
+// Package metrics 提供 Prometheus 文本格式的指标收集。
+//
+// 核心功能：
+//   - CounterVec / HistogramVec 支持任意数量的标签
+//   - GaugeFunc / CounterFunc 在抓取时回调获取数值（如出站队列长度）
+//   - Registry.WritePrometheus 输出 Prometheus 0.0.4 文本格式，由管理 HTTP 服务暴露在 /metrics
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 标签值之间的分隔符，不会出现在正常文本中
+const labelSep = "\xff"
+
+// collector 可输出指标的收集器
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry 指标注册中心
+type Registry struct {
+	collectors []collector
+	mu         sync.RWMutex
+}
+
+// NewRegistry 创建指标注册中心
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.collectors {
+		if existing.name() == c.name() {
+			panic(fmt.Sprintf("指标 %s 重复注册", c.name()))
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出全部指标
+func (r *Registry) WritePrometheus(w io.Writer) {
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	sort.Slice(collectors, func(i, j int) bool {
+		return collectors[i].name() < collectors[j].name()
+	})
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// -------------------- Counter --------------------
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	metric string
+	help   string
+	labels []string
+	values map[string]*counterValue
+	mu     sync.RWMutex
+}
+
+type counterValue struct {
+	mu sync.Mutex
+	v  float64
+}
+
+// NewCounterVec 创建并注册计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		metric: name,
+		help:   help,
+		labels: labels,
+		values: make(map[string]*counterValue),
+	}
+	r.register(c)
+	return c
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加 n（n 必须非负）
+func (c *CounterVec) Add(n float64, labelValues ...string) {
+	if n < 0 {
+		return
+	}
+	v := c.get(labelValues)
+	v.mu.Lock()
+	v.v += n
+	v.mu.Unlock()
+}
+
+// Value 获取当前计数
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.RLock()
+	v, ok := c.values[seriesKey(c.labels, labelValues)]
+	c.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.v
+}
+
+func (c *CounterVec) get(labelValues []string) *counterValue {
+	key := seriesKey(c.labels, labelValues)
+
+	c.mu.RLock()
+	v, ok := c.values[key]
+	c.mu.RUnlock()
+	if ok {
+		return v
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok = c.values[key]; !ok {
+		v = &counterValue{}
+		c.values[key] = v
+	}
+	return v
+}
+
+func (c *CounterVec) name() string { return c.metric }
+
+func (c *CounterVec) write(w io.Writer) {
+	writeHeader(w, c.metric, c.help, "counter")
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		v.mu.Lock()
+		val := v.v
+		v.mu.Unlock()
+		fmt.Fprintf(w, "%s%s %s\n", c.metric, formatLabels(c.labels, key, "", ""), formatFloat(val))
+	}
+}
+
+// -------------------- Histogram --------------------
+
+// DefaultBuckets 默认耗时分桶（秒）
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	metric  string
+	help    string
+	labels  []string
+	buckets []float64
+	values  map[string]*histogramValue
+	mu      sync.RWMutex
+}
+
+type histogramValue struct {
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec 创建并注册直方图，buckets 为空时使用 DefaultBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &HistogramVec{
+		metric:  name,
+		help:    help,
+		labels:  labels,
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := seriesKey(h.labels, labelValues)
+
+	h.mu.RLock()
+	hv, ok := h.values[key]
+	h.mu.RUnlock()
+	if !ok {
+		h.mu.Lock()
+		if hv, ok = h.values[key]; !ok {
+			hv = &histogramValue{counts: make([]uint64, len(h.buckets))}
+			h.values[key] = hv
+		}
+		h.mu.Unlock()
+	}
+
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			hv.counts[i]++
+		}
+	}
+	hv.sum += v
+	hv.count++
+}
+
+func (h *HistogramVec) name() string { return h.metric }
+
+func (h *HistogramVec) write(w io.Writer) {
+	writeHeader(w, h.metric, h.help, "histogram")
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, key := range sortedKeys(h.values) {
+		hv := h.values[key]
+		hv.mu.Lock()
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metric, formatLabels(h.labels, key, "le", formatFloat(upper)), hv.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metric, formatLabels(h.labels, key, "le", "+Inf"), hv.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metric, formatLabels(h.labels, key, "", ""), formatFloat(hv.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metric, formatLabels(h.labels, key, "", ""), hv.count)
+		hv.mu.Unlock()
+	}
+}
+
+// -------------------- 回调型指标 --------------------
+
+type funcMetric struct {
+	metric string
+	help   string
+	kind   string
+	fn     func() float64
+}
+
+// NewGaugeFunc 注册在抓取时回调取值的仪表
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{metric: name, help: help, kind: "gauge", fn: fn})
+}
+
+// NewCounterFunc 注册在抓取时回调取值的计数器（返回值必须单调递增）
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{metric: name, help: help, kind: "counter", fn: fn})
+}
+
+func (f *funcMetric) name() string { return f.metric }
+
+func (f *funcMetric) write(w io.Writer) {
+	writeHeader(w, f.metric, f.help, f.kind)
+	fmt.Fprintf(w, "%s %s\n", f.metric, formatFloat(f.fn()))
+}
+
+// FuncVec 带标签、在抓取时回调取值的指标，每组标签值对应一个回调（如每个 Bot 的出站队列）
+type FuncVec struct {
+	metric string
+	help   string
+	kind   string
+	labels []string
+	fns    map[string]func() float64
+	mu     sync.RWMutex
+}
+
+// NewGaugeFuncVec 创建并注册带标签的回调仪表
+func (r *Registry) NewGaugeFuncVec(name, help string, labels ...string) *FuncVec {
+	return r.newFuncVec(name, help, "gauge", labels)
+}
+
+// NewCounterFuncVec 创建并注册带标签的回调计数器（返回值必须单调递增）
+func (r *Registry) NewCounterFuncVec(name, help string, labels ...string) *FuncVec {
+	return r.newFuncVec(name, help, "counter", labels)
+}
+
+func (r *Registry) newFuncVec(name, help, kind string, labels []string) *FuncVec {
+	f := &FuncVec{
+		metric: name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		fns:    make(map[string]func() float64),
+	}
+	r.register(f)
+	return f
+}
+
+// Set 设置一组标签值对应的回调，已存在时替换
+func (f *FuncVec) Set(fn func() float64, labelValues ...string) {
+	key := seriesKey(f.labels, labelValues)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fns[key] = fn
+}
+
+// Delete 删除一组标签值对应的回调
+func (f *FuncVec) Delete(labelValues ...string) {
+	key := seriesKey(f.labels, labelValues)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.fns, key)
+}
+
+func (f *FuncVec) name() string { return f.metric }
+
+func (f *FuncVec) write(w io.Writer) {
+	writeHeader(w, f.metric, f.help, f.kind)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, key := range sortedKeys(f.fns) {
+		fmt.Fprintf(w, "%s%s %s\n", f.metric, formatLabels(f.labels, key, "", ""), formatFloat(f.fns[key]()))
+	}
+}
+
+// -------------------- 格式化 --------------------
+
+func seriesKey(labels, values []string) string {
+	if len(values) != len(labels) {
+		panic(fmt.Sprintf("标签数量不匹配: 需要 %d 个, 实际 %d 个", len(labels), len(values)))
+	}
+	return strings.Join(values, labelSep)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels 生成 {a="x",b="y"}，extraName 非空时追加一个额外标签（如 le）
+func formatLabels(labels []string, key, extraName, extraValue string) string {
+	if len(labels) == 0 && extraName == "" {
+		return ""
+	}
+
+	var values []string
+	if len(labels) > 0 {
+		values = strings.Split(key, labelSep)
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, l := range labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `%s="%s"`, l, labelEscaper.Replace(values[i]))
+	}
+	if extraName != "" {
+		if len(labels) > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `%s="%s"`, extraName, extraValue)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
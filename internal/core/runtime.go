@@ -2,10 +2,19 @@ package core
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
+	"sync/atomic"
+	"time"
 
 	contextx "yueling_tg/internal/core/context"
+	"yueling_tg/internal/core/metrics"
+	"yueling_tg/internal/core/sender"
+	"yueling_tg/internal/core/trace"
 	"yueling_tg/internal/middleware"
+	"yueling_tg/pkg/i18n"
+	"yueling_tg/pkg/paginator"
 	"yueling_tg/pkg/plugin"
 	"yueling_tg/pkg/plugin/handler"
 	"yueling_tg/pkg/plugin/provider"
@@ -15,32 +24,90 @@ import (
 )
 
 type Runtime struct {
+	Name           string // Bot 名称，用作指标的 bot 标签
 	Api            *telego.Bot
 	Logger         zerolog.Logger
 	PluginRegistry *plugin.PluginRegistry
-	Middlewares    []middleware.Middleware
+	Sender         *sender.Sender     // 出站请求层（可为空）
+	Container      *handler.Container // 运行时依赖注入容器，同一进程中的每个 Bot 各自一个
+	ChatLocales    *i18n.ChatLocales  // 群组语言设置，注入每个更新的上下文（可为空）
+
+	// UpdateMiddlewares 更新级中间件，包裹整个更新的匹配与分发，可在匹配前拦截更新
+	UpdateMiddlewares []middleware.Middleware
+	// Middlewares 全局中间件，包裹每次匹配成功的处理器调用。
+	// 执行顺序：全局 → 插件级（builder.Use）→ 匹配器级（OnXxx().Use）→ 处理器，
+	// 可通过 plugin.MatcherOf / plugin.PluginOf 读取匹配到的匹配器与插件
+	Middlewares []middleware.Middleware
+	// HandlerTimeout 处理器的默认截止时间，匹配器未设置 Timeout 时使用，0 表示不限制
+	HandlerTimeout time.Duration
+
+	ready   atomic.Bool   // 是否已开始接收更新
+	tasks   chan func()   // 需要在事件循环中执行的任务（如配置热更新回调）
+	stopped chan struct{} // 事件循环退出时关闭
+
+	ctx    context.Context // 长轮询的上下文，Stop 时取消
+	cancel context.CancelFunc
+}
+
+// senderCloseTimeout 停止时等待出站队列排空的最长时间
+const senderCloseTimeout = 10 * time.Second
+
+// Ready 是否已完成启动并开始接收更新
+func (r *Runtime) Ready() bool {
+	return r.ready.Load()
 }
 
 func NewRuntime(api *telego.Bot, logger zerolog.Logger) *Runtime {
-	return &Runtime{
+	r := &Runtime{
 		Api:            api,
 		Logger:         logger,
 		PluginRegistry: plugin.NewPluginRegistry(),
-		Middlewares:    []middleware.Middleware{},
+		Container:      handler.NewContainer(),
+		tasks:          make(chan func()),
+		stopped:        make(chan struct{}),
+		UpdateMiddlewares: []middleware.Middleware{
+			paginator.Middleware(), // 内置：分页按钮回调
+		},
 	}
-}
-
-// Run 启动事件循环
-func (r *Runtime) Run() {
-
-	gc := handler.InitGlobalContainer()
+	r.ctx, r.cancel = context.WithCancel(context.Background())
 
-	// 注册插件列表
-	gc.RegisterStatic(
+	// 注册插件列表与当前上下文
+	r.Container.RegisterStatic(
 		provider.StaticProvider(func() any {
 			return r.PluginRegistry.Plugins()
 		}),
 	)
+	r.Container.RegisterDynamic(provider.DynamicProvider(func(ctx *contextx.Context) any {
+		return ctx
+	}))
+	return r
+}
+
+// Exec 在事件循环中执行 fn 并等待其完成，使其不与处理器并发；
+// 事件循环未运行时直接执行
+func (r *Runtime) Exec(fn func()) {
+	if !r.ready.Load() {
+		fn()
+		return
+	}
+
+	done := make(chan struct{})
+	select {
+	case r.tasks <- func() { defer close(done); fn() }:
+		<-done
+	case <-r.stopped:
+		fn()
+	}
+}
+
+// Stop 停止接收更新，Run 在处理完当前更新、排空出站队列后返回
+func (r *Runtime) Stop() {
+	r.cancel()
+}
+
+// Run 启动事件循环，Stop 后返回
+func (r *Runtime) Run() {
+	defer r.closeSender()
 
 	r.Logger.Info().Msg("正在清理历史消息...")
 
@@ -49,7 +116,7 @@ func (r *Runtime) Run() {
 
 	r.Logger.Info().Msg("Bot 运行中...")
 
-	updates, _ := r.Api.UpdatesViaLongPolling(context.Background(), &telego.GetUpdatesParams{
+	updates, err := r.Api.UpdatesViaLongPolling(r.ctx, &telego.GetUpdatesParams{
 		Offset:  0,
 		Limit:   100, // 建议设置为 100,每次最多获取 100 条更新
 		Timeout: 60,  // 长轮询超时时间(秒),建议设置为 60
@@ -79,29 +146,77 @@ func (r *Runtime) Run() {
 			telego.RemovedChatBoostUpdates,
 		},
 	})
+	if err != nil {
+		r.Logger.Error().Err(err).Msg("启动长轮询失败")
+		return
+	}
 
-	for update := range updates {
-		ctx := contextx.NewContext(context.Background(), r.Api, update)
-
-		gc.RegisterDynamic(provider.DynamicProvider(func(ctx *contextx.Context) any {
-			return ctx
-		}))
+	r.ready.Store(true)
+	defer func() {
+		r.ready.Store(false)
+		close(r.stopped)
+	}()
 
-		if ctx.GetMessage() != nil {
-			r.Logger.Info().
-				Str("user", ctx.GetUsername()).
-				Str("text", ctx.GetMessageText()).
-				Msg("收到消息")
+	for {
+		select {
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			r.handleUpdate(update)
+		case task := <-r.tasks:
+			task()
+		case <-r.ctx.Done():
+			return
 		}
+	}
+}
 
-		handler := middleware.Chain(r.Middlewares, func(ctx *contextx.Context) error {
-			return r.processMatchers(ctx)
-		})
+// closeSender 关闭出站请求层，等待已排队的请求发送完毕
+func (r *Runtime) closeSender() {
+	if r.Sender == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), senderCloseTimeout)
+	defer cancel()
+	if err := r.Sender.Close(ctx); err != nil {
+		r.Logger.Warn().Err(err).Msg("出站队列未能在停止前发送完毕")
+	}
+}
 
-		if err := handler(ctx); err != nil {
-			r.Logger.Error().Err(err).Msg("处理消息失败")
-		}
+// handleUpdate 处理单个更新
+func (r *Runtime) handleUpdate(update telego.Update) {
+	// 每个更新一个根 Span
+	spanCtx, span := trace.Start(context.Background(), "update", trace.WithKind(trace.KindServer))
+	ctx := contextx.NewContext(spanCtx, r.Api, update)
+	handler.Bind(ctx, r.Container)
+	if r.ChatLocales != nil {
+		ctx.Set(contextx.ChatLocales, r.ChatLocales)
+	}
+	metrics.UpdatesTotal.Inc(r.Name, ctx.GetUpdateType())
+	span.SetAttr("update.id", update.UpdateID).
+		SetAttr("update.type", ctx.GetUpdateType()).
+		SetAttr("chat.id", ctx.GetChatID().ID).
+		SetAttr("user.id", ctx.GetUserID())
+
+	logger := ctx.Logger(r.Logger)
+
+	if ctx.GetMessage() != nil {
+		logger.Info().
+			Str("user", ctx.GetUsername()).
+			Str("text", ctx.GetMessageText()).
+			Msg("收到消息")
+	}
+
+	handler := middleware.Chain(r.UpdateMiddlewares, func(ctx *contextx.Context) error {
+		return r.processMatchers(ctx)
+	})
+
+	if err := handler(ctx); err != nil {
+		span.RecordError(err)
+		logger.Error().Err(err).Msg("处理消息失败")
 	}
+	span.End()
 }
 
 // clearPendingUpdates 清理所有待处理的历史消息
@@ -144,25 +259,53 @@ func (r *Runtime) processMatchers(ctx *contextx.Context) error {
 	})
 
 	for _, matcher := range allMatchers {
-		if !matcher.Match(ctx) {
+		matched, err := r.match(ctx, matcher)
+		// 规则或权限检查中的 panic 同样只跳过当前匹配器
+		var pe *middleware.PanicError
+		if errors.As(err, &pe) {
+			metrics.HandlerPanics.Inc(r.Name, pluginIDOf(matcher))
+			ctx.Logger(r.Logger).Error().
+				Str("plugin", pluginIDOf(matcher)).
+				Str("panic", pe.Error()).
+				Str("stack", string(pe.Stack)).
+				Msg("匹配器判定时发生 panic")
+			continue
+		}
+		if !matched {
 			continue
 		}
 
-		pluginName := "unknown"
+		pluginName, pluginID := "unknown", ""
 		if matcher.Plugin() != nil {
 			pluginName = matcher.Plugin().PluginInfo().Name
+			pluginID = matcher.Plugin().PluginInfo().ID
 		}
 
-		r.Logger.Debug().
-			Str("plugin", pluginName).
+		ctx.Storage.Set(contextx.PluginName, pluginName)
+		ctx.Storage.Set(contextx.PluginID, pluginID)
+		plugin.BindMatcher(ctx, matcher)
+
+		logger := ctx.Logger(r.Logger)
+		logger.Debug().
 			Int("priority", matcher.Priority).
 			Msg("匹配成功")
 
-		ctx.Storage.Set(contextx.PluginName, pluginName)
+		metrics.MatcherHits.Inc(r.Name, pluginID)
+		err = r.call(ctx, matcher, pluginID)
 
-		if err := matcher.Call(ctx); err != nil {
-			r.Logger.Error().Err(err).
-				Str("plugin", pluginName).
+		// panic 只影响当前匹配器，后续匹配器照常执行
+		if errors.As(err, &pe) {
+			metrics.HandlerPanics.Inc(r.Name, pluginID)
+			logger.Error().
+				Str("panic", pe.Error()).
+				Str("stack", string(pe.Stack)).
+				Msg("处理器发生 panic")
+			continue
+		}
+
+		if err != nil {
+			metrics.HandlerErrors.Inc(r.Name, pluginID)
+			logger.Error().Err(err).
 				Msg("执行处理器失败")
 
 			if !matcher.Block {
@@ -171,11 +314,94 @@ func (r *Runtime) processMatchers(ctx *contextx.Context) error {
 		}
 
 		if matcher.Block {
-			r.Logger.Debug().
-				Str("plugin", pluginName).
+			logger.Debug().
 				Msg("事件传播被阻止")
 			break
 		}
 	}
 	return nil
 }
+
+// match 判定匹配器，规则与权限检查（可能调用 GetChatMember 等接口）记录在 match Span 中。
+// panic 转换为 *middleware.PanicError 返回
+func (r *Runtime) match(ctx *contextx.Context, matcher *plugin.Matcher) (matched bool, err error) {
+	if !trace.Enabled() {
+		defer middleware.Recover(&err)
+		return matcher.Match(ctx), nil
+	}
+
+	parent := ctx.Ctx
+	spanCtx, span := trace.Start(parent, "match", trace.WithAttrs(
+		"plugin", pluginIDOf(matcher),
+		"priority", matcher.Priority,
+	))
+	ctx.Ctx = spanCtx
+	defer func() {
+		ctx.Ctx = parent
+		span.SetAttr("matched", matched)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	defer middleware.Recover(&err)
+	return matcher.Match(ctx), nil
+}
+
+// call 经过全局、插件级与匹配器级中间件调用处理器，记录耗时指标与 handler Span。
+// panic 转换为 *middleware.PanicError 返回；截止时间通过 ctx.Ctx 传递，到期后处理器需自行返回
+func (r *Runtime) call(ctx *contextx.Context, matcher *plugin.Matcher, pluginID string) error {
+	parent := ctx.Ctx
+	spanCtx, span := trace.Start(parent, "handler "+pluginID, trace.WithAttrs("plugin", pluginID))
+	ctx.Ctx = spanCtx
+
+	timeout := matcher.Timeout
+	if timeout <= 0 {
+		timeout = r.HandlerTimeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx.Ctx, cancel = context.WithTimeout(spanCtx, timeout)
+		defer cancel()
+	}
+	deadline := ctx.Ctx
+
+	start := time.Now()
+	err := r.invoke(ctx, matcher)
+	metrics.HandlerDuration.Observe(time.Since(start).Seconds(), r.Name, pluginID)
+
+	if errors.Is(deadline.Err(), context.DeadlineExceeded) {
+		metrics.HandlerTimeouts.Inc(r.Name, pluginID)
+		if err == nil {
+			err = fmt.Errorf("处理超时 %v: %w", timeout, context.DeadlineExceeded)
+		}
+	}
+
+	ctx.Ctx = parent
+	span.RecordError(err)
+	span.End()
+	return err
+}
+
+// invoke 执行中间件链与处理器，捕获其中的 panic
+func (r *Runtime) invoke(ctx *contextx.Context, matcher *plugin.Matcher) (err error) {
+	defer middleware.Recover(&err)
+	return middleware.Chain(r.middlewaresOf(matcher), func(ctx *contextx.Context) error {
+		return matcher.Call(ctx)
+	})(ctx)
+}
+
+// middlewaresOf 按 全局 → 插件级 → 匹配器级 的顺序返回匹配器的中间件
+func (r *Runtime) middlewaresOf(matcher *plugin.Matcher) []middleware.Middleware {
+	chain := append([]middleware.Middleware(nil), r.Middlewares...)
+	if mp, ok := matcher.Plugin().(plugin.PluginMiddlewareProvider); ok {
+		chain = append(chain, mp.Middlewares()...)
+	}
+	return append(chain, matcher.Middlewares...)
+}
+
+func pluginIDOf(matcher *plugin.Matcher) string {
+	if matcher.Plugin() == nil {
+		return ""
+	}
+	return matcher.Plugin().PluginInfo().ID
+}
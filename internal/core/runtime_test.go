@@ -0,0 +1,214 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	contextx "yueling_tg/internal/core/context"
+	"yueling_tg/internal/core/trace"
+	"yueling_tg/internal/middleware"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/plugin/dsl/permission"
+
+	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/rs/zerolog"
+)
+
+const testToken = "123456789:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// okCaller 对所有请求返回一条消息
+type okCaller struct{}
+
+func (okCaller) Call(context.Context, string, *ta.RequestData) (*ta.Response, error) {
+	return &ta.Response{
+		Ok:     true,
+		Result: []byte(`{"message_id":1,"date":0,"chat":{"id":-100123,"type":"supergroup"}}`),
+	}, nil
+}
+
+type echoPlugin struct {
+	*plugin.Base
+}
+
+func newEchoPlugin() plugin.Plugin {
+	p := &echoPlugin{}
+	builder := plugin.New().Info(&plugin.PluginInfo{ID: "echo", Name: "复读"})
+	builder.OnMessage().Priority(3).Do(func(ctx *contextx.Context) error {
+		_, err := ctx.Api.SendMessage(ctx.Ctx, tu.Message(ctx.GetChatID(), ctx.GetMessageText()))
+		return err
+	})
+	return builder.Go(p)
+}
+
+func TestHandleUpdateTrace(t *testing.T) {
+	config.SetDataDir(t.TempDir())
+
+	exp := trace.NewMemoryExporter()
+	trace.SetExporter(exp, 1)
+	defer trace.Shutdown(context.Background())
+
+	api, err := telego.NewBot(testToken, telego.WithAPICaller(trace.Caller{Next: okCaller{}}), telego.WithDiscardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRuntime(api, zerolog.Nop())
+	r.Middlewares = append(r.Middlewares, middleware.MiddlewareFunc("测试中间件", func(ctx *contextx.Context, next middleware.HandlerFunc) error {
+		return next(ctx)
+	}))
+	if err := r.PluginRegistry.RegisterPlugins(newEchoPlugin()); err != nil {
+		t.Fatal(err)
+	}
+
+	r.handleUpdate(telego.Update{
+		UpdateID: 7,
+		Message: &telego.Message{
+			MessageID: 1,
+			Chat:      telego.Chat{ID: -100123, Type: telego.ChatTypeSupergroup},
+			From:      &telego.User{ID: 42, FirstName: "测试"},
+			Text:      "hello",
+		},
+	})
+	if err := trace.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exp.Spans()
+	byName := make(map[string]*trace.SpanData, len(spans))
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	get := func(name string) *trace.SpanData {
+		t.Helper()
+		s, ok := byName[name]
+		if !ok {
+			var names []string
+			for _, s := range spans {
+				names = append(names, s.Name)
+			}
+			t.Fatalf("span %q not found in %s", name, strings.Join(names, ", "))
+		}
+		return s
+	}
+
+	update := get("update")
+	tests := []struct {
+		name   string
+		parent string
+		kind   trace.Kind
+		attrs  map[string]any
+	}{
+		{"update", "", trace.KindServer, map[string]any{
+			"update.id": 7, "update.type": telego.MessageUpdates, "chat.id": int64(-100123), "user.id": int64(42),
+		}},
+		{"middleware 分页中间件", "update", trace.KindInternal, nil},
+		{"match", "middleware 分页中间件", trace.KindInternal, map[string]any{
+			"plugin": "echo", "priority": 3, "matched": true,
+		}},
+		{"handler echo", "middleware 分页中间件", trace.KindInternal, map[string]any{"plugin": "echo"}},
+		{"middleware 测试中间件", "handler echo", trace.KindInternal, nil},
+		{"telegram.sendMessage", "middleware 测试中间件", trace.KindClient, map[string]any{
+			"rpc.system": "telegram", "rpc.method": "sendMessage",
+		}},
+	}
+	for _, tt := range tests {
+		s := get(tt.name)
+		if s.TraceID != update.TraceID {
+			t.Errorf("%s: trace id = %s, want %s", tt.name, s.TraceID, update.TraceID)
+		}
+		if tt.parent == "" {
+			if s.ParentSpanID.IsValid() {
+				t.Errorf("%s: unexpected parent %s", tt.name, s.ParentSpanID)
+			}
+		} else if want := get(tt.parent).SpanID; s.ParentSpanID != want {
+			t.Errorf("%s: parent = %s, want %s (%s)", tt.name, s.ParentSpanID, want, tt.parent)
+		}
+		if s.Kind != tt.kind {
+			t.Errorf("%s: kind = %v, want %v", tt.name, s.Kind, tt.kind)
+		}
+		if s.Status == trace.StatusError {
+			t.Errorf("%s: status error: %s", tt.name, s.StatusMessage)
+		}
+		for k, want := range tt.attrs {
+			if got := s.Attributes[k]; got != want {
+				t.Errorf("%s: attr %s = %#v, want %#v", tt.name, k, got, want)
+			}
+		}
+	}
+	if len(spans) != len(tests) {
+		t.Errorf("got %d spans, want %d", len(spans), len(tests))
+	}
+}
+
+func TestSampleRatio(t *testing.T) {
+	tests := []struct {
+		ratio float64
+		want  int
+	}{
+		{0, 0},
+		{-1, 0},
+		{1, 20},
+		{2, 20},
+	}
+	for _, tt := range tests {
+		exp := trace.NewMemoryExporter()
+		trace.SetExporter(exp, tt.ratio)
+		for i := 0; i < 20; i++ {
+			_, span := trace.Start(context.Background(), "update")
+			span.End()
+		}
+		trace.Flush(context.Background())
+		if got := len(exp.Spans()); got != tt.want {
+			t.Errorf("sample ratio %v: got %d spans, want %d", tt.ratio, got, tt.want)
+		}
+	}
+	trace.Shutdown(context.Background())
+}
+
+func TestMatchPanicIsolated(t *testing.T) {
+	config.SetDataDir(t.TempDir())
+
+	for _, traced := range []bool{false, true} {
+		if traced {
+			trace.SetExporter(trace.NewMemoryExporter(), 1)
+		}
+
+		api, err := telego.NewBot(testToken, telego.WithAPICaller(okCaller{}), telego.WithDiscardLogger())
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewRuntime(api, zerolog.Nop())
+
+		// 优先级更高的匹配器在权限检查中 panic，不影响之后的匹配器
+		called := false
+		builder := plugin.New().Info(&plugin.PluginInfo{ID: "panic", Name: "panic"})
+		builder.OnMessage().Priority(5).
+			When(permission.PermissionFunc(func(*contextx.Context) bool { panic("boom") })).
+			Block(true).
+			Do(func(*contextx.Context) error { return nil })
+		builder.OnMessage().Priority(1).Do(func(*contextx.Context) error {
+			called = true
+			return nil
+		})
+		if err := r.PluginRegistry.RegisterPlugins(builder.Go(&echoPlugin{})); err != nil {
+			t.Fatal(err)
+		}
+
+		r.handleUpdate(telego.Update{
+			UpdateID: 1,
+			Message: &telego.Message{
+				MessageID: 1,
+				Chat:      telego.Chat{ID: -100123, Type: telego.ChatTypeSupergroup},
+				From:      &telego.User{ID: 42, FirstName: "测试"},
+				Text:      "hello",
+			},
+		})
+		if !called {
+			t.Errorf("traced=%v: matcher after the panicking rule was not called", traced)
+		}
+		trace.Shutdown(context.Background())
+	}
+}
@@ -0,0 +1,61 @@
+package sender
+
+import "time"
+
+// bucket 令牌桶
+type bucket struct {
+	capacity float64   // 桶容量（允许的突发数量）
+	rate     float64   // 每秒补充的令牌数
+	tokens   float64   // 当前令牌数
+	last     time.Time // 上次补充时间
+	blocked  time.Time // 被 429 暂停到的时间点
+	lastUsed time.Time // 最近一次使用时间（用于清理）
+}
+
+func newBucket(capacity int, per time.Duration, now time.Time) *bucket {
+	return &bucket{
+		capacity: float64(capacity),
+		rate:     float64(capacity) / per.Seconds(),
+		tokens:   float64(capacity),
+		last:     now,
+		lastUsed: now,
+	}
+}
+
+// refill 按时间补充令牌
+func (b *bucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+	}
+}
+
+// wait 返回距离下一个可用令牌的等待时间，0 表示当前可用
+func (b *bucket) wait(now time.Time) time.Duration {
+	if now.Before(b.blocked) {
+		return b.blocked.Sub(now)
+	}
+	b.refill(now)
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// take 消耗一个令牌（调用前需确认 wait 为 0）
+func (b *bucket) take(now time.Time) {
+	b.tokens--
+	b.lastUsed = now
+}
+
+// block 暂停桶直到指定时间，并清空令牌
+func (b *bucket) block(until time.Time) {
+	if until.After(b.blocked) {
+		b.blocked = until
+	}
+	b.tokens = 0
+	b.last = until
+}
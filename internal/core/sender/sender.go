@@ -0,0 +1,569 @@
+// Package sender 实现了出站请求层：所有 Bot API 调用都会经过这里。
+//
+// 核心功能：
+//   - 全局令牌桶（默认约 30 条/秒）与按聊天区分的令牌桶（群组默认 20 条/分钟，仅限发消息类请求）
+//   - 遇到 429 时按照 retry_after 自动等待并重试
+//   - 高/普通/低三条优先级队列，查询类请求不排队直接发送
+//   - Close 停止调度并排空队列，Bot 停止时调用
+//
+// Sender 实现了 telegoapi.Caller，通过 telego.WithAPICaller 挂载在 telego.Bot 之下，
+// 因此 Context 的 Send*/Reply* 等方法无需任何改动即可受益。
+package sender
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"yueling_tg/internal/core/log"
+	"yueling_tg/internal/core/metrics"
+
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+var logger = log.NewAPI("出站队列")
+
+// -------------------- 优先级 --------------------
+
+// Priority 请求优先级
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+
+	laneCount = 3
+)
+
+type priorityKey struct{}
+
+// WithPriority 为请求指定优先级，传给 Bot API 方法的 ctx 即可生效
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+func priorityFrom(ctx context.Context, method string) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= PriorityLow && p <= PriorityHigh {
+		return p
+	}
+	switch {
+	case method == "sendChatAction":
+		return PriorityLow
+	case strings.HasPrefix(method, "delete"),
+		strings.HasPrefix(method, "restrict"),
+		strings.HasPrefix(method, "ban"):
+		// 管理类操作通常需要尽快生效
+		return PriorityHigh
+	default:
+		return PriorityNormal
+	}
+}
+
+// -------------------- 配置 --------------------
+
+// Config 限流配置
+type Config struct {
+	GlobalLimit   int           // 全局每秒请求数
+	GroupLimit    int           // 单个群组/频道每分钟消息数
+	PrivateLimit  int           // 单个私聊每秒消息数
+	MaxRetries    int           // 429 最大重试次数
+	MaxRetryAfter time.Duration // retry_after 超过该值时直接放弃
+	IdleTTL       time.Duration // 聊天令牌桶闲置多久后回收
+	Bot           string        // 所属 Bot 名称，用作指标的 bot 标签
+}
+
+// DefaultConfig 默认配置（参考 Telegram 官方限制）
+func DefaultConfig() Config {
+	return Config{
+		GlobalLimit:   30,
+		GroupLimit:    20,
+		PrivateLimit:  1,
+		MaxRetries:    3,
+		MaxRetryAfter: time.Minute,
+		IdleTTL:       10 * time.Minute,
+	}
+}
+
+// -------------------- 统计 --------------------
+
+// Stats 出站队列统计
+type Stats struct {
+	Queued      int            // 当前排队请求数
+	Lanes       [laneCount]int // 各优先级队列长度（低/普通/高）
+	Sent        uint64         // 已发送请求数
+	Retried     uint64         // 因 429 重试次数
+	RateLimited uint64         // 收到的 429 次数
+	Failed      uint64         // 最终失败的请求数
+	ChatBuckets int            // 当前维护的聊天令牌桶数量
+}
+
+// -------------------- 发送器 --------------------
+
+// ErrClosed 出站请求层已关闭
+var ErrClosed = errors.New("出站队列已关闭")
+
+type job struct {
+	chat     string
+	priority Priority
+	ready    chan struct{}
+	err      error // 放行时为空，关闭时未能发送则为 ErrClosed
+	canceled atomic.Bool
+}
+
+// Sender 出站请求层
+type Sender struct {
+	caller ta.Caller
+	cfg    Config
+
+	mu     sync.Mutex
+	global *bucket
+	chats  map[string]*bucket
+	lanes  [laneCount][]*job
+	notify chan struct{}
+	lastGC time.Time
+	closed bool          // 已关闭，不再接受排队请求
+	quit   chan struct{} // Close 时关闭，通知调度循环排空后退出
+	done   chan struct{} // 调度循环退出时关闭
+
+	sent        atomic.Uint64
+	retried     atomic.Uint64
+	rateLimited atomic.Uint64
+	failed      atomic.Uint64
+}
+
+var _ ta.Caller = (*Sender)(nil)
+
+// New 创建出站请求层，caller 为实际发起 HTTP 请求的调用器
+func New(caller ta.Caller, cfg Config) *Sender {
+	def := DefaultConfig()
+	if cfg.GlobalLimit <= 0 {
+		cfg.GlobalLimit = def.GlobalLimit
+	}
+	if cfg.GroupLimit <= 0 {
+		cfg.GroupLimit = def.GroupLimit
+	}
+	if cfg.PrivateLimit <= 0 {
+		cfg.PrivateLimit = def.PrivateLimit
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.MaxRetryAfter <= 0 {
+		cfg.MaxRetryAfter = def.MaxRetryAfter
+	}
+	if cfg.IdleTTL <= 0 {
+		cfg.IdleTTL = def.IdleTTL
+	}
+
+	now := time.Now()
+	s := &Sender{
+		caller: caller,
+		cfg:    cfg,
+		global: newBucket(cfg.GlobalLimit, time.Second, now),
+		chats:  make(map[string]*bucket),
+		notify: make(chan struct{}, 1),
+		lastGC: now,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go s.dispatch()
+
+	return s
+}
+
+// Stats 获取当前统计信息
+func (s *Sender) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := Stats{
+		Sent:        s.sent.Load(),
+		Retried:     s.retried.Load(),
+		RateLimited: s.rateLimited.Load(),
+		Failed:      s.failed.Load(),
+		ChatBuckets: len(s.chats),
+	}
+	for i, lane := range s.lanes {
+		st.Lanes[i] = len(lane)
+		st.Queued += len(lane)
+	}
+	return st
+}
+
+// QueueLen 当前排队请求数
+func (s *Sender) QueueLen() int {
+	return s.Stats().Queued
+}
+
+// Call 实现 telegoapi.Caller
+func (s *Sender) Call(ctx context.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	method := methodName(url)
+
+	// 请求体在每次尝试时都会被读取，先拷贝一份以便重试
+	var body []byte
+	if data.Buffer != nil {
+		body = bytes.Clone(data.Buffer.Bytes())
+	}
+
+	limited := isLimited(method)
+	chat := ""
+	if limited {
+		chat = extractChatID(data.ContentType, body)
+	}
+	// 只有发消息类请求计入聊天令牌桶，编辑、删除与管理操作只受全局限制
+	bucketChat := ""
+	if isMessage(method) {
+		bucketChat = chat
+	}
+
+	for attempt := 0; ; attempt++ {
+		if limited {
+			if err := s.acquire(ctx, bucketChat, priorityFrom(ctx, method)); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := s.caller.Call(ctx, url, &ta.RequestData{
+			ContentType: data.ContentType,
+			Buffer:      bytes.NewBuffer(body),
+		})
+		s.sent.Add(1)
+		metrics.APIRequests.Inc(s.cfg.Bot, method)
+		if err != nil || (resp != nil && !resp.Ok) {
+			metrics.APIErrors.Inc(s.cfg.Bot, method)
+		}
+
+		retryAfter, limitedResp := rateLimitOf(resp, err)
+		if !limitedResp {
+			return resp, err
+		}
+
+		s.rateLimited.Add(1)
+		s.penalize(chat, retryAfter)
+
+		if attempt >= s.cfg.MaxRetries || retryAfter > s.cfg.MaxRetryAfter {
+			s.failed.Add(1)
+			logger.Warn().
+				Str("method", method).
+				Str("chat", chat).
+				Dur("retry_after", retryAfter).
+				Msg("触发限流，放弃重试")
+			return resp, err
+		}
+
+		s.retried.Add(1)
+		logger.Warn().
+			Str("method", method).
+			Str("chat", chat).
+			Dur("retry_after", retryAfter).
+			Int("attempt", attempt+1).
+			Msg("触发限流，等待后重试")
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(retryAfter):
+		}
+	}
+}
+
+// Close 停止接受新的排队请求，等待已排队的请求按限流发送完毕后停止调度循环。
+// ctx 结束时仍未发送的请求以 ErrClosed 失败，此时返回 ctx 的错误
+func (s *Sender) Close(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.quit)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	for p := range s.lanes {
+		for _, j := range s.lanes[p] {
+			j.err = ErrClosed
+			close(j.ready)
+		}
+		s.lanes[p] = nil
+	}
+	s.mu.Unlock()
+	s.wake()
+
+	<-s.done
+	return ctx.Err()
+}
+
+// acquire 排队等待令牌
+func (s *Sender) acquire(ctx context.Context, chat string, p Priority) error {
+	j := &job{chat: chat, priority: p, ready: make(chan struct{})}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	s.lanes[p] = append(s.lanes[p], j)
+	s.mu.Unlock()
+	s.wake()
+
+	select {
+	case <-j.ready:
+		return j.err
+	case <-ctx.Done():
+		j.canceled.Store(true)
+		s.wake()
+		return fmt.Errorf("等待发送队列: %w", ctx.Err())
+	}
+}
+
+// penalize 收到 429 后暂停对应令牌桶
+func (s *Sender) penalize(chat string, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until := time.Now().Add(retryAfter)
+	if chat == "" {
+		s.global.block(until)
+		return
+	}
+	s.chatBucket(chat, time.Now()).block(until)
+}
+
+func (s *Sender) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch 调度循环：按优先级放行满足限流条件的请求，关闭后排空队列再退出
+func (s *Sender) dispatch() {
+	defer close(s.done)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		wait := s.release(time.Now())
+		closed := s.closed
+		s.mu.Unlock()
+
+		if wait < 0 {
+			if closed {
+				return
+			}
+			// 队列为空，等待新请求
+			select {
+			case <-s.notify:
+			case <-s.quit:
+			}
+			continue
+		}
+		if wait == 0 {
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-s.notify:
+		}
+	}
+}
+
+// release 放行一个请求；返回 0 表示已放行，>0 表示需要等待的时间，<0 表示队列为空
+func (s *Sender) release(now time.Time) time.Duration {
+	s.gc(now)
+
+	if s.queued() == 0 {
+		return -1
+	}
+
+	if wait := s.global.wait(now); wait > 0 {
+		return wait
+	}
+
+	minWait := time.Duration(-1)
+	for p := laneCount - 1; p >= 0; p-- {
+		lane := s.lanes[p]
+		for i := 0; i < len(lane); i++ {
+			j := lane[i]
+			if j.canceled.Load() {
+				lane = append(lane[:i], lane[i+1:]...)
+				i--
+				continue
+			}
+
+			wait := time.Duration(0)
+			var b *bucket
+			if j.chat != "" {
+				b = s.chatBucket(j.chat, now)
+				wait = b.wait(now)
+			}
+			if wait > 0 {
+				if minWait < 0 || wait < minWait {
+					minWait = wait
+				}
+				continue
+			}
+
+			s.global.take(now)
+			if b != nil {
+				b.take(now)
+			}
+			s.lanes[p] = append(lane[:i], lane[i+1:]...)
+			close(j.ready)
+			return 0
+		}
+		s.lanes[p] = lane
+	}
+
+	if minWait < 0 && s.queued() == 0 {
+		return -1
+	}
+	if minWait < 0 {
+		return time.Millisecond
+	}
+	return minWait
+}
+
+func (s *Sender) queued() int {
+	n := 0
+	for _, lane := range s.lanes {
+		n += len(lane)
+	}
+	return n
+}
+
+// chatBucket 获取或创建聊天令牌桶
+func (s *Sender) chatBucket(chat string, now time.Time) *bucket {
+	b, ok := s.chats[chat]
+	if !ok {
+		if isGroupChat(chat) {
+			b = newBucket(s.cfg.GroupLimit, time.Minute, now)
+		} else {
+			b = newBucket(s.cfg.PrivateLimit, time.Second, now)
+		}
+		s.chats[chat] = b
+	}
+	return b
+}
+
+// gc 回收长时间闲置的聊天令牌桶
+func (s *Sender) gc(now time.Time) {
+	if now.Sub(s.lastGC) < time.Minute {
+		return
+	}
+	s.lastGC = now
+
+	for chat, b := range s.chats {
+		if now.Sub(b.lastUsed) > s.cfg.IdleTTL && now.After(b.blocked) {
+			delete(s.chats, chat)
+		}
+	}
+}
+
+// -------------------- 工具函数 --------------------
+
+// methodName 从请求地址中提取方法名，如 .../bot<token>/sendMessage
+func methodName(url string) string {
+	if idx := strings.LastIndex(url, "/"); idx != -1 {
+		return url[idx+1:]
+	}
+	return url
+}
+
+// isLimited 判断方法是否需要排队限流，查询与应答类请求直接放行
+func isLimited(method string) bool {
+	switch {
+	case strings.HasPrefix(method, "get"),
+		strings.HasPrefix(method, "answer"),
+		strings.HasPrefix(method, "set"),
+		method == "logOut", method == "close":
+		return false
+	default:
+		return true
+	}
+}
+
+// isMessage 判断方法是否为发消息类请求，只有这类请求计入聊天令牌桶
+func isMessage(method string) bool {
+	return strings.HasPrefix(method, "send") ||
+		strings.HasPrefix(method, "copy") ||
+		strings.HasPrefix(method, "forward")
+}
+
+var (
+	jsonChatID      = regexp.MustCompile(`"chat_id"\s*:\s*("[^"]*"|-?\d+)`)
+	multipartChatID = regexp.MustCompile(`name="chat_id"\r\n\r\n([^\r\n]+)`)
+)
+
+// extractChatID 从请求体中提取 chat_id，用于区分聊天令牌桶
+func extractChatID(contentType string, body []byte) string {
+	var m [][]byte
+	if strings.HasPrefix(contentType, ta.ContentTypeJSON) {
+		m = jsonChatID.FindSubmatch(body)
+	} else {
+		m = multipartChatID.FindSubmatch(body)
+	}
+	if len(m) < 2 {
+		return ""
+	}
+	return strings.Trim(string(m[1]), `"`)
+}
+
+// isGroupChat 群组/频道 ID 为负数，@username 形式只能是频道或超级群
+func isGroupChat(chat string) bool {
+	if strings.HasPrefix(chat, "@") {
+		return true
+	}
+	id, err := strconv.ParseInt(chat, 10, 64)
+	return err == nil && id < 0
+}
+
+// rateLimitOf 判断响应是否为 429，并返回需要等待的时间
+func rateLimitOf(resp *ta.Response, err error) (time.Duration, bool) {
+	var apiErr *ta.Error
+	switch {
+	case resp != nil && resp.Error != nil:
+		apiErr = resp.Error
+	case err != nil:
+		if !errors.As(err, &apiErr) {
+			return 0, false
+		}
+	default:
+		return 0, false
+	}
+
+	if apiErr.ErrorCode != 429 {
+		return 0, false
+	}
+
+	retryAfter := time.Second
+	if apiErr.Parameters != nil && apiErr.Parameters.RetryAfter > 0 {
+		retryAfter = time.Duration(apiErr.Parameters.RetryAfter) * time.Second
+	}
+	return retryAfter, true
+}
@@ -0,0 +1,290 @@
+package sender
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+func TestBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := newBucket(2, time.Second, now)
+
+	tests := []struct {
+		name string
+		at   time.Duration // 相对 now 的时间
+		take bool
+		want time.Duration
+	}{
+		{"满桶可用", 0, true, 0},
+		{"剩余一个令牌", 0, true, 0},
+		{"令牌耗尽", 0, false, 500 * time.Millisecond},
+		{"补充一半", 250 * time.Millisecond, false, 250 * time.Millisecond},
+		{"补充完成", 500 * time.Millisecond, true, 0},
+		{"补充不超过容量", 10 * time.Second, false, 0},
+	}
+	for _, tt := range tests {
+		at := now.Add(tt.at)
+		if got := b.wait(at); got != tt.want {
+			t.Fatalf("%s: wait = %v, want %v", tt.name, got, tt.want)
+		}
+		if tt.take {
+			b.take(at)
+		}
+	}
+	if b.tokens != 2 {
+		t.Fatalf("tokens = %v, want 2", b.tokens)
+	}
+
+	// 429 暂停期间不可用，暂停结束后从空桶开始补充
+	until := now.Add(20 * time.Second)
+	b.block(until)
+	if got := b.wait(now.Add(15 * time.Second)); got != 5*time.Second {
+		t.Fatalf("blocked wait = %v, want 5s", got)
+	}
+	if got := b.wait(until); got != 500*time.Millisecond {
+		t.Fatalf("wait after block = %v, want 500ms", got)
+	}
+}
+
+func TestMethodClasses(t *testing.T) {
+	tests := []struct {
+		method  string
+		limited bool
+		message bool
+	}{
+		{"sendMessage", true, true},
+		{"sendPhoto", true, true},
+		{"copyMessage", true, true},
+		{"forwardMessages", true, true},
+		{"editMessageText", true, false},
+		{"deleteMessage", true, false},
+		{"restrictChatMember", true, false},
+		{"banChatMember", true, false},
+		{"getMe", false, false},
+		{"answerCallbackQuery", false, false},
+		{"setMyCommands", false, false},
+		{"close", false, false},
+	}
+	for _, tt := range tests {
+		if got := isLimited(tt.method); got != tt.limited {
+			t.Errorf("isLimited(%q) = %v, want %v", tt.method, got, tt.limited)
+		}
+		if got := isMessage(tt.method); got != tt.message {
+			t.Errorf("isMessage(%q) = %v, want %v", tt.method, got, tt.message)
+		}
+	}
+}
+
+func TestExtractChatID(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"JSON 数字", ta.ContentTypeJSON, `{"chat_id":-100123,"text":"hi"}`, "-100123"},
+		{"JSON 用户名", ta.ContentTypeJSON, `{"chat_id": "@channel"}`, "@channel"},
+		{"multipart", "multipart/form-data; boundary=x", "--x\r\nContent-Disposition: form-data; name=\"chat_id\"\r\n\r\n42\r\n--x--", "42"},
+		{"没有 chat_id", ta.ContentTypeJSON, `{"offset":1}`, ""},
+	}
+	for _, tt := range tests {
+		if got := extractChatID(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("%s: extractChatID = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsGroupChat(t *testing.T) {
+	tests := map[string]bool{
+		"-100123":  true,
+		"@channel": true,
+		"42":       false,
+		"":         false,
+	}
+	for chat, want := range tests {
+		if got := isGroupChat(chat); got != want {
+			t.Errorf("isGroupChat(%q) = %v, want %v", chat, got, want)
+		}
+	}
+}
+
+// fakeCaller 按顺序返回预设的响应
+type fakeCaller struct {
+	mu        sync.Mutex
+	responses []*ta.Response
+	calls     int
+}
+
+func (f *fakeCaller) Call(_ context.Context, _ string, _ *ta.RequestData) (*ta.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if len(f.responses) == 0 {
+		return &ta.Response{Ok: true}, nil
+	}
+	resp := f.responses[0]
+	f.responses = f.responses[1:]
+	return resp, nil
+}
+
+func tooManyRequests(retryAfter int) *ta.Response {
+	return &ta.Response{Error: &ta.Error{
+		ErrorCode:  429,
+		Parameters: &ta.ResponseParameters{RetryAfter: retryAfter},
+	}}
+}
+
+func request(chat string) *ta.RequestData {
+	return &ta.RequestData{
+		ContentType: ta.ContentTypeJSON,
+		Buffer:      bytes.NewBufferString(`{"chat_id":` + chat + `}`),
+	}
+}
+
+func TestRetryAfter429(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []*ta.Response
+		cfg       Config
+		wantOk    bool
+		wantCalls int
+		wantStats Stats
+	}{
+		{
+			name:      "重试后成功",
+			responses: []*ta.Response{tooManyRequests(1)},
+			cfg:       Config{MaxRetries: 3},
+			wantOk:    true,
+			wantCalls: 2,
+			wantStats: Stats{Sent: 2, Retried: 1, RateLimited: 1},
+		},
+		{
+			name:      "超过最大重试次数",
+			responses: []*ta.Response{tooManyRequests(1), tooManyRequests(1)},
+			cfg:       Config{MaxRetries: 1},
+			wantCalls: 2,
+			wantStats: Stats{Sent: 2, Retried: 1, RateLimited: 2, Failed: 1},
+		},
+		{
+			name:      "等待时间过长直接放弃",
+			responses: []*ta.Response{tooManyRequests(120)},
+			cfg:       Config{MaxRetries: 3, MaxRetryAfter: time.Minute},
+			wantCalls: 1,
+			wantStats: Stats{Sent: 1, RateLimited: 1, Failed: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller := &fakeCaller{responses: tt.responses}
+			s := New(caller, tt.cfg)
+
+			resp, err := s.Call(context.Background(), "https://api/bot/sendMessage", request("42"))
+			if err != nil {
+				t.Fatalf("Call: %v", err)
+			}
+			if resp.Ok != tt.wantOk {
+				t.Errorf("Ok = %v, want %v", resp.Ok, tt.wantOk)
+			}
+			if caller.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", caller.calls, tt.wantCalls)
+			}
+			st := s.Stats()
+			st.ChatBuckets = 0
+			if st != tt.wantStats {
+				t.Errorf("stats = %+v, want %+v", st, tt.wantStats)
+			}
+		})
+	}
+}
+
+func TestChatBucketOnlyForMessages(t *testing.T) {
+	s := New(&fakeCaller{}, Config{GroupLimit: 1})
+	call := func(method string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, err := s.Call(ctx, "https://api/bot/"+method, request("-100123"))
+		return err
+	}
+
+	// 群组每分钟只允许一条消息，编辑、删除与管理操作不受影响
+	if err := call("sendMessage"); err != nil {
+		t.Fatalf("first sendMessage: %v", err)
+	}
+	for _, method := range []string{"editMessageText", "deleteMessage", "restrictChatMember", "banChatMember"} {
+		if err := call(method); err != nil {
+			t.Errorf("%s: %v", method, err)
+		}
+	}
+	if err := call("sendMessage"); err == nil {
+		t.Error("second sendMessage should wait for the chat bucket")
+	}
+}
+
+func TestClose(t *testing.T) {
+	// 私聊每秒一条：第二条需要排队约 1 秒，关闭时应等待其发送完毕
+	caller := &fakeCaller{}
+	s := New(caller, Config{})
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := s.Call(context.Background(), "https://api/bot/sendMessage", request("42"))
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("queued call: %v", err)
+		}
+	}
+	if caller.calls != 2 {
+		t.Errorf("calls = %d, want 2", caller.calls)
+	}
+	if _, err := s.Call(context.Background(), "https://api/bot/sendMessage", request("42")); !errors.Is(err, ErrClosed) {
+		t.Errorf("Call after Close = %v, want ErrClosed", err)
+	}
+	// 查询类请求不经过队列
+	if _, err := s.Call(context.Background(), "https://api/bot/getMe", request("42")); err != nil {
+		t.Errorf("getMe after Close: %v", err)
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	// 群组每分钟一条：第二条在关闭超时前无法发送，以 ErrClosed 失败
+	s := New(&fakeCaller{}, Config{GroupLimit: 1})
+	if _, err := s.Call(context.Background(), "https://api/bot/sendMessage", request("-100123")); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.Call(context.Background(), "https://api/bot/sendMessage", request("-100123"))
+		errs <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := s.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close = %v, want deadline exceeded", err)
+	}
+	if err := <-errs; !errors.Is(err, ErrClosed) {
+		t.Errorf("queued call = %v, want ErrClosed", err)
+	}
+	select {
+	case <-s.done:
+	default:
+		t.Error("dispatch loop should have stopped")
+	}
+}
@@ -0,0 +1,156 @@
+// Package server 提供可选的管理 HTTP 服务。
+//
+// 暴露的接口：
+//   - /metrics  Prometheus 文本格式指标
+//   - /healthz  存活检查：所有插件 HealthCheck 通过时返回 200
+//   - /readyz   就绪检查：运行时已开始接收更新且插件健康时返回 200
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"yueling_tg/internal/core/log"
+	"yueling_tg/internal/core/metrics"
+)
+
+var logger = log.NewSystem("管理服务")
+
+// Config 管理服务配置（config.toml 中的 [server] 段）
+type Config struct {
+	Enabled bool   `mapstructure:"enabled" doc:"是否启用 /metrics、/healthz、/readyz"`
+	Listen  string `mapstructure:"listen" doc:"监听地址" validate:"required"`
+}
+
+// DefaultConfig 返回默认配置，默认不启用且只监听本机
+func DefaultConfig() Config {
+	return Config{
+		Enabled: false,
+		Listen:  "127.0.0.1:9090",
+	}
+}
+
+// Checks 健康检查数据来源
+type Checks struct {
+	Health func() map[string]error // 插件健康检查结果，key 为插件 ID
+	Ready  func() bool             // 运行时是否就绪
+}
+
+// Server 管理 HTTP 服务
+type Server struct {
+	cfg      Config
+	checks   Checks
+	registry *metrics.Registry
+	http     *http.Server
+}
+
+// New 创建管理服务
+func New(cfg Config, registry *metrics.Registry, checks Checks) *Server {
+	if cfg.Listen == "" {
+		cfg.Listen = DefaultConfig().Listen
+	}
+	if registry == nil {
+		registry = metrics.Default
+	}
+
+	s := &Server{
+		cfg:      cfg,
+		checks:   checks,
+		registry: registry,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.handleMetrics)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+
+	s.http = &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Start 在后台启动服务，监听失败时立即返回错误
+func (s *Server) Start() error {
+	ln, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return err
+	}
+
+	logger.Info().Str("listen", ln.Addr().String()).Msg("管理服务已启动")
+
+	go func() {
+		if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error().Err(err).Msg("管理服务异常退出")
+		}
+	}()
+	return nil
+}
+
+// Shutdown 优雅关闭服务
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+// -------------------- 接口 --------------------
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	s.registry.WritePrometheus(w)
+}
+
+// healthReport 健康检查响应
+type healthReport struct {
+	Status  string            `json:"status"`
+	Ready   *bool             `json:"ready,omitempty"`
+	Plugins map[string]string `json:"plugins,omitempty"`
+}
+
+func (s *Server) health() (map[string]string, bool) {
+	if s.checks.Health == nil {
+		return nil, true
+	}
+
+	results := s.checks.Health()
+	plugins := make(map[string]string, len(results))
+	healthy := true
+	for id, err := range results {
+		if err != nil {
+			plugins[id] = err.Error()
+			healthy = false
+		} else {
+			plugins[id] = "ok"
+		}
+	}
+	return plugins, healthy
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	plugins, healthy := s.health()
+	writeReport(w, healthy, healthReport{Plugins: plugins})
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	plugins, healthy := s.health()
+	ready := s.checks.Ready == nil || s.checks.Ready()
+	writeReport(w, healthy && ready, healthReport{Ready: &ready, Plugins: plugins})
+}
+
+func writeReport(w http.ResponseWriter, ok bool, report healthReport) {
+	status := http.StatusOK
+	report.Status = "ok"
+	if !ok {
+		status = http.StatusServiceUnavailable
+		report.Status = "unavailable"
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,44 @@
+package trace
+
+import (
+	"context"
+	"strings"
+
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+// Caller 包装 telegoapi.Caller，为每个出站 Bot API 请求创建 Span
+type Caller struct {
+	Next ta.Caller
+}
+
+var _ ta.Caller = Caller{}
+
+// Call 实现 telegoapi.Caller
+func (c Caller) Call(ctx context.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	method := url
+	if idx := strings.LastIndex(url, "/"); idx != -1 {
+		method = url[idx+1:]
+	}
+
+	// 只追踪属于某个更新的请求，长轮询等后台请求没有父 Span
+	if SpanFromContext(ctx) == nil {
+		return c.Next.Call(ctx, url, data)
+	}
+
+	ctx, span := Start(ctx, "telegram."+method,
+		WithKind(KindClient),
+		WithAttrs("rpc.system", "telegram", "rpc.method", method),
+	)
+	defer span.End()
+
+	resp, err := c.Next.Call(ctx, url, data)
+	switch {
+	case err != nil:
+		span.RecordError(err)
+	case resp != nil && !resp.Ok && resp.Error != nil:
+		span.SetAttr("telegram.error_code", resp.Error.ErrorCode)
+		span.SetStatus(StatusError, resp.Error.Description)
+	}
+	return resp, err
+}
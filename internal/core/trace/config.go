@@ -0,0 +1,209 @@
+package trace
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"yueling_tg/internal/core/log"
+	"yueling_tg/pkg/config"
+)
+
+var logger = log.NewSystem("链路追踪")
+
+// Config 追踪配置（config.toml 中的 [trace] 段）
+type Config struct {
+	Enabled     bool                     `mapstructure:"enabled" doc:"是否启用"`
+	Exporter    string                   `mapstructure:"exporter" doc:"导出器" validate:"oneof=stdout otlp"`
+	Endpoint    string                   `mapstructure:"endpoint" doc:"OTLP 地址，如 http://localhost:4318/v1/traces" validate:"url"`
+	Headers     map[string]config.Secret `mapstructure:"headers" doc:"OTLP 附加请求头（如鉴权令牌）"`
+	ServiceName string                   `mapstructure:"service_name" doc:"服务名"`
+	SampleRatio float64                  `mapstructure:"sample_ratio" doc:"采样率 0~1" validate:"min=0,max=1"`
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		Enabled:     false,
+		Exporter:    "stdout",
+		Endpoint:    "http://localhost:4318/v1/traces",
+		ServiceName: "yueling_tg",
+		SampleRatio: 1,
+	}
+}
+
+// Setup 按配置启用全局追踪，未启用时为空操作
+func Setup(cfg Config) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var exp Exporter
+	switch strings.ToLower(cfg.Exporter) {
+	case "", "stdout":
+		exp = NewWriterExporter(os.Stdout)
+	case "otlp":
+		headers := make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			headers[k] = v.Value()
+		}
+		exp = NewOTLPExporter(cfg.Endpoint, cfg.ServiceName, headers)
+	default:
+		return fmt.Errorf("未知的追踪导出器: %s", cfg.Exporter)
+	}
+
+	SetExporter(exp, cfg.SampleRatio)
+	logger.Info().Str("exporter", cfg.Exporter).Float64("sample_ratio", cfg.SampleRatio).Msg("链路追踪已启用")
+	return nil
+}
+
+// SetExporter 使用指定导出器启用全局追踪（测试中可传入 MemoryExporter），exp 为 nil 时关闭追踪；
+// sampleRatio 为 0 时不采样任何新追踪
+func SetExporter(exp Exporter, sampleRatio float64) {
+	sampleRatio = min(max(sampleRatio, 0), 1)
+
+	var t *Tracer
+	if exp != nil {
+		t = &Tracer{
+			processor:   newBatchProcessor(exp),
+			sampleRatio: sampleRatio,
+		}
+	}
+
+	if old := global.Swap(t); old != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		old.processor.shutdown(ctx)
+	}
+}
+
+// Flush 立即导出缓冲中的 Span
+func Flush(ctx context.Context) error {
+	if t := global.Load(); t != nil {
+		return t.processor.flush(ctx)
+	}
+	return nil
+}
+
+// Shutdown 导出剩余 Span 并关闭追踪
+func Shutdown(ctx context.Context) error {
+	t := global.Swap(nil)
+	if t == nil {
+		return nil
+	}
+	return t.processor.shutdown(ctx)
+}
+
+// -------------------- 批量处理 --------------------
+
+const (
+	batchSize     = 256
+	batchInterval = 5 * time.Second
+	queueSize     = 4096
+)
+
+// batchProcessor 缓冲结束的 Span，按数量或时间批量导出
+type batchProcessor struct {
+	exporter Exporter
+	queue    chan *SpanData
+	flushReq chan chan error
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newBatchProcessor(exp Exporter) *batchProcessor {
+	p := &batchProcessor{
+		exporter: exp,
+		queue:    make(chan *SpanData, queueSize),
+		flushReq: make(chan chan error),
+		done:     make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// onEnd 队列已满时丢弃，避免阻塞处理流程
+func (p *batchProcessor) onEnd(s *SpanData) {
+	select {
+	case p.queue <- s:
+	default:
+	}
+}
+
+func (p *batchProcessor) loop() {
+	ticker := time.NewTicker(batchInterval)
+	defer ticker.Stop()
+
+	batch := make([]*SpanData, 0, batchSize)
+	export := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		err := p.exporter.Export(ctx, batch)
+		if err != nil {
+			logger.Warn().Err(err).Int("spans", len(batch)).Msg("导出 Span 失败")
+		}
+		batch = make([]*SpanData, 0, batchSize)
+		return err
+	}
+	drain := func() {
+		for {
+			select {
+			case s := <-p.queue:
+				batch = append(batch, s)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case s := <-p.queue:
+			batch = append(batch, s)
+			if len(batch) >= batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case reply := <-p.flushReq:
+			drain()
+			reply <- export()
+		case <-p.done:
+			drain()
+			export()
+			return
+		}
+	}
+}
+
+func (p *batchProcessor) flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+	select {
+	case p.flushReq <- reply:
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *batchProcessor) shutdown(ctx context.Context) error {
+	err := p.flush(ctx)
+	p.once.Do(func() { close(p.done) })
+	if shutdownErr := p.exporter.Shutdown(ctx); shutdownErr != nil {
+		return shutdownErr
+	}
+	return err
+}
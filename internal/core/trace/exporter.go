@@ -0,0 +1,212 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Exporter Span 导出器
+type Exporter interface {
+	Export(ctx context.Context, spans []*SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+// -------------------- 内存导出器 --------------------
+
+// MemoryExporter 将 Span 保存在内存中，用于测试与调试
+type MemoryExporter struct {
+	spans []*SpanData
+	mu    sync.Mutex
+}
+
+// NewMemoryExporter 创建内存导出器
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (m *MemoryExporter) Export(_ context.Context, spans []*SpanData) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spans = append(m.spans, spans...)
+	return nil
+}
+
+func (m *MemoryExporter) Shutdown(context.Context) error { return nil }
+
+// Spans 返回已导出的全部 Span
+func (m *MemoryExporter) Spans() []*SpanData {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*SpanData(nil), m.spans...)
+}
+
+// Reset 清空已导出的 Span
+func (m *MemoryExporter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spans = nil
+}
+
+// -------------------- stdout 导出器 --------------------
+
+// WriterExporter 每个 Span 输出一行 JSON
+type WriterExporter struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewWriterExporter 创建输出到 w 的导出器（stdout 导出器即 NewWriterExporter(os.Stdout)）
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+type jsonSpan struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	Name       string         `json:"name"`
+	Start      time.Time      `json:"start"`
+	DurationMs float64        `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+func (e *WriterExporter) Export(_ context.Context, spans []*SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.w)
+	for _, s := range spans {
+		js := jsonSpan{
+			TraceID:    s.TraceID.String(),
+			SpanID:     s.SpanID.String(),
+			Name:       s.Name,
+			Start:      s.Start,
+			DurationMs: float64(s.Duration().Microseconds()) / 1000,
+			Attributes: s.Attributes,
+		}
+		if s.ParentSpanID.IsValid() {
+			js.ParentID = s.ParentSpanID.String()
+		}
+		if s.Status == StatusError {
+			js.Error = s.StatusMessage
+		}
+		if err := enc.Encode(js); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *WriterExporter) Shutdown(context.Context) error { return nil }
+
+// -------------------- OTLP/HTTP 导出器 --------------------
+
+// OTLPExporter 以 OTLP/HTTP JSON 格式发送到采集器（如 http://localhost:4318/v1/traces）
+type OTLPExporter struct {
+	endpoint string
+	service  string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewOTLPExporter 创建 OTLP 导出器
+func NewOTLPExporter(endpoint, service string, headers map[string]string) *OTLPExporter {
+	return &OTLPExporter{
+		endpoint: endpoint,
+		service:  service,
+		headers:  headers,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []*SpanData) error {
+	otlpSpans := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           s.TraceID.String(),
+			"spanId":            s.SpanID.String(),
+			"name":              s.Name,
+			"kind":              int(s.Kind),
+			"startTimeUnixNano": strconv.FormatInt(s.Start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.End.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.Attributes),
+			"status":            map[string]any{"code": int(s.Status), "message": s.StatusMessage},
+		}
+		if s.ParentSpanID.IsValid() {
+			span["parentSpanId"] = s.ParentSpanID.String()
+		}
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	payload := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": map[string]any{
+				"attributes": otlpAttributes(map[string]any{"service.name": e.service}),
+			},
+			"scopeSpans": []any{map[string]any{
+				"scope": map[string]any{"name": "yueling_tg"},
+				"spans": otlpSpans,
+			}},
+		}},
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化 Span 失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送 Span 失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("采集器返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(context.Context) error { return nil }
+
+// otlpAttributes 转换为 OTLP KeyValue 列表
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	list := make([]map[string]any, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]any
+		switch val := v.(type) {
+		case string:
+			value = map[string]any{"stringValue": val}
+		case bool:
+			value = map[string]any{"boolValue": val}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(val)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(val, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": val}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(val)}
+		}
+		list = append(list, map[string]any{"key": k, "value": value})
+	}
+	return list
+}
@@ -0,0 +1,237 @@
+// Package trace 提供 OpenTelemetry 风格的链路追踪。
+//
+// 核心功能：
+//   - 每个更新一个根 Span，中间件、匹配器判定、处理器调用与出站 Bot API 请求均为子 Span
+//   - Span 通过 context.Context 传递，Context.Ctx 即携带当前 Span
+//   - 支持 stdout、OTLP/HTTP（JSON）与内存导出器，未启用时所有操作均为空操作
+package trace
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TraceID 追踪 ID
+type TraceID [16]byte
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// IsValid 是否为有效 ID
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+// SpanID Span ID
+type SpanID [8]byte
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// IsValid 是否为有效 ID
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+// Kind Span 类型
+type Kind int
+
+const (
+	KindInternal Kind = iota + 1
+	KindServer
+	KindClient
+)
+
+// Status Span 状态
+type Status int
+
+const (
+	StatusUnset Status = iota
+	StatusOK
+	StatusError
+)
+
+// SpanData 已结束的 Span，交给导出器
+type SpanData struct {
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID
+	Name          string
+	Kind          Kind
+	Start         time.Time
+	End           time.Time
+	Attributes    map[string]any
+	Status        Status
+	StatusMessage string
+}
+
+// Duration Span 耗时
+func (d *SpanData) Duration() time.Duration {
+	return d.End.Sub(d.Start)
+}
+
+// Span 进行中的 Span，nil Span 的所有方法都是空操作
+type Span struct {
+	tracer *Tracer
+	data   SpanData
+	ended  atomic.Bool
+	mu     sync.Mutex
+}
+
+// SetAttr 设置属性
+func (s *Span) SetAttr(key string, value any) *Span {
+	if s == nil {
+		return s
+	}
+	s.mu.Lock()
+	s.data.Attributes[key] = value
+	s.mu.Unlock()
+	return s
+}
+
+// RecordError 记录错误并将状态置为失败，err 为 nil 时忽略
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Status = StatusError
+	s.data.StatusMessage = err.Error()
+	s.mu.Unlock()
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(status Status, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.data.Status = status
+	s.data.StatusMessage = message
+	s.mu.Unlock()
+}
+
+// End 结束 Span 并交给导出器，重复调用只生效一次
+func (s *Span) End() {
+	if s == nil || !s.ended.CompareAndSwap(false, true) {
+		return
+	}
+	s.mu.Lock()
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.processor.onEnd(&data)
+}
+
+// TraceID 返回追踪 ID，nil Span 返回空 ID
+func (s *Span) TraceID() TraceID {
+	if s == nil {
+		return TraceID{}
+	}
+	return s.data.TraceID
+}
+
+// SpanID 返回 Span ID
+func (s *Span) SpanID() SpanID {
+	if s == nil {
+		return SpanID{}
+	}
+	return s.data.SpanID
+}
+
+// -------------------- Context 传递 --------------------
+
+type spanKey struct{}
+
+// ContextWithSpan 将 Span 放入 ctx
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// SpanFromContext 取出 ctx 中的当前 Span，没有时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// -------------------- Tracer --------------------
+
+// Tracer 创建 Span
+type Tracer struct {
+	processor   *batchProcessor
+	sampleRatio float64
+}
+
+// Option Span 选项
+type Option func(*SpanData)
+
+// WithKind 指定 Span 类型
+func WithKind(k Kind) Option {
+	return func(d *SpanData) { d.Kind = k }
+}
+
+// WithAttrs 指定初始属性（键值交替）
+func WithAttrs(kv ...any) Option {
+	return func(d *SpanData) {
+		for i := 0; i+1 < len(kv); i += 2 {
+			d.Attributes[fmt.Sprint(kv[i])] = kv[i+1]
+		}
+	}
+}
+
+// Start 开始一个 Span：ctx 中有父 Span 时作为其子 Span，否则按采样率决定是否开启新追踪。
+// 未采样或追踪未启用时返回原 ctx 与 nil Span
+func (t *Tracer) Start(ctx context.Context, name string, opts ...Option) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanFromContext(ctx)
+	if parent == nil && t.sampleRatio < 1 && rand.Float64() >= t.sampleRatio {
+		return ctx, nil
+	}
+
+	s := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			Kind:       KindInternal,
+			Start:      time.Now(),
+			Attributes: make(map[string]any),
+		},
+	}
+	crand.Read(s.data.SpanID[:])
+	if parent != nil {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentSpanID = parent.data.SpanID
+	} else {
+		crand.Read(s.data.TraceID[:])
+	}
+
+	for _, opt := range opts {
+		opt(&s.data)
+	}
+
+	return ContextWithSpan(ctx, s), s
+}
+
+// -------------------- 全局 Tracer --------------------
+
+var global atomic.Pointer[Tracer]
+
+// Start 使用全局 Tracer 开始 Span，追踪未启用时为空操作
+func Start(ctx context.Context, name string, opts ...Option) (context.Context, *Span) {
+	return global.Load().Start(ctx, name, opts...)
+}
+
+// Enabled 追踪是否已启用
+func Enabled() bool {
+	return global.Load() != nil
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"yueling_tg/internal/core/context"
+	"yueling_tg/internal/core/trace"
 )
 
 // 构建中间件 并返回最终处理函数
@@ -14,7 +15,17 @@ func Chain(middlewares []Middleware, final func(ctx *context.Context) error) fun
 		m := middlewares[i]
 		next := current
 		current = func(ctx *context.Context) error {
-			return m.Process(ctx, next)
+			// 每个中间件一个 Span，后续中间件与处理器都是它的子 Span
+			parent := ctx.Ctx
+			spanCtx, span := trace.Start(parent, "middleware "+m.Name())
+			ctx.Ctx = spanCtx
+
+			err := m.Process(ctx, next)
+
+			ctx.Ctx = parent
+			span.RecordError(err)
+			span.End()
+			return err
 		}
 	}
 	return current
@@ -15,7 +15,7 @@ type middlewareFuncWrapper struct {
 func (m middlewareFuncWrapper) Process(ctx *context.Context, next HandlerFunc) error {
 	err := m.fn(ctx, next)
 	if err != nil {
-		logger.Error().Err(err).Str("middleware", m.name).Msg("中间件处理失败")
+		ctx.Logger(logger).Error().Err(err).Str("middleware", m.name).Msg("中间件处理失败")
 	}
 	return err
 }
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError 处理器或中间件中的 panic 转换成的错误
+type PanicError struct {
+	Value any    // recover() 的返回值
+	Stack []byte // 发生 panic 时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap panic 的值为 error 时返回它
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover 捕获 panic 并以 *PanicError 写入 err，需直接 defer 调用：
+//
+//	defer middleware.Recover(&err)
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Value: r, Stack: debug.Stack()}
+	}
+}
@@ -1,18 +1,19 @@
 package main
 
 import (
-	"net/http"
-	"net/url"
 	"os"
-	"time"
 	logx "yueling_tg/internal/core/log"
 
 	"yueling_tg/middleware"
 	"yueling_tg/pkg/bot"
+	"yueling_tg/pkg/cli"
+	"yueling_tg/pkg/plugin"
 	"yueling_tg/plugins/admin"
+	"yueling_tg/plugins/antispam"
 	"yueling_tg/plugins/ban"
 	"yueling_tg/plugins/banword"
 	"yueling_tg/plugins/calculator"
+	"yueling_tg/plugins/captcha"
 	"yueling_tg/plugins/chat"
 	"yueling_tg/plugins/emotion"
 	"yueling_tg/plugins/fortune"
@@ -24,67 +25,52 @@ import (
 	"yueling_tg/plugins/recall"
 	"yueling_tg/plugins/reply"
 	"yueling_tg/plugins/sticker"
+	"yueling_tg/plugins/welcome"
 
 	"github.com/rs/zerolog/log"
-
-	"github.com/joho/godotenv"
 )
 
+// version 版本号，构建时通过 -ldflags "-X main.version=..." 设置
+var version = "dev"
+
 func main() {
 
 	logger := logx.NewSystem("系统")
 	log.Logger = logger
-	logger.Info().Msg("启动 Telegram Bot...")
-
-	// 加载 .env 文件
-	if err := godotenv.Load(".env"); err != nil {
-		logger.Warn().Msg("未找到 .env 文件，将使用系统环境变量")
-	}
-
-	// 读取 Telegram Bot Token
-	botToken := os.Getenv("TELEGRAM_BOT_TOKEN")
-	if botToken == "" {
-		logger.Panic().Msg("TELEGRAM_BOT_TOKEN 未设置")
-	}
-
-	httpProxy := os.Getenv("HTTP_PROXY")
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	if httpProxy == "" {
-		logger.Warn().Msg("未设置代理，将使用默认网络连接")
-	} else {
-
-		proxyURL, err := url.Parse(httpProxy)
-		if err != nil {
-			return
-		}
-		transport := &http.Transport{
-			Proxy: http.ProxyURL(proxyURL),
-		}
-		client.Transport = transport
-		logger.Info().Msgf("已使用环境变量设置代理: HTTP_PROXY=%s", httpProxy)
-	}
 
-	b, err := bot.NewBot(botToken, "./config.toml", client)
-	if err != nil {
-		logger.Panic().Msg("创建 Bot 失败")
+	app := &cli.App{
+		Name:    "yueling",
+		Version: version,
+		Plugins: newPlugins,
+		Setup: func(b *bot.Bot) {
+			// 每个更新一次：兜底匹配阶段的 panic
+			b.RegisterUpdateMiddlewares(
+				middleware.RecoveryMiddleware(),
+			)
+
+			// 频率限制：读取 [ratelimit] 段，修改后即时生效
+			limiter := middleware.NewRateLimiter(middleware.DefaultRateLimitConfig())
+			if err := limiter.Watch(b.Config()); err != nil {
+				log.Warn().Err(err).Msg("读取频率限制配置失败，使用默认配置")
+			}
+
+			// 每次处理器调用：频率限制、记录插件与耗时
+			b.RegisterMiddlewares(
+				limiter.Middleware(),
+				middleware.LoggingMiddleware(),
+			)
+		},
 	}
 
-	b.RegisterMiddlewares(
-		middleware.LoggingMiddleware(),
-		middleware.RateLimitMiddleware(60, 1*time.Minute),
-		middleware.RecoveryMiddleware(),
-	)
+	os.Exit(app.Run(os.Args[1:]))
+}
 
-	b.RegisterPlugins(
+// newPlugins 创建全部插件
+func newPlugins() []plugin.Plugin {
+	return []plugin.Plugin{
 		image.New(), emotion.New(), fortune.New(), help.New(), reply.New(), chat.New(),
 		ban.New(), recall.New(), calculator.New(), random.New(), music.New(),
-		sticker.New(), admin.New(), banword.New(), randommember.New(),
-	)
-
-	b.Run()
-
+		sticker.New(), admin.New(), banword.New(), randommember.New(), antispam.New(),
+		captcha.New(), welcome.New(),
+	}
 }
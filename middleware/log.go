@@ -5,11 +5,12 @@ import (
 	"yueling_tg/internal/core/context"
 	"yueling_tg/internal/core/log"
 	"yueling_tg/internal/middleware"
+	"yueling_tg/pkg/plugin"
 )
 
 var logger = log.NewMiddleware("事件耗时统计")
 
-// LoggingMiddleware 日志中间件
+// LoggingMiddleware 日志中间件，注册为全局中间件时记录每次处理器调用的插件、触发方式与耗时
 func LoggingMiddleware() middleware.Middleware {
 	return middleware.MiddlewareFunc("日志中间件", func(ctx *context.Context, next middleware.HandlerFunc) error {
 		start := time.Now()
@@ -19,16 +20,17 @@ func LoggingMiddleware() middleware.Middleware {
 		duration := time.Since(start)
 		if err != nil {
 			return err
+		}
 
-		} else {
-
-			pluginName, ok := ctx.Storage.Get(context.PluginName)
-			if ok {
-				logger.Info().Msgf("事件处理成功 BOT: %v 耗时: %v", pluginName, duration)
-			} else {
-				logger.Info().Msgf("事件处理成功 BOT: %v  耗时: %v", "未知插件", duration)
-			}
+		pluginName, ok := ctx.Storage.Get(context.PluginName)
+		if !ok {
+			pluginName = "未知插件"
+		}
+		event := ctx.Logger(logger).Info()
+		if m := plugin.MatcherOf(ctx); m != nil {
+			event = event.Str("trigger", m.Trigger.Kind).Int("priority", m.Priority)
 		}
+		event.Msgf("事件处理成功 BOT: %v 耗时: %v", pluginName, duration)
 
 		return err
 	})
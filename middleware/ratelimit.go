@@ -2,49 +2,277 @@ package middleware
 
 import (
 	"fmt"
+	"slices"
+	"strconv"
 	"sync"
 	"time"
 	"yueling_tg/internal/core/context"
+	"yueling_tg/internal/core/log"
 	"yueling_tg/internal/middleware"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/plugin"
 )
 
-// RateLimitMiddleware 频率限制中间件
-func RateLimitMiddleware(maxRequests int, window time.Duration) middleware.Middleware {
-	type userLimit struct {
-		requests []time.Time
-		mu       sync.Mutex
-	}
+var loggerRateLimit = log.NewMiddleware("频率限制")
 
-	users := sync.Map{}
+// 频率限制的统计范围
+const (
+	ScopeUser     = "user"      // 每个用户全局一个令牌桶
+	ScopeChat     = "chat"      // 每个会话一个令牌桶
+	ScopeUserChat = "user_chat" // 每个用户在每个会话中一个令牌桶
+)
 
-	return middleware.MiddlewareFunc("频率限制中间件", func(ctx *context.Context, next middleware.HandlerFunc) error {
-		userID := ctx.GetUserID()
+// rateLimitKey 本次更新的判定结果在 context.Storage 中的键，同一更新命中多个匹配器时只计一次
+const rateLimitKey = "ratelimit_allowed"
 
-		now := time.Now()
+// RateLimitConfig 频率限制配置（config.toml 中的 [ratelimit] 段）
+type RateLimitConfig struct {
+	Enabled        bool                     `mapstructure:"enabled" doc:"是否启用"`
+	Scope          string                   `mapstructure:"scope" doc:"统计范围：user / chat / user_chat" validate:"oneof=user chat user_chat"`
+	PerMinute      int                      `mapstructure:"per_minute" doc:"每分钟补充的令牌数" validate:"min=1"`
+	Burst          int                      `mapstructure:"burst" doc:"令牌桶容量，允许的突发次数" validate:"min=1"`
+	Triggers       []string                 `mapstructure:"triggers" doc:"计入限制的匹配器触发方式，见 list-plugins 中的类型"`
+	ExemptAdmins   bool                     `mapstructure:"exempt_admins" doc:"群主与管理员不受限制"`
+	Superusers     []int64                  `mapstructure:"superusers" doc:"不受限制的用户 ID"`
+	Notice         string                   `mapstructure:"notice" doc:"触发限制时的提示，为空时不提示"`
+	NoticeInterval int                      `mapstructure:"notice_interval" doc:"同一对象两次提示的最小间隔（秒）" validate:"min=0"`
+	IdleTTL        int                      `mapstructure:"idle_ttl" doc:"令牌桶空闲多久后回收（秒）" validate:"min=1"`
+	Chats          map[string]ChatRateLimit `mapstructure:"chats" doc:"按会话 ID 覆盖限制，如 [ratelimit.chats.\"-1001234567890\"]"`
+}
 
-		// 获取或创建用户限制记录
-		value, _ := users.LoadOrStore(userID, &userLimit{})
-		ul := value.(*userLimit)
+// ChatRateLimit 单个会话的限制，未填写的项沿用全局配置
+type ChatRateLimit struct {
+	Disabled  bool `mapstructure:"disabled" doc:"该会话不限制"`
+	PerMinute int  `mapstructure:"per_minute" doc:"每分钟补充的令牌数" validate:"min=0"`
+	Burst     int  `mapstructure:"burst" doc:"令牌桶容量" validate:"min=0"`
+}
 
-		ul.mu.Lock()
-		defer ul.mu.Unlock()
+// DefaultRateLimitConfig 默认配置：每个用户每分钟 20 条命令，允许连续 5 条
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled:        true,
+		Scope:          ScopeUser,
+		PerMinute:      20,
+		Burst:          5,
+		Triggers:       []string{"command", "fullmatch", "prefix"},
+		ExemptAdmins:   true,
+		Notice:         "⏳ 操作太频繁了，请稍后再试",
+		NoticeInterval: 30,
+		IdleTTL:        600,
+	}
+}
 
-		// 清理过期记录
-		var validRequests []time.Time
-		for _, t := range ul.requests {
-			if now.Sub(t) < window {
-				validRequests = append(validRequests, t)
-			}
+func init() {
+	config.RegisterSchema("ratelimit", DefaultRateLimitConfig())
+}
+
+// limit 返回会话 chatID 的速率（每秒）与容量，ok 为 false 表示不限制
+func (c RateLimitConfig) limit(chatID int64) (rate float64, burst int, ok bool) {
+	perMinute, burst := c.PerMinute, c.Burst
+	if chat, exists := c.Chats[strconv.FormatInt(chatID, 10)]; exists {
+		if chat.Disabled {
+			return 0, 0, false
+		}
+		if chat.PerMinute > 0 {
+			perMinute = chat.PerMinute
 		}
+		if chat.Burst > 0 {
+			burst = chat.Burst
+		}
+	}
+	return float64(perMinute) / 60, burst, true
+}
+
+// key 按统计范围生成令牌桶的键
+func (c RateLimitConfig) key(ctx *context.Context) string {
+	user, chat := ctx.GetUserID(), ctx.GetChatID().ID
+	switch c.Scope {
+	case ScopeChat:
+		return fmt.Sprintf("c:%d", chat)
+	case ScopeUserChat:
+		return fmt.Sprintf("uc:%d:%d", user, chat)
+	default:
+		return fmt.Sprintf("u:%d", user)
+	}
+}
+
+type bucket struct {
+	tokens  float64
+	last    time.Time // 上次补充令牌的时间
+	noticed time.Time // 上次发送提示的时间
+}
+
+// RateLimiter 基于令牌桶的频率限制，只统计匹配成功的命令类匹配器，需注册为全局中间件
+type RateLimiter struct {
+	mu        sync.Mutex
+	cfg       RateLimitConfig
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter 创建频率限制器
+func NewRateLimiter(cfg RateLimitConfig) *RateLimiter {
+	return &RateLimiter{
+		cfg:     cfg,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetConfig 更新配置，已有令牌桶保留
+func (l *RateLimiter) SetConfig(cfg RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
+
+// Config 返回当前配置
+func (l *RateLimiter) Config() RateLimitConfig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cfg
+}
+
+// Len 返回当前的令牌桶数量
+func (l *RateLimiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// take 从 key 的令牌桶中取一个令牌；取不到时 notify 表示需要发送提示
+func (l *RateLimiter) take(key string, rate float64, burst int) (ok, notify bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{tokens: float64(burst), last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, false
+	}
 
-		if len(validRequests) >= maxRequests {
-			return fmt.Errorf("频率限制: 用户 %s 在 %v 内已发送 %d 条消息", userID, window, maxRequests)
+	interval := time.Duration(l.cfg.NoticeInterval) * time.Second
+	if l.cfg.Notice != "" && now.Sub(b.noticed) >= interval {
+		b.noticed = now
+		return false, true
+	}
+	return false, false
+}
+
+// sweep 回收空闲超过 IdleTTL 的令牌桶，最多每半个 IdleTTL 执行一次
+func (l *RateLimiter) sweep(now time.Time) {
+	ttl := time.Duration(l.cfg.IdleTTL) * time.Second
+	if ttl <= 0 || now.Sub(l.lastSweep) < ttl/2 {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > ttl {
+			delete(l.buckets, key)
 		}
+	}
+}
+
+// exempt 超级用户与（开启时）群管理员不受限制
+func (l *RateLimiter) exempt(ctx *context.Context, cfg RateLimitConfig) bool {
+	if slices.Contains(cfg.Superusers, ctx.GetUserID()) {
+		return true
+	}
+	return cfg.ExemptAdmins && ctx.IsGroupChat() && ctx.IsAdmin()
+}
+
+// allow 判定本次调用是否放行，同一更新只判定一次
+func (l *RateLimiter) allow(ctx *context.Context) bool {
+	if v, ok := ctx.Storage.GetBool(rateLimitKey); ok {
+		return v
+	}
+
+	allowed := l.check(ctx)
+	ctx.Storage.Set(rateLimitKey, allowed)
+	return allowed
+}
+
+func (l *RateLimiter) check(ctx *context.Context) bool {
+	cfg := l.Config()
+	if !cfg.Enabled || ctx.GetUserID() == 0 {
+		return true
+	}
+	rate, burst, limited := cfg.limit(ctx.GetChatID().ID)
+	if !limited {
+		return true
+	}
+
+	// 豁免的用户不消耗令牌，以免按会话统计时占用其他成员的额度
+	if l.exempt(ctx, cfg) {
+		return true
+	}
 
-		// 记录本次请求
-		validRequests = append(validRequests, now)
-		ul.requests = validRequests
+	key := cfg.key(ctx)
+	ok, notify := l.take(key, rate, burst)
+	if ok {
+		return true
+	}
 
+	ctx.Logger(loggerRateLimit).Debug().Str("key", key).Msg("触发频率限制")
+	if notify {
+		if _, err := ctx.Reply(cfg.Notice); err != nil {
+			ctx.Logger(loggerRateLimit).Warn().Err(err).Msg("发送频率限制提示失败")
+		}
+	}
+	return false
+}
+
+// Middleware 返回全局中间件：只统计触发方式在 Triggers 中的匹配器，被限制的调用直接跳过
+func (l *RateLimiter) Middleware() middleware.Middleware {
+	return middleware.MiddlewareFunc("频率限制中间件", func(ctx *context.Context, next middleware.HandlerFunc) error {
+		m := plugin.MatcherOf(ctx)
+		if m == nil || !slices.Contains(l.Config().Triggers, m.Trigger.Kind) {
+			return next(ctx)
+		}
+		if !l.allow(ctx) {
+			return nil
+		}
 		return next(ctx)
 	})
 }
+
+// Watch 订阅 cm 的 [ratelimit] 段并读取当前配置，读取失败时保留原配置
+func (l *RateLimiter) Watch(cm *config.ConfigManager) error {
+	cm.WatchSection("ratelimit", config.Listener{
+		Apply: func(_, raw any) error {
+			cfg := DefaultRateLimitConfig()
+			if err := config.Decode(raw, &cfg); err != nil {
+				return err
+			}
+			l.SetConfig(cfg)
+			return nil
+		},
+	})
+
+	cfg := DefaultRateLimitConfig()
+	if err := cm.GetSection("ratelimit", &cfg); err != nil {
+		return err
+	}
+	l.SetConfig(cfg)
+	return nil
+}
+
+// RateLimitMiddleware 以默认配置为基础创建频率限制中间件（不读取配置文件），
+// 每个用户每 window 最多 maxRequests 条命令
+func RateLimitMiddleware(maxRequests int, window time.Duration) middleware.Middleware {
+	cfg := DefaultRateLimitConfig()
+	cfg.PerMinute = max(1, int(float64(maxRequests)*float64(time.Minute)/float64(window)))
+	cfg.Burst = max(1, maxRequests)
+	return NewRateLimiter(cfg).Middleware()
+}
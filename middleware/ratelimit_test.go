@@ -0,0 +1,307 @@
+package middleware
+
+import (
+	stdctx "context"
+	"encoding/json"
+	"path"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"yueling_tg/internal/core/context"
+
+	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+const testToken = "123456789:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+
+// fakeCaller 记录调用的方法；admins 中的用户在 getChatMember 中返回管理员
+type fakeCaller struct {
+	mu      sync.Mutex
+	admins  []int64
+	methods []string
+}
+
+func (f *fakeCaller) Call(_ stdctx.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	method := path.Base(url)
+	f.mu.Lock()
+	f.methods = append(f.methods, method)
+	f.mu.Unlock()
+
+	if method == "getChatMember" {
+		var params struct {
+			UserID int64 `json:"user_id"`
+		}
+		if err := json.Unmarshal(data.Buffer.Bytes(), &params); err != nil {
+			return nil, err
+		}
+		status := `{"status":"member","user":{"id":1,"is_bot":false,"first_name":"x"}}`
+		if slices.Contains(f.admins, params.UserID) {
+			status = `{"status":"administrator","user":{"id":1,"is_bot":false,"first_name":"x"}}`
+		}
+		return &ta.Response{Ok: true, Result: []byte(status)}, nil
+	}
+	return &ta.Response{
+		Ok:     true,
+		Result: []byte(`{"message_id":1,"date":0,"chat":{"id":-100123,"type":"supergroup"}}`),
+	}, nil
+}
+
+// count 返回 method 被调用的次数
+func (f *fakeCaller) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, m := range f.methods {
+		if m == method {
+			n++
+		}
+	}
+	return n
+}
+
+// testLimiter 创建使用假时钟的限制器，返回推进时钟的函数
+func testLimiter(t *testing.T, cfg RateLimitConfig, admins ...int64) (*RateLimiter, *fakeCaller, func(user, chat int64) *context.Context, func(time.Duration)) {
+	t.Helper()
+	caller := &fakeCaller{admins: admins}
+	api, err := telego.NewBot(testToken, telego.WithAPICaller(caller), telego.WithDiscardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1000, 0)
+	l := NewRateLimiter(cfg)
+	l.now = func() time.Time { return now }
+
+	newCtx := func(user, chat int64) *context.Context {
+		chatType := telego.ChatTypePrivate
+		if chat < 0 {
+			chatType = telego.ChatTypeSupergroup
+		}
+		return context.NewContext(stdctx.Background(), api, telego.Update{
+			Message: &telego.Message{
+				MessageID: 1,
+				Chat:      telego.Chat{ID: chat, Type: chatType},
+				From:      &telego.User{ID: user, FirstName: "测试"},
+				Text:      "/ping",
+			},
+		})
+	}
+	advance := func(d time.Duration) { now = now.Add(d) }
+	return l, caller, newCtx, advance
+}
+
+// testConfig 每分钟 60 个令牌（每秒一个），容量 2，不提示
+func testConfig() RateLimitConfig {
+	cfg := DefaultRateLimitConfig()
+	cfg.PerMinute = 60
+	cfg.Burst = 2
+	cfg.ExemptAdmins = false
+	cfg.Notice = ""
+	return cfg
+}
+
+func TestRateLimitRefill(t *testing.T) {
+	l, _, newCtx, advance := testLimiter(t, testConfig())
+
+	tests := []struct {
+		name string
+		wait time.Duration // 本次调用前经过的时间
+		want bool
+	}{
+		{"满桶第一次", 0, true},
+		{"满桶第二次", 0, true},
+		{"令牌耗尽", 0, false},
+		{"补充不足一个", 500 * time.Millisecond, false},
+		{"补充一个", 500 * time.Millisecond, true},
+		{"再次耗尽", 0, false},
+		{"补充不超过容量", time.Hour, true},
+		{"容量内第二次", 0, true},
+		{"容量用完", 0, false},
+	}
+	for _, tt := range tests {
+		advance(tt.wait)
+		if got := l.allow(newCtx(1, 1)); got != tt.want {
+			t.Errorf("%s: allow = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitScopes(t *testing.T) {
+	type call struct {
+		user, chat int64
+		want       bool
+	}
+	tests := []struct {
+		name  string
+		scope string
+		calls []call
+	}{
+		{"按用户跨会话共享", ScopeUser, []call{
+			{1, -100, true}, {1, -200, false}, {2, -100, true},
+		}},
+		{"按会话成员共享", ScopeChat, []call{
+			{1, -100, true}, {2, -100, false}, {1, -200, true},
+		}},
+		{"按用户与会话", ScopeUserChat, []call{
+			{1, -100, true}, {1, -100, false}, {1, -200, true}, {2, -100, true},
+		}},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.Scope = tt.scope
+		cfg.Burst = 1
+		l, _, newCtx, _ := testLimiter(t, cfg)
+		for i, c := range tt.calls {
+			if got := l.allow(newCtx(c.user, c.chat)); got != c.want {
+				t.Errorf("%s: call %d (user %d, chat %d) = %v, want %v", tt.name, i, c.user, c.chat, got, c.want)
+			}
+		}
+	}
+}
+
+func TestRateLimitChatOverrides(t *testing.T) {
+	cfg := testConfig()
+	cfg.Burst = 1
+	cfg.Chats = map[string]ChatRateLimit{
+		"-100": {Disabled: true},
+		"-200": {Burst: 3},
+	}
+
+	tests := []struct {
+		name string
+		chat int64
+		want int // 连续调用中放行的次数
+	}{
+		{"全局配置", -300, 1},
+		{"会话不限制", -100, 10},
+		{"覆盖容量", -200, 3},
+	}
+	for _, tt := range tests {
+		l, _, newCtx, _ := testLimiter(t, cfg)
+		got := 0
+		for range 10 {
+			if l.allow(newCtx(1, tt.chat)) {
+				got++
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: allowed %d calls, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.Enabled = false
+	cfg.Burst = 1
+	l, _, newCtx, _ := testLimiter(t, cfg)
+	for i := range 5 {
+		if !l.allow(newCtx(1, -100)) {
+			t.Fatalf("call %d limited while disabled", i)
+		}
+	}
+	if n := l.Len(); n != 0 {
+		t.Errorf("buckets = %d, want 0", n)
+	}
+}
+
+func TestRateLimitNotice(t *testing.T) {
+	cfg := testConfig()
+	cfg.PerMinute = 1
+	cfg.Burst = 1
+	cfg.Notice = "慢一点"
+	cfg.NoticeInterval = 30
+	l, caller, newCtx, advance := testLimiter(t, cfg)
+
+	tests := []struct {
+		name    string
+		wait    time.Duration
+		allowed bool
+		notices int // 截至本次调用累计发送的提示数
+	}{
+		{"放行", 0, true, 0},
+		{"首次限制时提示", 0, false, 1},
+		{"间隔内不重复提示", 10 * time.Second, false, 1},
+		{"超过间隔再次提示", 20 * time.Second, false, 2},
+		{"补充后放行", 30 * time.Second, true, 2},
+	}
+	for _, tt := range tests {
+		advance(tt.wait)
+		if got := l.allow(newCtx(1, -100)); got != tt.allowed {
+			t.Errorf("%s: allow = %v, want %v", tt.name, got, tt.allowed)
+		}
+		if got := caller.count("sendMessage"); got != tt.notices {
+			t.Errorf("%s: notices = %d, want %d", tt.name, got, tt.notices)
+		}
+	}
+}
+
+func TestRateLimitSweep(t *testing.T) {
+	cfg := testConfig()
+	cfg.IdleTTL = 10
+	l, _, newCtx, advance := testLimiter(t, cfg)
+
+	l.allow(newCtx(1, -100))
+	l.allow(newCtx(2, -100))
+	if n := l.Len(); n != 2 {
+		t.Fatalf("buckets = %d, want 2", n)
+	}
+
+	// 用户 2 保持活跃，用户 1 空闲超过 IdleTTL 后被回收
+	advance(6 * time.Second)
+	l.allow(newCtx(2, -100))
+	advance(6 * time.Second)
+	l.allow(newCtx(3, -100))
+	if n := l.Len(); n != 2 {
+		t.Errorf("buckets after sweep = %d, want 2", n)
+	}
+	if _, ok := l.buckets["u:1"]; ok {
+		t.Error("idle bucket u:1 was not swept")
+	}
+}
+
+func TestRateLimitOncePerUpdate(t *testing.T) {
+	cfg := testConfig()
+	cfg.Burst = 1
+	l, _, newCtx, _ := testLimiter(t, cfg)
+
+	// 同一更新命中多个匹配器，只消耗一个令牌并得到相同结果
+	ctx := newCtx(1, -100)
+	for i := range 3 {
+		if !l.allow(ctx) {
+			t.Fatalf("matcher %d of the same update was limited", i)
+		}
+	}
+	if l.allow(newCtx(1, -100)) {
+		t.Error("next update should be limited")
+	}
+}
+
+func TestRateLimitExempt(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *RateLimitConfig)
+		user   int64
+		want   bool // 豁免用户之后，普通成员的第一次调用是否放行
+	}{
+		{"超级用户不消耗会话令牌", func(c *RateLimitConfig) { c.Superusers = []int64{9} }, 9, true},
+		{"管理员不消耗会话令牌", func(c *RateLimitConfig) { c.ExemptAdmins = true }, 8, true},
+		{"未开启管理员豁免", func(c *RateLimitConfig) {}, 8, false},
+	}
+	for _, tt := range tests {
+		cfg := testConfig()
+		cfg.Scope = ScopeChat
+		cfg.Burst = 1
+		tt.modify(&cfg)
+		l, _, newCtx, _ := testLimiter(t, cfg, 8)
+
+		for range 3 {
+			l.allow(newCtx(tt.user, -100))
+		}
+		if got := l.allow(newCtx(1, -100)); got != tt.want {
+			t.Errorf("%s: member allow = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,8 +1,7 @@
 package middleware
 
 import (
-	"fmt"
-	"runtime/debug"
+	"errors"
 	"yueling_tg/internal/core/context"
 	"yueling_tg/internal/core/log"
 	"yueling_tg/internal/middleware"
@@ -10,26 +9,20 @@ import (
 
 var loggerRecover = log.NewMiddleware("PANIC 中间件")
 
+// RecoveryMiddleware 捕获 panic 并作为错误返回。
+// 运行时已对每次处理器调用单独恢复，本中间件注册为更新级中间件时兜底匹配阶段与其它中间件中的 panic
 func RecoveryMiddleware() middleware.Middleware {
-	return middleware.MiddlewareFunc("panic中间件", func(ctx *context.Context, next middleware.HandlerFunc) error {
+	return middleware.MiddlewareFunc("panic中间件", func(ctx *context.Context, next middleware.HandlerFunc) (err error) {
 		defer func() {
-			if r := recover(); r != nil {
-				var errMsg string
-
-				switch v := r.(type) {
-				case error:
-					errMsg = v.Error()
-				default:
-					errMsg = fmt.Sprintf("%v", v)
-				}
-
-				stack := string(debug.Stack())
-				loggerRecover.Error().
-					Str("panic", errMsg).
-					Str("stack", stack).
+			var pe *middleware.PanicError
+			if errors.As(err, &pe) {
+				ctx.Logger(loggerRecover).Error().
+					Str("panic", pe.Error()).
+					Str("stack", string(pe.Stack)).
 					Msg("捕获 panic")
 			}
 		}()
+		defer middleware.Recover(&err)
 
 		return next(ctx)
 	})
@@ -1,28 +1,30 @@
 package middleware
 
 import (
+	stdctx "context"
+	"errors"
 	"fmt"
 	"time"
 	"yueling_tg/internal/core/context"
 	"yueling_tg/internal/middleware"
 )
 
+// TimeoutMiddleware 为后续处理设置截止时间。
+// 截止时间通过 ctx.Ctx 传递，ctx 上的 API 辅助方法与使用 ctx.Ctx 的 HTTP 请求会在到期后返回；
+// 处理器需要配合检查 ctx.Done()，不会被强制中断
 func TimeoutMiddleware(timeout time.Duration) middleware.Middleware {
 	return middleware.MiddlewareFunc("超时中间件", func(ctx *context.Context, next middleware.HandlerFunc) error {
-
-		ec, cancel := ctx.WithTimeout(timeout)
-		defer cancel()
-
-		done := make(chan error, 1)
-		go func() {
-			done <- next(ctx)
+		parent := ctx.Ctx
+		_, cancel := ctx.WithTimeout(timeout)
+		defer func() {
+			cancel()
+			ctx.Ctx = parent
 		}()
 
-		select {
-		case err := <-done:
-			return err
-		case <-ec.Done():
-			return fmt.Errorf("处理超时: %v", timeout)
+		err := next(ctx)
+		if errors.Is(ctx.Err(), stdctx.DeadlineExceeded) && err == nil {
+			err = fmt.Errorf("处理超时 %v: %w", timeout, stdctx.DeadlineExceeded)
 		}
+		return err
 	})
 }
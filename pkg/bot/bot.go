@@ -4,20 +4,44 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
+	"net/url"
 	"strings"
+	"time"
 	"yueling_tg/internal/core"
 	logx "yueling_tg/internal/core/log"
+	"yueling_tg/internal/core/metrics"
+	"yueling_tg/internal/core/sender"
+	"yueling_tg/internal/core/server"
+	"yueling_tg/internal/core/trace"
 	"yueling_tg/internal/middleware"
 	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/i18n"
 	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/userdir"
 
 	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
 	"github.com/rs/zerolog/log"
 )
 
 type Bot struct {
+	name    string // 名称，默认 Bot 为空
 	runtime *core.Runtime
+	config  *config.ConfigManager // 本 Bot 的配置
+	group   *Group                // 所属的 Bot 组（未加入时为空）
+}
+
+// Options 创建 Bot 的参数
+type Options struct {
+	// Name Bot 名称，用作日志、指标的 bot 标签与环境变量命名空间（YUELING_<NAME>_...）。
+	// 为空表示默认 Bot，同一进程中运行多个 Bot 时必须各不相同
+	Name string
+	// Token 为空时读取本 Bot 配置中的 [bot] token
+	Token string
+	// Client 发起 Bot API 请求的 HTTP 客户端，为空时按 [bot] proxy 创建
+	Client *http.Client
+	// Config 本 Bot 的配置（插件配置、数据目录与热更新），为空时使用全局配置
+	Config *config.ConfigManager
 }
 
 type ZerologWrapper struct{}
@@ -28,7 +52,6 @@ func (z ZerologWrapper) Debugf(format string, args ...any) {
 		return
 	}
 
-	log.Debug().Msg(format)
 	log.Debug().Msgf(format, args...)
 }
 
@@ -46,46 +69,172 @@ func (z ZerologWrapper) Errorf(format string, args ...any) {
 	log.Error().Msgf(format, args...)
 }
 
-// 创建一个新的 Bot 实例
+func init() {
+	// 全局配置段的结构与默认值，用于校验与生成默认配置
+	config.RegisterSchema("bot", Settings{})
+	config.RegisterSchema("log", logx.DefaultConfig())
+	config.RegisterSchema("trace", trace.DefaultConfig())
+	config.RegisterSchema("i18n", i18n.DefaultConfig())
+	config.RegisterSchema("server", server.DefaultConfig())
+}
+
+// NewBot 创建单个 Bot：初始化全局配置与进程级设施（日志、链路追踪、多语言与管理服务）。
+// 同一进程运行多个 Bot 时使用 New 与 Group
 func NewBot(botToken, configPath string, client *http.Client) (*Bot, error) {
-	loggerWrapper := ZerologWrapper{}
+	if err := config.InitConfigManager(configPath); err != nil {
+		return nil, err
+	}
+
+	group := NewGroup(config.GetManager())
+	b, err := New(Options{Token: botToken, Client: client})
+	if err != nil {
+		return nil, err
+	}
+	group.Add(b)
+	return b, nil
+}
+
+// New 创建 Bot，拥有独立的配置、运行时、出站请求层与插件。
+// 不会初始化进程级设施，需加入 Group 运行，或在此之前调用 NewBot / NewGroup
+func New(opts Options) (*Bot, error) {
+	cm := opts.Config
+	if cm == nil {
+		cm = config.GetManager()
+	}
+	cm.SetName(opts.Name)
 
-	bot, err := telego.NewBot(botToken,
+	label := opts.Name
+	if label == "" {
+		label = "default"
+	}
+
+	settings, err := ReadSettings(cm)
+	if err != nil {
+		return nil, fmt.Errorf("读取 [bot] 配置失败: %w", err)
+	}
+
+	token, client := opts.Token, opts.Client
+	if token == "" || client == nil {
+		if token == "" {
+			if !settings.Token.IsSet() {
+				env := cm.EnvName("bot.token")
+				if opts.Name == "" {
+					env += " / TELEGRAM_BOT_TOKEN"
+				}
+				return nil, fmt.Errorf("Bot %s 未设置 Token（[bot] token 或 %s）", label, env)
+			}
+			token = settings.Token.Value()
+		}
+		if client == nil {
+			if client, err = newHTTPClient(settings.Proxy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 出站请求层：限流、429 重试与优先级队列
+	senderCfg := sender.DefaultConfig()
+	senderCfg.Bot = label
+	out := sender.New(ta.HTTPCaller{Client: client}, senderCfg)
+
+	api, err := telego.NewBot(token,
 		telego.WithDefaultDebugLogger(),
-		telego.WithHTTPClient(client),
-		telego.WithLogger(loggerWrapper),
+		telego.WithAPICaller(trace.Caller{Next: out}),
+		telego.WithLogger(ZerologWrapper{}),
 	)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, fmt.Errorf("创建 Bot %s 失败: %w", label, err)
 	}
 
-	config.InitConfigManager(configPath)
-
-	b, err := bot.GetMe(context.Background())
+	me, err := api.GetMe(context.Background())
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return nil, fmt.Errorf("Bot %s 授权失败: %w", label, err)
 	}
 
-	fullName := b.FirstName + b.LastName
+	fullName := me.FirstName + me.LastName
+	if opts.Name != "" {
+		fullName = opts.Name
+	}
 
 	botLogger := logx.NewBot(fullName)
-	botLogger.Info().Msgf("授权账户: @%s", fullName)
+	botLogger.Info().Msgf("授权账户: @%s", me.Username)
 
-	runtime := core.NewRuntime(bot, botLogger)
+	runtime := core.NewRuntime(api, botLogger)
+	runtime.Name = label
+	runtime.Sender = out
+	runtime.HandlerTimeout = time.Duration(settings.HandlerTimeout) * time.Second
+	// 用户目录：记录每个更新中出现的用户，供插件把 @username 解析为用户
+	runtime.UpdateMiddlewares = append(runtime.UpdateMiddlewares, userdir.For(cm).Middleware())
+	// 群组语言设置保存在本 Bot 的数据目录中
+	runtime.ChatLocales = i18n.For(cm)
 
-	return &Bot{runtime: runtime}, nil
+	metrics.OutboundQueueDepth.Set(func() float64 {
+		return float64(out.QueueLen())
+	}, label)
+	metrics.APIRateLimited.Set(func() float64 {
+		return float64(out.Stats().RateLimited)
+	}, label)
+
+	// 配置热更新：回调在本 Bot 的事件循环中执行
+	cm.SetExecutor(runtime.Exec)
+
+	return &Bot{name: opts.Name, runtime: runtime, config: cm}, nil
 }
 
-// RegisterMiddlewares 注册中间件
+// newHTTPClient 创建 Bot API 使用的 HTTP 客户端，proxy 为空时直连
+func newHTTPClient(proxy config.Secret) (*http.Client, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	if !proxy.IsSet() {
+		log.Warn().Msg("未设置代理，将使用默认网络连接")
+		return client, nil
+	}
+
+	proxyURL, err := url.Parse(proxy.Value())
+	if err != nil {
+		return nil, fmt.Errorf("代理地址无效")
+	}
+	client.Transport = &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+	}
+	log.Info().Msgf("已设置代理: %s", proxyURL.Redacted())
+	return client, nil
+}
+
+// Name 返回 Bot 名称，默认 Bot 为空
+func (b *Bot) Name() string {
+	return b.name
+}
+
+// Config 返回本 Bot 的配置
+func (b *Bot) Config() *config.ConfigManager {
+	return b.config
+}
+
+// Scope 在 fn 执行期间将本 Bot 的配置作为当前配置，用于创建插件：
+// 插件构造函数中读取的配置、注册的配置结构与 DataPath 都属于本 Bot
+func (b *Bot) Scope(fn func()) {
+	config.With(b.config, fn)
+}
+
+// RegisterMiddlewares 注册全局中间件，包裹每次匹配成功的处理器调用，
+// 在插件级与匹配器级中间件之前执行，可读取匹配到的插件与匹配器
 func (b *Bot) RegisterMiddlewares(m ...middleware.Middleware) {
 	b.runtime.Middlewares = append(b.runtime.Middlewares, m...)
 }
 
-// RegisterPlugins 注册插件
+// RegisterUpdateMiddlewares 注册更新级中间件，每个更新执行一次，包裹匹配与分发的全过程
+func (b *Bot) RegisterUpdateMiddlewares(m ...middleware.Middleware) {
+	b.runtime.UpdateMiddlewares = append(b.runtime.UpdateMiddlewares, m...)
+}
+
+// RegisterPlugins 注册插件，配置热更新订阅属于本 Bot 的配置
 func (b *Bot) RegisterPlugins(plugins ...plugin.Plugin) {
-	b.runtime.PluginRegistry.RegisterPlugins(plugins...)
+	b.Scope(func() {
+		b.runtime.PluginRegistry.RegisterPlugins(plugins...)
+	})
 }
 
 // Plugins 获取已注册插件
@@ -93,7 +242,40 @@ func (b *Bot) Plugins() []plugin.Plugin {
 	return b.runtime.PluginRegistry.Plugins()
 }
 
-// Run 启动 Bot
+// CheckConfig 返回启动阶段收集到的全部配置错误（未知键、类型错误与校验失败）
+func (b *Bot) CheckConfig() []error {
+	return b.config.Problems()
+}
+
+// Run 启动 Bot，存在配置错误时全部输出后退出。已加入 Group 时运行整个组
 func (b *Bot) Run() {
+	if b.group != nil {
+		b.group.Run()
+		return
+	}
+
+	if problems := b.CheckConfig(); len(problems) > 0 {
+		for _, err := range problems {
+			b.runtime.Logger.Error().Msg(err.Error())
+		}
+		b.runtime.Logger.Fatal().Int("count", len(problems)).Msg("配置有误，请修改配置文件后重新启动")
+	}
+
+	if b.config.Path() != "" {
+		if err := b.config.Watch(config.DefaultDebounce); err != nil {
+			b.runtime.Logger.Warn().Err(err).Msg("配置文件监听失败，热更新不可用")
+		}
+		defer b.config.StopWatch()
+	}
+
 	b.runtime.Run()
 }
+
+// Stop 停止 Bot：不再接收更新，排空出站队列后 Run 返回。已加入 Group 时停止整个组
+func (b *Bot) Stop() {
+	if b.group != nil {
+		b.group.Stop()
+		return
+	}
+	b.runtime.Stop()
+}
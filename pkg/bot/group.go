@@ -0,0 +1,196 @@
+package bot
+
+import (
+	"sync"
+
+	logx "yueling_tg/internal/core/log"
+	"yueling_tg/internal/core/metrics"
+	"yueling_tg/internal/core/server"
+	"yueling_tg/internal/core/trace"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/i18n"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Group 同一进程中运行的一组 Bot。
+//
+// 日志、链路追踪、多语言、指标与管理服务是进程级设施，按共享配置（通常是主配置文件中的
+// [log]、[trace]、[i18n]、[server] 段）初始化一次；每个 Bot 拥有独立的配置、运行时、
+// 出站请求层、插件与数据目录，指标以 bot 标签区分。
+type Group struct {
+	shared *config.ConfigManager
+	server *server.Server // 管理 HTTP 服务（未启用时为空）
+
+	mu   sync.RWMutex
+	bots []*Bot
+}
+
+// NewGroup 按共享配置初始化进程级设施，shared 为空时不做初始化
+func NewGroup(shared *config.ConfigManager) *Group {
+	g := &Group{shared: shared}
+	if shared == nil {
+		return g
+	}
+
+	// 日志：级别、输出格式与文件轮转
+	logCfg := logx.DefaultConfig()
+	if err := shared.GetSection("log", &logCfg); err != nil {
+		log.Warn().Err(err).Msg("读取日志配置失败，使用默认配置")
+	} else if err := logx.Configure(logCfg); err != nil {
+		log.Warn().Err(err).Msg("应用日志配置失败，使用默认配置")
+	}
+
+	// 链路追踪
+	traceCfg := trace.DefaultConfig()
+	if err := shared.GetSection("trace", &traceCfg); err != nil {
+		log.Warn().Err(err).Msg("读取追踪配置失败，已禁用")
+	} else if err := trace.Setup(traceCfg); err != nil {
+		log.Warn().Err(err).Msg("初始化链路追踪失败")
+	}
+
+	// 多语言：默认语言与外部翻译目录
+	i18nCfg := i18n.DefaultConfig()
+	if err := shared.GetSection("i18n", &i18nCfg); err != nil {
+		log.Warn().Err(err).Msg("读取多语言配置失败，使用默认配置")
+	}
+	if err := i18n.Setup(i18nCfg); err != nil {
+		log.Warn().Err(err).Msg("初始化多语言失败")
+	}
+
+	// 管理服务：/metrics、/healthz、/readyz，所有 Bot 共用
+	serverCfg := server.DefaultConfig()
+	if err := shared.GetSection("server", &serverCfg); err != nil {
+		log.Warn().Err(err).Msg("读取管理服务配置失败，已禁用")
+	} else if serverCfg.Enabled {
+		g.server = server.New(serverCfg, metrics.Default, server.Checks{
+			Health: g.healthCheck,
+			Ready:  g.ready,
+		})
+	}
+
+	// 日志配置修改即时生效（校验由已注册的配置结构完成）
+	shared.WatchSection("log", config.Listener{
+		Apply: func(_, raw any) error {
+			cfg := logx.DefaultConfig()
+			if err := config.Decode(raw, &cfg); err != nil {
+				return err
+			}
+			return logx.Configure(cfg)
+		},
+	})
+
+	return g
+}
+
+// Add 将 Bot 加入组
+func (g *Group) Add(bots ...*Bot) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, b := range bots {
+		b.group = g
+		g.bots = append(g.bots, b)
+	}
+}
+
+// Bots 返回组内全部 Bot
+func (g *Group) Bots() []*Bot {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append([]*Bot(nil), g.bots...)
+}
+
+// managers 返回共享配置与各 Bot 的配置（去重）
+func (g *Group) managers() []*config.ConfigManager {
+	var list []*config.ConfigManager
+	seen := make(map[*config.ConfigManager]bool)
+	add := func(cm *config.ConfigManager) {
+		if cm != nil && !seen[cm] {
+			seen[cm] = true
+			list = append(list, cm)
+		}
+	}
+	add(g.shared)
+	for _, b := range g.Bots() {
+		add(b.config)
+	}
+	return list
+}
+
+// CheckConfig 返回共享配置与各 Bot 配置在启动阶段收集到的全部错误
+func (g *Group) CheckConfig() []error {
+	var problems []error
+	for _, cm := range g.managers() {
+		problems = append(problems, cm.Problems()...)
+	}
+	return problems
+}
+
+// Run 启动组内全部 Bot，存在配置错误时全部输出后退出；所有 Bot 停止后返回
+func (g *Group) Run() {
+	if problems := g.CheckConfig(); len(problems) > 0 {
+		for _, err := range problems {
+			log.Error().Msg(err.Error())
+		}
+		log.Fatal().Int("count", len(problems)).Msg("配置有误，请修改配置文件后重新启动")
+	}
+
+	if g.server != nil {
+		if err := g.server.Start(); err != nil {
+			log.Error().Err(err).Msg("管理服务启动失败")
+		}
+	}
+
+	for _, cm := range g.managers() {
+		if cm.Path() == "" {
+			continue
+		}
+		if err := cm.Watch(config.DefaultDebounce); err != nil {
+			log.Warn().Err(err).Str("path", cm.Path()).Msg("配置文件监听失败，热更新不可用")
+			continue
+		}
+		defer cm.StopWatch()
+	}
+
+	var wg sync.WaitGroup
+	for _, b := range g.Bots() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.runtime.Run()
+		}()
+	}
+	wg.Wait()
+}
+
+// Stop 停止组内全部 Bot，Run 在所有 Bot 排空出站队列后返回
+func (g *Group) Stop() {
+	for _, b := range g.Bots() {
+		b.runtime.Stop()
+	}
+}
+
+// healthCheck 汇总各 Bot 的插件健康检查，多个 Bot 时 key 为 <Bot 名称>/<插件 ID>
+func (g *Group) healthCheck() map[string]error {
+	bots := g.Bots()
+	results := make(map[string]error)
+	for _, b := range bots {
+		for id, err := range b.runtime.PluginRegistry.HealthCheck() {
+			if len(bots) > 1 {
+				id = b.runtime.Name + "/" + id
+			}
+			results[id] = err
+		}
+	}
+	return results
+}
+
+// ready 全部 Bot 都已开始接收更新
+func (g *Group) ready() bool {
+	for _, b := range g.Bots() {
+		if !b.runtime.Ready() {
+			return false
+		}
+	}
+	return true
+}
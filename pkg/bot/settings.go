@@ -0,0 +1,37 @@
+package bot
+
+import (
+	"os"
+
+	"yueling_tg/pkg/config"
+)
+
+// Settings 启动参数（config.toml 中的 [bot] 段），同样支持 YUELING_BOT_TOKEN / YUELING_BOT_TOKEN_FILE 等环境变量
+type Settings struct {
+	Token config.Secret `mapstructure:"token" doc:"Bot Token，为空时读取环境变量 TELEGRAM_BOT_TOKEN"`
+	Proxy config.Secret `mapstructure:"proxy" doc:"HTTP 代理地址，为空时读取环境变量 HTTP_PROXY"`
+
+	HandlerTimeout int `mapstructure:"handler_timeout" doc:"处理器默认截止时间（秒），匹配器可单独设置，0 表示不限制" validate:"min=0"`
+}
+
+// LoadSettings 读取当前配置的 [bot] 段，见 ReadSettings。
+// 需在 config.InitConfigManager 之后调用
+func LoadSettings() (Settings, error) {
+	return ReadSettings(config.GetManager())
+}
+
+// ReadSettings 读取 cm 的 [bot] 段，未配置的项回退到 TELEGRAM_BOT_TOKEN 与 HTTP_PROXY 环境变量。
+// 命名的 Bot 不回退到 TELEGRAM_BOT_TOKEN，避免多个 Bot 误用同一个 Token
+func ReadSettings(cm *config.ConfigManager) (Settings, error) {
+	var s Settings
+	if err := cm.GetSection("bot", &s); err != nil {
+		return s, err
+	}
+	if !s.Token.IsSet() && cm.Name() == "" {
+		s.Token = config.Secret(os.Getenv("TELEGRAM_BOT_TOKEN"))
+	}
+	if !s.Proxy.IsSet() {
+		s.Proxy = config.Secret(os.Getenv("HTTP_PROXY"))
+	}
+	return s, nil
+}
@@ -0,0 +1,274 @@
+// Package cli 提供 Bot 程序的命令行入口，基于 bot.Bot 构建，嵌入本框架的项目可直接复用。
+//
+// 子命令：
+//   - run            启动 Bot（默认）
+//   - check-config   校验配置，或输出默认 / 当前生效的配置
+//   - list-plugins   列出插件及其命令与匹配器
+//   - export-data    将插件数据打包导出
+//   - import-data    从导出的数据包恢复插件数据
+//   - version        输出版本信息
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	logx "yueling_tg/internal/core/log"
+	"yueling_tg/pkg/bot"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/plugin"
+
+	"github.com/joho/godotenv"
+)
+
+var logger = logx.NewSystem("命令行")
+
+// App 命令行程序
+type App struct {
+	Name    string // 程序名，用于帮助信息
+	Version string // 版本号
+
+	// Plugins 创建全部插件，在配置加载后调用（list-plugins、check-config 与数据导入导出同样会调用）
+	Plugins func() []plugin.Plugin
+	// Setup 在 Bot 创建后、注册插件前调用，可用于注册中间件（可为空）
+	Setup func(b *bot.Bot)
+
+	Stdout io.Writer // 默认 os.Stdout
+	Stderr io.Writer // 默认 os.Stderr
+}
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(a *App, args []string) error
+}
+
+var commands = []command{
+	{"run", "启动 Bot", (*App).runBot},
+	{"check-config", "校验配置，-defaults 输出默认配置，-effective 输出当前生效的配置", (*App).checkConfig},
+	{"list-plugins", "列出插件及其命令与匹配器", (*App).listPlugins},
+	{"export-data", "将插件数据打包导出", (*App).exportData},
+	{"import-data", "从导出的数据包恢复插件数据", (*App).importData},
+	{"version", "输出版本信息", (*App).version},
+}
+
+// errUsage 参数错误，已输出帮助信息
+var errUsage = errors.New("参数错误")
+
+// Run 解析命令行并执行子命令，返回进程退出码。未指定子命令时执行 run
+func (a *App) Run(args []string) int {
+	if a.Name == "" {
+		a.Name = "yueling"
+	}
+
+	// 兼容旧参数：-print-default-config 等同于 check-config -defaults
+	if len(args) > 0 && strings.TrimLeft(args[0], "-") == "print-default-config" {
+		args = append([]string{"check-config", "-defaults"}, args[1:]...)
+	}
+
+	name := "run"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" || name == "-h" || name == "--help" {
+		a.usage()
+		return 0
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(a, args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			if !errors.Is(err, errUsage) {
+				fmt.Fprintf(a.stderr(), "%s %s: %v\n", a.Name, name, err)
+			}
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintf(a.stderr(), "未知的子命令: %s\n\n", name)
+	a.usage()
+	return 2
+}
+
+func (a *App) usage() {
+	w := a.stderr()
+	fmt.Fprintf(w, "用法: %s <子命令> [参数]\n\n子命令:\n", a.Name)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintf(w, "\n使用 %s <子命令> -h 查看子命令的参数\n", a.Name)
+}
+
+func (a *App) stdout() io.Writer {
+	if a.Stdout != nil {
+		return a.Stdout
+	}
+	return os.Stdout
+}
+
+func (a *App) stderr() io.Writer {
+	if a.Stderr != nil {
+		return a.Stderr
+	}
+	return os.Stderr
+}
+
+// -------------------- 公共参数 --------------------
+
+// options 各子命令共用的参数
+type options struct {
+	config  string
+	env     string
+	dataDir string
+	bots    botList
+}
+
+// botSpec 通过 -bot 指定的 Bot
+type botSpec struct {
+	name   string
+	config string
+}
+
+// botList 可重复的 -bot 名称=配置文件 参数
+type botList []botSpec
+
+func (l *botList) String() string {
+	parts := make([]string, 0, len(*l))
+	for _, b := range *l {
+		parts = append(parts, b.name+"="+b.config)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (l *botList) Set(v string) error {
+	name, path, ok := strings.Cut(v, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("格式应为 名称=配置文件")
+	}
+	for _, b := range *l {
+		if b.name == name {
+			return fmt.Errorf("Bot %s 重复", name)
+		}
+	}
+	*l = append(*l, botSpec{name: name, config: path})
+	return nil
+}
+
+// dataDir Bot 的数据目录：<数据根目录>/<名称>
+func (b botSpec) dataDir(root string) string {
+	return filepath.Join(root, b.name)
+}
+
+// flagSet 创建子命令的参数集并注册公共参数
+func (a *App) flagSet(name string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(a.Name+" "+name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr())
+
+	o := &options{}
+	fs.StringVar(&o.config, "config", "./config.toml", "配置文件路径")
+	fs.StringVar(&o.env, "env", ".env", "环境变量文件，不存在时忽略")
+	fs.StringVar(&o.dataDir, "data-dir", config.DefaultDataDir, "数据根目录，插件的默认数据路径以此为基准")
+	fs.Var(&o.bots, "bot", "名称=配置文件，使用该 Bot 的配置与数据目录（<data-dir>/<名称>）；run 可重复指定以运行多个 Bot")
+	return fs, o
+}
+
+// parse 解析参数，出错时 flag 包已输出帮助信息
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// load 加载环境变量文件与配置。create 为 false 时配置文件不存在不会被创建，所有配置取默认值。
+// 除 run 外，指定 -bot 时加载该 Bot 的配置，数据目录为 <data-dir>/<名称>
+func (a *App) load(o *options, create bool) error {
+	if err := godotenv.Load(o.env); err != nil {
+		if o.env != ".env" {
+			return fmt.Errorf("加载环境变量文件 %s 失败: %w", o.env, err)
+		}
+		logger.Debug().Msg("未找到 .env 文件，将使用系统环境变量")
+	}
+
+	config.SetDataDir(o.dataDir)
+
+	name := ""
+	if !create {
+		if len(o.bots) > 1 {
+			return fmt.Errorf("该子命令只能指定一个 -bot")
+		}
+		if len(o.bots) == 1 {
+			b := o.bots[0]
+			name, o.config = b.name, b.config
+			config.SetDataDir(b.dataDir(o.dataDir))
+		}
+
+		if _, err := os.Stat(o.config); errors.Is(err, os.ErrNotExist) {
+			logger.Warn().Str("path", o.config).Msg("配置文件不存在，使用默认配置")
+			config.InitEmptyConfigManager()
+			config.GetManager().SetName(name)
+			return nil
+		}
+	}
+	if err := config.InitConfigManager(o.config); err != nil {
+		return err
+	}
+	config.GetManager().SetName(name)
+	return nil
+}
+
+// newPlugins 创建全部插件，期间插件输出到标准输出的提示改为输出到标准错误，避免混入命令结果
+func (a *App) newPlugins() []plugin.Plugin {
+	if a.Plugins == nil {
+		return nil
+	}
+
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	return a.Plugins()
+}
+
+// selectPlugins 按 ID 过滤插件，ids 为空时返回按 ID 排序的全部插件
+func selectPlugins(plugins []plugin.Plugin, ids []string) ([]plugin.Plugin, error) {
+	if len(ids) == 0 {
+		sorted := append([]plugin.Plugin(nil), plugins...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].PluginInfo().ID < sorted[j].PluginInfo().ID
+		})
+		return sorted, nil
+	}
+
+	byID := make(map[string]plugin.Plugin, len(plugins))
+	for _, p := range plugins {
+		byID[p.PluginInfo().ID] = p
+	}
+
+	selected := make([]plugin.Plugin, 0, len(ids))
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("未知的插件: %s", id)
+		}
+		selected = append(selected, p)
+	}
+	return selected, nil
+}
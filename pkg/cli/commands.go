@@ -0,0 +1,267 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"yueling_tg/pkg/bot"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/plugin"
+)
+
+// -------------------- run --------------------
+
+func (a *App) runBot(args []string) error {
+	fs, o := a.flagSet("run")
+	logLevel := fs.String("log-level", "", "日志级别，覆盖配置文件中的 [log] level")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	// 通过环境变量覆盖，热更新时同样生效
+	if *logLevel != "" {
+		os.Setenv(config.EnvName("log.level"), *logLevel)
+	}
+
+	if err := a.load(o, true); err != nil {
+		return err
+	}
+
+	logger.Info().Msg("启动 Telegram Bot...")
+
+	// 主配置提供进程级设施；未指定 -bot 时也是唯一 Bot 的配置
+	group := bot.NewGroup(config.GetManager())
+
+	if len(o.bots) == 0 {
+		b, err := bot.New(bot.Options{})
+		if err != nil {
+			return err
+		}
+		group.Add(b)
+	}
+	for _, spec := range o.bots {
+		cm, err := config.NewManager(spec.config)
+		if err != nil {
+			return fmt.Errorf("加载 Bot %s 的配置失败: %w", spec.name, err)
+		}
+		cm.SetDataDir(spec.dataDir(o.dataDir))
+
+		b, err := bot.New(bot.Options{Name: spec.name, Config: cm})
+		if err != nil {
+			return err
+		}
+		group.Add(b)
+	}
+
+	for _, b := range group.Bots() {
+		if a.Setup != nil {
+			a.Setup(b)
+		}
+		if a.Plugins != nil {
+			var plugins []plugin.Plugin
+			b.Scope(func() { plugins = a.Plugins() })
+			b.RegisterPlugins(plugins...)
+		}
+	}
+
+	// 收到 SIGINT / SIGTERM 时停止，已排队的消息发送完毕后退出
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	go func() {
+		sig := <-sigs
+		logger.Info().Str("signal", sig.String()).Msg("正在停止 Telegram Bot...")
+		group.Stop()
+	}()
+
+	group.Run()
+	return nil
+}
+
+// -------------------- check-config --------------------
+
+func (a *App) checkConfig(args []string) error {
+	fs, o := a.flagSet("check-config")
+	defaults := fs.Bool("defaults", false, "输出根据全部插件生成的带注释默认配置")
+	effective := fs.Bool("effective", false, "输出当前生效的配置（敏感字段已隐藏）")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *defaults {
+		config.InitEmptyConfigManager()
+		config.SetDataDir(o.dataDir)
+		a.newPlugins()
+		return config.WriteDefaults(a.stdout())
+	}
+
+	if err := a.load(o, false); err != nil {
+		return err
+	}
+	plugins := a.newPlugins()
+
+	if *effective {
+		return config.WriteEffective(a.stdout())
+	}
+
+	problems := config.Check()
+	problems = append(problems, checkAssets(plugins)...)
+	if len(problems) == 0 {
+		fmt.Fprintf(a.stdout(), "%s: 配置有效\n", o.config)
+		return nil
+	}
+
+	for _, err := range problems {
+		fmt.Fprintf(a.stdout(), "- %s\n", strings.ReplaceAll(err.Error(), "\n", "\n  "))
+	}
+	return fmt.Errorf("%s: 发现 %d 个问题", o.config, len(problems))
+}
+
+// checkAssets 检查各插件声明的必需资源
+func checkAssets(plugins []plugin.Plugin) []error {
+	var problems []error
+	for _, p := range plugins {
+		cp, ok := p.(plugin.PluginContextProvider)
+		if !ok || cp.PluginContext() == nil {
+			continue
+		}
+		for _, err := range cp.PluginContext().Check() {
+			problems = append(problems, fmt.Errorf("插件 %s: %w", p.PluginInfo().ID, err))
+		}
+	}
+	return problems
+}
+
+// -------------------- list-plugins --------------------
+
+// pluginListing list-plugins 的 JSON 输出
+type pluginListing struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Version     string           `json:"version"`
+	Group       string           `json:"group"`
+	Description string           `json:"description"`
+	Usage       string           `json:"usage,omitempty"`
+	Matchers    []matcherListing `json:"matchers"`
+}
+
+type matcherListing struct {
+	Kind     string   `json:"kind"`
+	Patterns []string `json:"patterns,omitempty"`
+	Priority int      `json:"priority"`
+	Block    bool     `json:"block"`
+}
+
+func (a *App) listPlugins(args []string) error {
+	fs, o := a.flagSet("list-plugins")
+	asJSON := fs.Bool("json", false, "以 JSON 输出")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.load(o, false); err != nil {
+		return err
+	}
+
+	plugins, err := selectPlugins(a.newPlugins(), fs.Args())
+	if err != nil {
+		return err
+	}
+
+	listings := make([]pluginListing, 0, len(plugins))
+	for _, p := range plugins {
+		info := p.PluginInfo()
+		l := pluginListing{
+			ID:          info.ID,
+			Name:        info.Name,
+			Version:     info.Version,
+			Group:       info.Group,
+			Description: info.Description,
+			Usage:       info.Usage,
+		}
+		for _, m := range p.Matchers() {
+			kind := m.Trigger.Kind
+			if kind == "" {
+				kind = "custom"
+			}
+			l.Matchers = append(l.Matchers, matcherListing{
+				Kind:     kind,
+				Patterns: m.Trigger.Patterns,
+				Priority: m.Priority,
+				Block:    m.Block,
+			})
+		}
+		listings = append(listings, l)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(a.stdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(listings)
+	}
+
+	w := tabwriter.NewWriter(a.stdout(), 0, 4, 2, ' ', 0)
+	for i, l := range listings {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s\t%s\tv%s\t%s\n", l.ID, l.Name, l.Version, l.Group)
+		for _, m := range l.Matchers {
+			block := ""
+			if m.Block {
+				block = "阻断"
+			}
+			fmt.Fprintf(w, "  %s\t%s\t优先级 %d\t%s\n", m.Kind, strings.Join(m.Patterns, " | "), m.Priority, block)
+		}
+	}
+	fmt.Fprintf(w, "\n共 %d 个插件\n", len(listings))
+	return w.Flush()
+}
+
+// -------------------- version --------------------
+
+func (a *App) version(args []string) error {
+	fs := flag.NewFlagSet(a.Name+" version", flag.ContinueOnError)
+	fs.SetOutput(a.stderr())
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	version := a.Version
+	if version == "" {
+		version = "dev"
+	}
+	fmt.Fprintf(a.stdout(), "%s %s\n", a.Name, version)
+	fmt.Fprintf(a.stdout(), "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		var revision, modified, buildTime string
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				revision = s.Value
+			case "vcs.modified":
+				modified = s.Value
+			case "vcs.time":
+				buildTime = s.Value
+			}
+		}
+		if revision != "" {
+			if modified == "true" {
+				revision += " (modified)"
+			}
+			fmt.Fprintf(a.stdout(), "commit: %s\n", revision)
+		}
+		if buildTime != "" {
+			fmt.Fprintf(a.stdout(), "time: %s\n", buildTime)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,426 @@
+package cli
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"yueling_tg/pkg/plugin"
+)
+
+// -------------------- 数据包格式 --------------------
+//
+// 数据包为 tar.gz，第一个条目是 manifest.json，其后是各插件的数据：
+//
+//	manifest.json
+//	<插件 ID>/<序号>/<文件名>        DataPaths()[序号] 为文件
+//	<插件 ID>/<序号>/<相对路径>      DataPaths()[序号] 为目录
+//
+// 导入时按序号映射到当前插件的 DataPaths()，因此数据目录变化后仍能恢复。
+
+const manifestName = "manifest.json"
+
+// manifest 数据包清单
+type manifest struct {
+	Version string           `json:"version"`
+	Created time.Time        `json:"created"`
+	Plugins []manifestPlugin `json:"plugins"`
+}
+
+type manifestPlugin struct {
+	ID    string         `json:"id"`
+	Paths []manifestPath `json:"paths"`
+}
+
+type manifestPath struct {
+	Index int    `json:"index"`
+	Path  string `json:"path"` // 导出时的路径，仅供参考
+	Dir   bool   `json:"dir"`
+}
+
+// dataProviders 过滤出提供数据路径的插件
+func dataProviders(plugins []plugin.Plugin) map[string]plugin.PluginDataProvider {
+	providers := make(map[string]plugin.PluginDataProvider)
+	for _, p := range plugins {
+		if dp, ok := p.(plugin.PluginDataProvider); ok {
+			providers[p.PluginInfo().ID] = dp
+		}
+	}
+	return providers
+}
+
+// -------------------- export-data --------------------
+
+func (a *App) exportData(args []string) error {
+	fs, o := a.flagSet("export-data")
+	output := fs.String("o", "", "输出文件，默认为 <程序名>-data-<时间>.tar.gz")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.load(o, false); err != nil {
+		return err
+	}
+
+	plugins, err := selectPlugins(a.newPlugins(), fs.Args())
+	if err != nil {
+		return err
+	}
+
+	m := manifest{Version: a.Version, Created: time.Now()}
+	for _, p := range plugins {
+		dp, ok := p.(plugin.PluginDataProvider)
+		if !ok {
+			if len(fs.Args()) > 0 {
+				logger.Warn().Str("plugin", p.PluginInfo().ID).Msg("插件没有可导出的数据")
+			}
+			continue
+		}
+
+		mp := manifestPlugin{ID: p.PluginInfo().ID}
+		for i, src := range dp.DataPaths() {
+			info, err := os.Stat(src)
+			if errors.Is(err, os.ErrNotExist) {
+				logger.Warn().Str("plugin", mp.ID).Str("path", src).Msg("数据路径不存在，已跳过")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			mp.Paths = append(mp.Paths, manifestPath{Index: i, Path: src, Dir: info.IsDir()})
+		}
+		if len(mp.Paths) > 0 {
+			m.Plugins = append(m.Plugins, mp)
+		}
+	}
+
+	if *output == "" {
+		*output = fmt.Sprintf("%s-data-%s.tar.gz", a.Name, m.Created.Format("20060102-150405"))
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeArchive(f, m); err != nil {
+		f.Close()
+		os.Remove(*output)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout(), "已导出 %d 个插件的数据到 %s\n", len(m.Plugins), *output)
+	return nil
+}
+
+// writeArchive 写入清单与全部数据
+func writeArchive(w io.Writer, m manifest) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    manifestName,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: m.Created,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	for _, p := range m.Plugins {
+		for _, mp := range p.Paths {
+			prefix := path.Join(p.ID, strconv.Itoa(mp.Index))
+			if !mp.Dir {
+				if err := addFile(tw, mp.Path, path.Join(prefix, filepath.Base(mp.Path))); err != nil {
+					return err
+				}
+				continue
+			}
+
+			err := filepath.WalkDir(mp.Path, func(src string, d fs.DirEntry, err error) error {
+				if err != nil || !d.Type().IsRegular() {
+					return err
+				}
+				rel, err := filepath.Rel(mp.Path, src)
+				if err != nil {
+					return err
+				}
+				return addFile(tw, src, path.Join(prefix, filepath.ToSlash(rel)))
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// addFile 将单个文件写入数据包
+func addFile(tw *tar.Writer, src, name string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    int64(info.Mode().Perm()),
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// -------------------- import-data --------------------
+
+func (a *App) importData(args []string) error {
+	fs, o := a.flagSet("import-data")
+	input := fs.String("i", "", "数据包路径（也可作为第一个参数给出）")
+	force := fs.Bool("force", false, "覆盖已存在的数据文件")
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	ids := fs.Args()
+	if *input == "" && len(ids) > 0 {
+		*input, ids = ids[0], ids[1:]
+	}
+	if *input == "" {
+		fmt.Fprintf(a.stderr(), "用法: %s import-data [参数] <数据包> [插件 ID...]\n", a.Name)
+		fs.PrintDefaults()
+		return errUsage
+	}
+
+	if err := a.load(o, false); err != nil {
+		return err
+	}
+
+	plugins, err := selectPlugins(a.newPlugins(), ids)
+	if err != nil {
+		return err
+	}
+	providers := dataProviders(plugins)
+
+	// 第一遍：读取清单并确定每个条目的目标路径，检查冲突
+	m, targets, err := planImport(*input, providers)
+	if err != nil {
+		return err
+	}
+
+	if !*force {
+		var conflicts []string
+		for _, dst := range targets {
+			if _, err := os.Stat(dst); err == nil {
+				conflicts = append(conflicts, dst)
+			}
+		}
+		if len(conflicts) > 0 {
+			sort.Strings(conflicts)
+			for _, c := range conflicts {
+				fmt.Fprintf(a.stderr(), "  %s\n", c)
+			}
+			return fmt.Errorf("%d 个数据文件已存在，使用 -force 覆盖（请先停止 Bot）", len(conflicts))
+		}
+	}
+
+	// 第二遍：写入文件
+	n, err := extractArchive(*input, targets)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range m.Plugins {
+		if _, ok := providers[p.ID]; !ok {
+			logger.Warn().Str("plugin", p.ID).Msg("插件未启用或未选择，已跳过")
+		}
+	}
+	fmt.Fprintf(a.stdout(), "已从 %s 导入 %d 个文件\n", *input, n)
+	return nil
+}
+
+// openArchive 打开数据包并读取清单
+func openArchive(name string) (*tar.Reader, manifest, func() error, error) {
+	var m manifest
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, m, nil, err
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, m, nil, fmt.Errorf("不是有效的数据包: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		f.Close()
+		return nil, m, nil, fmt.Errorf("不是有效的数据包: 缺少 %s", manifestName)
+	}
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		f.Close()
+		return nil, m, nil, fmt.Errorf("解析 %s 失败: %w", manifestName, err)
+	}
+	return tr, m, f.Close, nil
+}
+
+// planImport 计算数据包中每个条目在当前环境中的目标路径，未启用插件的条目不在结果中
+func planImport(name string, providers map[string]plugin.PluginDataProvider) (manifest, map[string]string, error) {
+	tr, m, closeFn, err := openArchive(name)
+	if err != nil {
+		return m, nil, err
+	}
+	defer closeFn()
+
+	entries := make(map[string]map[int]manifestPath, len(m.Plugins))
+	for _, p := range m.Plugins {
+		byIndex := make(map[int]manifestPath, len(p.Paths))
+		for _, mp := range p.Paths {
+			byIndex[mp.Index] = mp
+		}
+		entries[p.ID] = byIndex
+	}
+
+	targets := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		parts := strings.SplitN(hdr.Name, "/", 3)
+		if len(parts) != 3 {
+			return m, nil, fmt.Errorf("数据包条目无效: %s", hdr.Name)
+		}
+		id, rel := parts[0], parts[2]
+		index, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return m, nil, fmt.Errorf("数据包条目无效: %s", hdr.Name)
+		}
+
+		dp, ok := providers[id]
+		if !ok {
+			continue
+		}
+		mp, ok := entries[id][index]
+		paths := dp.DataPaths()
+		if !ok || index < 0 || index >= len(paths) {
+			logger.Warn().Str("plugin", id).Int("index", index).Msg("插件已没有对应的数据路径，已跳过")
+			continue
+		}
+
+		dst := paths[index]
+		if mp.Dir {
+			dst, err = safeJoin(dst, rel)
+			if err != nil {
+				return m, nil, fmt.Errorf("数据包条目 %s: %w", hdr.Name, err)
+			}
+		}
+		targets[hdr.Name] = dst
+	}
+	return m, targets, nil
+}
+
+// extractArchive 将 targets 中的条目写入目标路径，返回写入的文件数
+func extractArchive(name string, targets map[string]string) (int, error) {
+	tr, _, closeFn, err := openArchive(name)
+	if err != nil {
+		return 0, err
+	}
+	defer closeFn()
+
+	n := 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		dst, ok := targets[hdr.Name]
+		if !ok {
+			continue
+		}
+		if err := writeFileAtomic(dst, tr, fs.FileMode(hdr.Mode).Perm()); err != nil {
+			return n, fmt.Errorf("写入 %s 失败: %w", dst, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// safeJoin 拼接目录与数据包中的相对路径，拒绝越出目录的路径
+func safeJoin(dir, rel string) (string, error) {
+	if rel == "" || path.IsAbs(rel) || !fs.ValidPath(rel) {
+		return "", fmt.Errorf("非法路径")
+	}
+	return filepath.Join(dir, filepath.FromSlash(rel)), nil
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免中途失败留下残缺的数据文件
+func writeFileAtomic(dst string, r io.Reader, perm fs.FileMode) error {
+	if perm == 0 {
+		perm = 0644
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
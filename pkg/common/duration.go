@@ -0,0 +1,99 @@
+package common
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Forever 表示永久的时长（如永久禁言、永久封禁）
+const Forever time.Duration = -1
+
+// durationUnits 支持的时长单位
+var durationUnits = map[string]time.Duration{
+	"s": time.Second, "秒": time.Second,
+	"m": time.Minute, "min": time.Minute, "分": time.Minute, "分钟": time.Minute,
+	"h": time.Hour, "小时": time.Hour, "时": time.Hour,
+	"d": 24 * time.Hour, "天": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "周": 7 * 24 * time.Hour,
+}
+
+// ParseDuration 解析管理命令中的时长，如 "30s"、"10m"、"2h"、"1d12h"、"3天"，
+// "永久" / "forever" / "perm" 返回 Forever
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "":
+		return 0, fmt.Errorf("时长为空")
+	case "永久", "forever", "perm", "permanent":
+		return Forever, nil
+	}
+
+	var total time.Duration
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		// 数字部分
+		start := i
+		for i < len(runes) && unicode.IsDigit(runes[i]) {
+			i++
+		}
+		if start == i {
+			return 0, fmt.Errorf("无效的时长 %q", s)
+		}
+		n, err := strconv.Atoi(string(runes[start:i]))
+		if err != nil {
+			return 0, fmt.Errorf("无效的时长 %q", s)
+		}
+
+		// 单位部分
+		start = i
+		for i < len(runes) && !unicode.IsDigit(runes[i]) {
+			i++
+		}
+		unit, ok := durationUnits[string(runes[start:i])]
+		if !ok {
+			return 0, fmt.Errorf("无效的时长单位 %q（支持 s/m/h/d/w 或 秒/分钟/小时/天/周）", string(runes[start:i]))
+		}
+		if time.Duration(n) > (math.MaxInt64-total)/unit {
+			return 0, fmt.Errorf("时长 %q 过长", s)
+		}
+		total += time.Duration(n) * unit
+	}
+
+	if total <= 0 {
+		return 0, fmt.Errorf("时长必须大于 0")
+	}
+	return total, nil
+}
+
+// FormatDuration 以中文输出时长，如 "1天12小时"、"10分钟"，Forever 输出 "永久"
+func FormatDuration(d time.Duration) string {
+	if d == Forever {
+		return "永久"
+	}
+	if d < time.Second {
+		return "0秒"
+	}
+
+	parts := []struct {
+		unit time.Duration
+		name string
+	}{
+		{24 * time.Hour, "天"},
+		{time.Hour, "小时"},
+		{time.Minute, "分钟"},
+		{time.Second, "秒"},
+	}
+
+	var sb strings.Builder
+	for _, p := range parts {
+		if n := d / p.unit; n > 0 {
+			fmt.Fprintf(&sb, "%d%s", n, p.name)
+			d -= n * p.unit
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"秒", "30s", 30 * time.Second, false},
+		{"分钟", "10m", 10 * time.Minute, false},
+		{"组合", "1d12h", 36 * time.Hour, false},
+		{"中文单位", "3天", 72 * time.Hour, false},
+		{"中文组合", "1小时30分钟", 90 * time.Minute, false},
+		{"周", "2w", 14 * 24 * time.Hour, false},
+		{"忽略大小写与空白", " 2H ", 2 * time.Hour, false},
+		{"永久", "永久", Forever, false},
+		{"forever", "Forever", Forever, false},
+		{"空", "", 0, true},
+		{"缺少单位", "10", 0, true},
+		{"未知单位", "10y", 0, true},
+		{"缺少数字", "h", 0, true},
+		{"为零", "0s", 0, true},
+		{"数字溢出", "99999999999999999999s", 0, true},
+		{"乘积溢出", "9999999999w", 0, true},
+		{"累加溢出", "106751d106751d", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ParseDuration(%q) error = %v, wantErr %v", tt.name, tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: ParseDuration(%q) = %v, want %v", tt.name, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{Forever, "永久"},
+		{0, "0秒"},
+		{500 * time.Millisecond, "0秒"},
+		{30 * time.Second, "30秒"},
+		{10 * time.Minute, "10分钟"},
+		{36 * time.Hour, "1天12小时"},
+		{time.Hour + time.Second, "1小时1秒"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.d); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
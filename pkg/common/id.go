@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/hex"
 	"fmt"
@@ -26,8 +27,13 @@ func Sha1Hash(data []byte) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-func FetchFile(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+// FetchFile 下载文件内容，ctx 取消或到期时中止
+func FetchFile(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
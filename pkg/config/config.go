@@ -1,80 +1,165 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 
+	"yueling_tg/internal/core/log"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
+var logger = log.NewSystem("配置")
+
 // -------------------- 配置管理器 --------------------
 
-// ConfigManager 全局配置管理器
+// ConfigManager 配置管理器。单 Bot 时使用全局实例；同一进程运行多个 Bot 时每个 Bot 一个，
+// 各自拥有配置文件、环境变量命名空间、数据目录与插件配置结构
 type ConfigManager struct {
 	viper  *viper.Viper
 	mu     sync.RWMutex
 	path   string
 	format string // json, toml, yaml 等
+
+	name    string            // 命名空间（Bot 名称），为空表示默认 Bot
+	dataDir string            // 数据根目录，为空时使用 SetDataDir 设置的全局目录
+	schemas map[string]Schema // 本实例注册的插件配置结构，优先于全局注册的结构
+
+	problems []error // 启动阶段收集的配置错误，由 Problems 统一报告
+
+	// 热更新
+	subs     []subscription
+	exec     Executor
+	watcher  *fsnotify.Watcher
+	subMu    sync.Mutex
+	reloadMu sync.Mutex
 }
 
 var (
 	globalManager *ConfigManager
 	once          sync.Once
+
+	// 当前生效的配置管理器，由 With 临时切换，未切换时为全局实例
+	active  atomic.Pointer[ConfigManager]
+	scopeMu sync.Mutex
 )
 
-// InitConfigManager 初始化全局配置管理器（在bot启动时调用）
+// NewManager 创建关联配置文件的配置管理器，文件不存在时创建
 // configPath: 配置文件路径，如 "./config/plugins.json" 或 "./config/plugins.toml"
-func InitConfigManager(configPath string) error {
-	var err error
-	once.Do(func() {
-		v := viper.New()
-
-		// 自动检测文件格式
-		ext := filepath.Ext(configPath)
-		if len(ext) > 0 {
-			ext = ext[1:] // 去掉点号
-		} else {
-			ext = "json" // 默认格式
-		}
+func NewManager(configPath string) (*ConfigManager, error) {
+	v := viper.New()
+
+	// 自动检测文件格式
+	ext := filepath.Ext(configPath)
+	if len(ext) > 0 {
+		ext = ext[1:] // 去掉点号
+	} else {
+		ext = "json" // 默认格式
+	}
 
-		v.SetConfigFile(configPath)
-		v.SetConfigType(ext)
+	v.SetConfigFile(configPath)
+	v.SetConfigType(ext)
 
-		globalManager = &ConfigManager{
-			viper:  v,
-			path:   configPath,
-			format: ext,
-		}
+	cm := &ConfigManager{
+		viper:   v,
+		path:    configPath,
+		format:  ext,
+		schemas: make(map[string]Schema),
+	}
 
-		// 尝试读取配置文件
-		if err = v.ReadInConfig(); err != nil {
-			if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-				// 配置文件不存在，创建默认配置
-				if err = globalManager.ensureConfigDir(); err != nil {
-					return
-				}
-				err = globalManager.save()
-				return
+	// 尝试读取配置文件
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) || errors.Is(err, fs.ErrNotExist) {
+			// 配置文件不存在，创建默认配置
+			if err := cm.ensureConfigDir(); err != nil {
+				return cm, err
 			}
-			err = fmt.Errorf("读取配置文件失败: %w", err)
+			return cm, cm.save()
 		}
+		return cm, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	return cm, nil
+}
+
+// NewEmptyManager 创建不关联文件的空配置，所有配置均取默认值
+func NewEmptyManager() *ConfigManager {
+	return &ConfigManager{viper: viper.New(), format: "toml", schemas: make(map[string]Schema)}
+}
+
+// InitConfigManager 初始化全局配置管理器（在bot启动时调用）
+func InitConfigManager(configPath string) error {
+	var err error
+	once.Do(func() {
+		globalManager, err = NewManager(configPath)
 	})
 
 	return err
 }
 
-// GetManager 获取全局配置管理器实例
+// InitEmptyConfigManager 初始化不关联文件的空配置，所有配置均取默认值（用于生成默认配置）
+func InitEmptyConfigManager() {
+	once.Do(func() {
+		globalManager = NewEmptyManager()
+	})
+}
+
+// GetManager 获取当前生效的配置管理器：With 中为指定的实例，否则为全局实例
 func GetManager() *ConfigManager {
-	if globalManager == nil {
+	cm := Current()
+	if cm == nil {
 		panic("配置管理器未初始化，请先调用 InitConfigManager")
 	}
+	return cm
+}
+
+// Current 返回当前生效的配置管理器，未初始化时为 nil
+func Current() *ConfigManager {
+	if cm := active.Load(); cm != nil {
+		return cm
+	}
 	return globalManager
 }
 
+// With 在 fn 执行期间将 cm 作为当前配置管理器，使插件构造函数中的 GetPluginConfigOrDefault、
+// DataPath 等包级函数作用于 cm。同一时间只有一个 With 生效，fn 中不能再调用 With。
+//
+// 当前配置管理器是进程级的，只应在构造期间使用：fn 返回后，处理器、goroutine 与定时器
+// 中的包级函数看到的是全局实例或其他 Bot 的配置。需要在运行期使用的配置与存储应在构造时
+// 取得，或通过 plugin.PluginContext.Config 取得构造时的配置管理器
+func With(cm *ConfigManager, fn func()) {
+	scopeMu.Lock()
+	defer scopeMu.Unlock()
+
+	prev := active.Swap(cm)
+	defer active.Store(prev)
+	fn()
+}
+
+// Name 返回命名空间，默认 Bot 为空
+func (cm *ConfigManager) Name() string {
+	return cm.name
+}
+
+// SetName 设置命名空间。设置后环境变量先查找 YUELING_<NAME>_<KEY>，再回退到 YUELING_<KEY>
+func (cm *ConfigManager) SetName(name string) {
+	cm.name = name
+}
+
+// Path 返回配置文件路径，空配置为空字符串
+func (cm *ConfigManager) Path() string {
+	return cm.path
+}
+
 // ensureConfigDir 确保配置文件目录存在
 func (cm *ConfigManager) ensureConfigDir() error {
 	dir := filepath.Dir(cm.path)
@@ -89,6 +174,10 @@ func (cm *ConfigManager) save() error {
 	cm.mu.RLock()
 	defer cm.mu.RUnlock()
 
+	if cm.path == "" {
+		return nil
+	}
+
 	if err := cm.ensureConfigDir(); err != nil {
 		return err
 	}
@@ -106,31 +195,30 @@ func (cm *ConfigManager) save() error {
 	return nil
 }
 
-// Get 获取指定插件的配置（返回 map[string]interface{}）
+// Get 获取指定插件的配置（返回 map[string]interface{}），已叠加环境变量覆盖
 func (cm *ConfigManager) Get(pluginID string) (map[string]interface{}, bool) {
-	cm.mu.RLock()
-	defer cm.mu.RUnlock()
-
-	key := fmt.Sprintf("plugins.%s", pluginID)
-	if !cm.viper.IsSet(key) {
+	raw, ok := cm.GetRaw(pluginID)
+	if !ok {
 		return nil, false
 	}
-
-	cfg := cm.viper.GetStringMap(key)
-	return cfg, true
+	cfg, ok := raw.(map[string]any)
+	return cfg, ok
 }
 
-// GetRaw 获取指定插件的原始配置（返回任意类型）
+// GetRaw 获取指定插件的原始配置（返回任意类型），已叠加环境变量覆盖
 func (cm *ConfigManager) GetRaw(pluginID string) (interface{}, bool) {
-	cm.mu.RLock()
-	defer cm.mu.RUnlock()
-
-	key := fmt.Sprintf("plugins.%s", pluginID)
-	if !cm.viper.IsSet(key) {
+	raw, err := cm.effective("plugins." + pluginID)
+	if err != nil || raw == nil {
 		return nil, false
 	}
+	return raw, true
+}
 
-	return cm.viper.Get(key), true
+// effective 返回配置段叠加环境变量覆盖后的内容
+func (cm *ConfigManager) effective(key string) (any, error) {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+	return cm.overlay(cm.viper, key)
 }
 
 // Set 设置指定插件的配置
@@ -168,7 +256,10 @@ func (cm *ConfigManager) Exists(pluginID string) bool {
 			return true
 		}
 	}
-	return false
+
+	// 只通过环境变量提供的配置
+	raw, err := cm.overlay(cm.viper, prefix)
+	return err == nil && raw != nil
 }
 
 // GetAllPluginIDs 获取所有已配置的插件ID列表
@@ -195,59 +286,129 @@ func (cm *ConfigManager) Reload() error {
 	return nil
 }
 
-// -------------------- 插件配置辅助函数 --------------------
+// GetSection 解析当前配置中的顶层配置段（如 [i18n]），不存在时保持 target 原值
+func GetSection(name string, target interface{}) error {
+	return GetManager().GetSection(name, target)
+}
 
-// GetPluginConfig 插件获取并解析自己的配置
-func GetPluginConfig(pluginID string, target interface{}) error {
-	manager := GetManager()
+// GetSection 解析顶层配置段，不存在时保持 target 原值。错误同时记录到 Problems 中
+func (cm *ConfigManager) GetSection(name string, target interface{}) error {
+	raw, err := cm.effective(name)
+	if err != nil {
+		err = fmt.Errorf("读取配置段 [%s] 失败: %w", name, err)
+		cm.report(err)
+		return err
+	}
 
-	raw, exists := manager.GetRaw(pluginID)
-	if !exists {
-		return fmt.Errorf("插件 %s 的配置不存在", pluginID)
+	if err := Decode(raw, target); err != nil {
+		err = fmt.Errorf("解析配置段 [%s] 失败: %w", name, err)
+		cm.report(err)
+		return err
+	}
+	if err := Validate(target); err != nil {
+		err = fmt.Errorf("配置段 [%s] 无效: %w", name, err)
+		cm.report(err)
+		return err
+	}
+	return nil
+}
+
+// Decode 将原始配置（如热更新回调收到的 old/new）解析到 target，raw 为 nil 时不做修改。
+// 配置中存在 target 没有的键时返回错误，避免拼写错误被静默忽略
+func Decode(raw any, target interface{}) error {
+	if raw == nil {
+		return nil
 	}
 
 	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		TagName: "mapstructure",
-		Result:  target,
+		TagName:     "mapstructure",
+		Result:      target,
+		ErrorUnused: true,
 	})
 	if err != nil {
 		return fmt.Errorf("创建解码器失败: %w", err)
 	}
+	return decoder.Decode(raw)
+}
+
+// -------------------- 插件配置辅助函数 --------------------
+
+// GetPluginConfig 插件获取并解析自己的配置
+func GetPluginConfig(pluginID string, target interface{}) error {
+	manager := GetManager()
+
+	raw, err := manager.effective("plugins." + pluginID)
+	if err != nil {
+		return fmt.Errorf("读取插件 %s 的配置失败: %w", pluginID, err)
+	}
+	if raw == nil {
+		return fmt.Errorf("插件 %s 的配置不存在", pluginID)
+	}
 
-	if err := decoder.Decode(raw); err != nil {
+	if err := Decode(raw, target); err != nil {
 		return fmt.Errorf("解析插件 %s 的配置失败: %w", pluginID, err)
 	}
+	if err := Validate(target); err != nil {
+		return fmt.Errorf("插件 %s 的配置无效: %w", pluginID, err)
+	}
 
 	return nil
 }
 
-// GetPluginConfigOrDefault 获取插件配置，如果不存在则使用默认值并保存
+// GetPluginConfigOrDefault 注册插件的配置结构并读取配置，未填写的键使用默认值。
+// 解析或校验失败时 target 保持默认值，错误被收集到 Problems 中由启动流程统一报告
 func GetPluginConfigOrDefault(pluginID string, target interface{}, defaultConfig interface{}) error {
 	manager := GetManager()
+	manager.registerSchema("plugins."+pluginID, defaultConfig)
 
-	if !manager.Exists(pluginID) {
-		// 配置不存在，使用默认值并保存
-		if err := manager.Set(pluginID, defaultConfig); err != nil {
-			return fmt.Errorf("保存插件 %s 的默认配置失败: %w", pluginID, err)
-		}
+	if err := setDefault(target, defaultConfig); err != nil {
+		return fmt.Errorf("解析插件 %s 的默认配置失败: %w", pluginID, err)
+	}
 
-		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-			TagName: "mapstructure",
-			Result:  target,
-		})
-		if err != nil {
-			return fmt.Errorf("创建解码器失败: %w", err)
-		}
+	if !manager.Exists(pluginID) {
+		return nil
+	}
 
-		if err := decoder.Decode(defaultConfig); err != nil {
-			return fmt.Errorf("解析默认配置失败: %w", err)
+	if err := GetPluginConfig(pluginID, target); err != nil {
+		manager.report(err)
+		if err := setDefault(target, defaultConfig); err != nil {
+			return fmt.Errorf("解析插件 %s 的默认配置失败: %w", pluginID, err)
 		}
+	}
+	return nil
+}
 
+// setDefault 将默认值写入 target，类型相同时直接赋值，否则按 mapstructure 解析
+func setDefault(target, defaultConfig interface{}) error {
+	tv := reflect.ValueOf(target)
+	if tv.Kind() != reflect.Pointer || tv.IsNil() {
+		return fmt.Errorf("target 必须是非空指针")
+	}
+	dv := reflect.ValueOf(defaultConfig)
+	if dv.IsValid() && dv.Type().AssignableTo(tv.Elem().Type()) {
+		tv.Elem().Set(dv)
 		return nil
 	}
+	return Decode(defaultConfig, target)
+}
 
-	// 配置存在，直接获取并解析
-	return GetPluginConfig(pluginID, target)
+// report 记录一个配置错误
+func (cm *ConfigManager) report(err error) {
+	cm.subMu.Lock()
+	defer cm.subMu.Unlock()
+	cm.problems = append(cm.problems, err)
+}
+
+// Problems 返回当前配置启动以来收集到的全部配置错误，没有时返回 nil
+func Problems() []error {
+	return GetManager().Problems()
+}
+
+// Problems 返回启动以来收集到的全部配置错误，没有时返回 nil
+func (cm *ConfigManager) Problems() []error {
+	cm.subMu.Lock()
+	defer cm.subMu.Unlock()
+	return append([]error(nil), cm.problems...)
 }
 
 // SetPluginConfig 插件设置自己的配置
@@ -280,13 +441,36 @@ func GetAllPlugins() []string {
 	return manager.GetAllPluginIDs()
 }
 
+// -------------------- 单个配置项 --------------------
+//
+// 以下函数读取插件配置中的单个键，与配置段一样先查找环境变量覆盖
+// （YUELING_[<BOT>_]PLUGINS_<插件>_<键>，支持 _FILE），再读取配置文件
+
+// envSetting 读取单个键的环境变量覆盖并转换为 t 类型，值无法解析时记录警告并视为未设置
+func (cm *ConfigManager) envSetting(fullKey string, t reflect.Type) (any, bool) {
+	value, ok, err := lookupEnv(cm.envNames(fullKey))
+	if err == nil && ok {
+		var parsed any
+		if parsed, err = parseEnvValue(value, t); err == nil {
+			return parsed, true
+		}
+	}
+	if err != nil {
+		logger.Warn().Err(err).Str("env", cm.EnvName(fullKey)).Msg("环境变量无效，已忽略")
+	}
+	return nil, false
+}
+
 // GetString 获取插件配置中的字符串值
 func GetString(pluginID, key string, defaultValue string) string {
 	manager := GetManager()
+	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
+	if v, ok := manager.envSetting(fullKey, reflect.TypeOf(defaultValue)); ok {
+		return v.(string)
+	}
+
 	manager.mu.RLock()
 	defer manager.mu.RUnlock()
-
-	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
 	if !manager.viper.IsSet(fullKey) {
 		return defaultValue
 	}
@@ -296,10 +480,13 @@ func GetString(pluginID, key string, defaultValue string) string {
 // GetInt 获取插件配置中的整数值
 func GetInt(pluginID, key string, defaultValue int) int {
 	manager := GetManager()
+	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
+	if v, ok := manager.envSetting(fullKey, reflect.TypeOf(defaultValue)); ok {
+		return int(v.(int64))
+	}
+
 	manager.mu.RLock()
 	defer manager.mu.RUnlock()
-
-	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
 	if !manager.viper.IsSet(fullKey) {
 		return defaultValue
 	}
@@ -309,23 +496,34 @@ func GetInt(pluginID, key string, defaultValue int) int {
 // GetBool 获取插件配置中的布尔值
 func GetBool(pluginID, key string, defaultValue bool) bool {
 	manager := GetManager()
+	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
+	if v, ok := manager.envSetting(fullKey, reflect.TypeOf(defaultValue)); ok {
+		return v.(bool)
+	}
+
 	manager.mu.RLock()
 	defer manager.mu.RUnlock()
-
-	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
 	if !manager.viper.IsSet(fullKey) {
 		return defaultValue
 	}
 	return manager.viper.GetBool(fullKey)
 }
 
-// GetStringSlice 获取插件配置中的字符串数组
+// GetStringSlice 获取插件配置中的字符串数组，环境变量中以逗号分隔
 func GetStringSlice(pluginID, key string, defaultValue []string) []string {
 	manager := GetManager()
+	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
+	if v, ok := manager.envSetting(fullKey, reflect.TypeOf(defaultValue)); ok {
+		list := v.([]any)
+		out := make([]string, len(list))
+		for i, item := range list {
+			out[i] = item.(string)
+		}
+		return out
+	}
+
 	manager.mu.RLock()
 	defer manager.mu.RUnlock()
-
-	fullKey := fmt.Sprintf("plugins.%s.%s", pluginID, key)
 	if !manager.viper.IsSet(fullKey) {
 		return defaultValue
 	}
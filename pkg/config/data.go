@@ -0,0 +1,56 @@
+package config
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// DefaultDataDir 默认数据根目录
+const DefaultDataDir = "./data"
+
+var (
+	dataDir   = DefaultDataDir
+	dataDirMu sync.RWMutex
+)
+
+// SetDataDir 设置全局数据根目录，需在创建插件之前调用（插件的默认路径以此为基准）
+func SetDataDir(dir string) {
+	if dir == "" {
+		dir = DefaultDataDir
+	}
+	dataDirMu.Lock()
+	defer dataDirMu.Unlock()
+	dataDir = dir
+}
+
+// SetDataDir 设置本实例的数据根目录，同一进程运行多个 Bot 时各自独立；为空时使用全局目录
+func (cm *ConfigManager) SetDataDir(dir string) {
+	dataDirMu.Lock()
+	defer dataDirMu.Unlock()
+	cm.dataDir = dir
+}
+
+// DataDir 返回本实例的数据根目录
+func (cm *ConfigManager) DataDir() string {
+	dataDirMu.RLock()
+	defer dataDirMu.RUnlock()
+	if cm.dataDir != "" {
+		return cm.dataDir
+	}
+	return dataDir
+}
+
+// DataDir 返回当前配置管理器的数据根目录，未初始化时为全局目录
+func DataDir() string {
+	if cm := Current(); cm != nil {
+		return cm.DataDir()
+	}
+	dataDirMu.RLock()
+	defer dataDirMu.RUnlock()
+	return dataDir
+}
+
+// DataPath 返回数据根目录下的路径，用于插件的默认配置，如 DataPath("reply.json")
+func DataPath(elem ...string) string {
+	return filepath.Join(append([]string{DataDir()}, elem...)...)
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// WriteDefaults 根据已注册的配置结构生成带注释的默认 config.toml
+func WriteDefaults(w io.Writer) error {
+	header := "# Yueling 默认配置，由 check-config -defaults 生成\n" +
+		"# 未填写的键使用默认值；未知的键会导致启动失败\n"
+	return writeSchemas(w, header, func(s Schema) (reflect.Value, error) {
+		return reflect.ValueOf(s.Default), nil
+	})
+}
+
+// WriteEffective 输出当前实际生效的配置（默认值、配置文件与环境变量叠加后），敏感字段显示为 ******
+func WriteEffective(w io.Writer) error {
+	manager := GetManager()
+	header := "# Yueling 当前生效的配置（敏感字段已隐藏）\n"
+	return writeSchemas(w, header, func(s Schema) (reflect.Value, error) {
+		target := reflect.New(reflect.TypeOf(s.Default))
+		target.Elem().Set(reflect.ValueOf(s.Default))
+
+		raw, err := manager.effective(s.Key)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if err := Decode(raw, target.Interface()); err != nil {
+			return reflect.Value{}, err
+		}
+		return target.Elem(), nil
+	})
+}
+
+// writeSchemas 依次输出全部已注册的配置段
+func writeSchemas(w io.Writer, header string, value func(Schema) (reflect.Value, error)) error {
+	var b bytes.Buffer
+	b.WriteString(header)
+
+	for _, s := range Schemas() {
+		v, err := value(s)
+		if err != nil {
+			return fmt.Errorf("读取配置段 %s 失败: %w", s.Key, err)
+		}
+		b.WriteString("\n")
+		if err := writeTable(&b, s.Key, v, false); err != nil {
+			return fmt.Errorf("生成配置段 %s 失败: %w", s.Key, err)
+		}
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// subTable 需要在当前表之后输出的子表
+type subTable struct {
+	key   string
+	value reflect.Value
+	array bool
+	doc   string
+}
+
+// writeTable 输出一个表：先输出普通键值，再输出子表与表数组
+func writeTable(b *bytes.Buffer, key string, v reflect.Value, array bool) error {
+	v = indirect(v)
+
+	if array {
+		fmt.Fprintf(b, "[[%s]]\n", key)
+	} else {
+		fmt.Fprintf(b, "[%s]\n", key)
+	}
+
+	var later []subTable
+	addField := func(name string, fv reflect.Value, doc string) error {
+		fv = indirect(fv)
+		switch {
+		case !fv.IsValid():
+			return nil
+		case isTable(fv):
+			later = append(later, subTable{key: key + "." + quoteKey(name), value: fv, doc: doc})
+		case isTableArray(fv):
+			later = append(later, subTable{key: key + "." + quoteKey(name), value: fv, array: true, doc: doc})
+		default:
+			writeComment(b, doc)
+			return writeKeyValue(b, name, fv)
+		}
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := fieldKey(f)
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			doc := fieldDoc(f)
+			if f.Type == reflect.TypeOf(Secret("")) {
+				env := EnvName(key + "." + name)
+				doc = strings.TrimSpace(doc + "\n敏感信息，建议通过环境变量 " + env + " 或 " + env + "_FILE 提供")
+			}
+			if err := addField(name, v.Field(i), doc); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, k := range keys {
+			if err := addField(fmt.Sprint(k.Interface()), v.MapIndex(k), ""); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, sub := range later {
+		if !sub.array {
+			b.WriteString("\n")
+			writeComment(b, sub.doc)
+			if err := writeTable(b, sub.key, sub.value, false); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b.WriteString("\n")
+		writeComment(b, sub.doc)
+		if sub.value.Len() == 0 {
+			fmt.Fprintf(b, "# [[%s]]\n", sub.key)
+			continue
+		}
+		for i := 0; i < sub.value.Len(); i++ {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			if err := writeTable(b, sub.key, sub.value.Index(i), true); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeKeyValue 输出单个键值，值的格式交给 go-toml
+func writeKeyValue(b *bytes.Buffer, name string, v reflect.Value) error {
+	data, err := toml.Marshal(map[string]any{name: v.Interface()})
+	if err != nil {
+		return err
+	}
+	b.Write(data)
+	return nil
+}
+
+func writeComment(b *bytes.Buffer, doc string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		fmt.Fprintf(b, "# %s\n", line)
+	}
+}
+
+// fieldDoc 字段说明：doc 标签加上由 validate 标签生成的提示
+func fieldDoc(f reflect.StructField) string {
+	doc := f.Tag.Get("doc")
+
+	var hints []string
+	for _, rule := range strings.Split(f.Tag.Get("validate"), ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			hints = append(hints, "必填")
+		case "min":
+			hints = append(hints, "最小 "+arg)
+		case "max":
+			hints = append(hints, "最大 "+arg)
+		case "oneof":
+			hints = append(hints, "可选 "+strings.Join(strings.Fields(arg), " / "))
+		case "url":
+			hints = append(hints, "地址")
+		}
+	}
+	if len(hints) == 0 {
+		return doc
+	}
+	if doc == "" {
+		return strings.Join(hints, "，")
+	}
+	return fmt.Sprintf("%s（%s）", doc, strings.Join(hints, "，"))
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isTable 结构体与 map 输出为子表
+func isTable(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct || v.Kind() == reflect.Map
+}
+
+// isTableArray 结构体数组输出为表数组
+func isTableArray(v reflect.Value) bool {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return false
+	}
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// quoteKey 非裸键需要加引号
+func quoteKey(k string) string {
+	if bareKey.MatchString(k) {
+		return k
+	}
+	return strconv.Quote(k)
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// -------------------- 环境变量覆盖 --------------------
+//
+// 已注册结构的配置段中，每个键都可以用环境变量覆盖：
+//
+//	[plugins.chat] api_key   →  YUELING_PLUGINS_CHAT_API_KEY
+//	[log.file] path          →  YUELING_LOG_FILE_PATH
+//
+// 变量名后加 _FILE 表示从文件读取值（去掉末尾换行），适合 Docker / Kubernetes secrets：
+//
+//	YUELING_PLUGINS_CHAT_API_KEY_FILE=/run/secrets/deepseek
+//
+// 同时设置时直接给出的值优先。数组使用逗号分隔。GetString、GetInt 等读取单个键的函数
+// 同样先查找环境变量，不需要注册结构。
+//
+// 同一进程运行多个 Bot 时，命名为 test 的 Bot 先查找 YUELING_TEST_<KEY>，再回退到 YUELING_<KEY>，
+// 因此各 Bot 共用的密钥只需设置一次。
+
+// EnvPrefix 环境变量前缀
+const EnvPrefix = "YUELING"
+
+var envUnsafe = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// EnvName 配置键对应的环境变量名，如 plugins.chat.api_key → YUELING_PLUGINS_CHAT_API_KEY
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.Trim(envUnsafe.ReplaceAllString(key, "_"), "_"))
+}
+
+// EnvName 配置键在本实例命名空间下的环境变量名，如 Bot test 的 bot.token → YUELING_TEST_BOT_TOKEN
+func (cm *ConfigManager) EnvName(key string) string {
+	return cm.envNames(key)[0]
+}
+
+// envNames 按查找顺序返回配置键对应的环境变量名
+func (cm *ConfigManager) envNames(key string) []string {
+	if cm.name == "" {
+		return []string{EnvName(key)}
+	}
+	return []string{EnvName(cm.name + "." + key), EnvName(key)}
+}
+
+// lookupEnv 按顺序读取环境变量，支持 _FILE 间接引用
+func lookupEnv(names []string) (string, bool, error) {
+	for _, name := range names {
+		if v, ok := os.LookupEnv(name); ok {
+			return v, true, nil
+		}
+		if path, ok := os.LookupEnv(name + "_FILE"); ok && path != "" {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", false, fmt.Errorf("读取 %s_FILE 指向的文件失败: %w", name, err)
+			}
+			return strings.TrimRight(string(data), "\r\n"), true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// overlay 返回配置段的实际内容：v 中的值叠加环境变量覆盖。
+// 配置文件与环境变量都没有内容时返回 nil
+func (cm *ConfigManager) overlay(v *viper.Viper, key string) (any, error) {
+	raw := v.Get(key)
+
+	schema, ok := cm.lookupSchema(key)
+	if !ok || schema.Default == nil {
+		return raw, nil
+	}
+
+	base, _ := raw.(map[string]any)
+	merged, changed, err := cm.overlayEnv(key, reflect.TypeOf(schema.Default), base)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return raw, nil
+	}
+	return merged, nil
+}
+
+// overlayEnv 按结构体字段查找环境变量并写入 raw 的副本
+func (cm *ConfigManager) overlayEnv(key string, t reflect.Type, raw map[string]any) (map[string]any, bool, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return raw, false, nil
+	}
+
+	out := make(map[string]any, len(raw))
+	for k, v := range raw {
+		out[k] = v
+	}
+
+	changed := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := fieldKey(f)
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		fullKey := key + "." + name
+
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		// 嵌套结构体递归处理，map 与结构体数组不支持覆盖
+		if ft.Kind() == reflect.Struct {
+			sub, _ := out[name].(map[string]any)
+			merged, subChanged, err := cm.overlayEnv(fullKey, ft, sub)
+			if err != nil {
+				return nil, false, err
+			}
+			if subChanged {
+				out[name] = merged
+				changed = true
+			}
+			continue
+		}
+
+		value, ok, err := lookupEnv(cm.envNames(fullKey))
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			continue
+		}
+
+		parsed, err := parseEnvValue(value, ft)
+		if err != nil {
+			return nil, false, fmt.Errorf("环境变量 %s: %w", cm.EnvName(fullKey), err)
+		}
+		out[name] = parsed
+		changed = true
+	}
+	return out, changed, nil
+}
+
+// parseEnvValue 将环境变量的字符串值转换为字段类型
+func parseEnvValue(s string, t reflect.Type) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Bool:
+		return strconv.ParseBool(strings.TrimSpace(s))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(strings.TrimSpace(s), 64)
+	case reflect.Slice:
+		if s == "" {
+			return []any{}, nil
+		}
+		parts := strings.Split(s, ",")
+		list := make([]any, 0, len(parts))
+		for _, p := range parts {
+			v, err := parseEnvValue(strings.TrimSpace(p), t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("不支持通过环境变量设置 %s 类型", t)
+}
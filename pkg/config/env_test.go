@@ -0,0 +1,217 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := map[string]string{
+		"plugins.chat.api_key": "YUELING_PLUGINS_CHAT_API_KEY",
+		"log.file.path":        "YUELING_LOG_FILE_PATH",
+		"plugins.my-plugin.x":  "YUELING_PLUGINS_MY_PLUGIN_X",
+	}
+	for key, want := range tests {
+		if got := EnvName(key); got != want {
+			t.Errorf("EnvName(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	cm := NewEmptyManager()
+	cm.SetName("test")
+	if got := cm.EnvName("bot.token"); got != "YUELING_TEST_BOT_TOKEN" {
+		t.Errorf("named EnvName = %q", got)
+	}
+}
+
+func TestParseEnvValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		typ     any
+		want    any
+		wantErr bool
+	}{
+		{"字符串原样保留", " a b ", "", " a b ", false},
+		{"布尔", "true", false, true, false},
+		{"整数", " 42 ", 0, int64(42), false},
+		{"无符号整数", "7", uint(0), uint64(7), false},
+		{"浮点数", "0.5", 0.0, 0.5, false},
+		{"数组", "a, b", []string{}, []any{"a", "b"}, false},
+		{"整数数组", "1,2", []int64{}, []any{int64(1), int64(2)}, false},
+		{"空数组", "", []string{}, []any{}, false},
+		{"无效布尔", "yes please", false, nil, true},
+		{"无效整数", "x", 0, nil, true},
+		{"数组元素无效", "1,x", []int{}, nil, true},
+		{"不支持的类型", "x", map[string]int{}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseEnvValue(tt.value, reflect.TypeOf(tt.typ))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: parseEnvValue(%q) = %#v, want %#v", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEnvOverlay(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	content := `
+[plugins.demo]
+name = "file"
+mode = "slow"
+
+[plugins.demo.inner]
+count = 2
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "token")
+	if err := os.WriteFile(secret, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		bot     string
+		env     map[string]string
+		want    testConfig
+		wantErr bool
+	}{
+		{
+			name: "只有配置文件",
+			want: testConfig{Name: "file", Mode: "slow", Inner: innerConfig{Count: 2}},
+		},
+		{
+			name: "环境变量覆盖文件与默认值",
+			env: map[string]string{
+				"YUELING_PLUGINS_DEMO_MODE":        "fast",
+				"YUELING_PLUGINS_DEMO_RATIO":       "0.25",
+				"YUELING_PLUGINS_DEMO_TAGS":        "a,b",
+				"YUELING_PLUGINS_DEMO_INNER_COUNT": "5",
+			},
+			want: testConfig{Name: "file", Mode: "fast", Ratio: 0.25, Tags: []string{"a", "b"}, Inner: innerConfig{Count: 5}},
+		},
+		{
+			name: "_FILE 读取文件并去掉换行",
+			env:  map[string]string{"YUELING_PLUGINS_DEMO_TOKEN_FILE": secret},
+			want: testConfig{Name: "file", Mode: "slow", Inner: innerConfig{Count: 2}, Token: "s3cret"},
+		},
+		{
+			name: "直接给出的值优先于 _FILE",
+			env: map[string]string{
+				"YUELING_PLUGINS_DEMO_TOKEN":      "direct",
+				"YUELING_PLUGINS_DEMO_TOKEN_FILE": secret,
+			},
+			want: testConfig{Name: "file", Mode: "slow", Inner: innerConfig{Count: 2}, Token: "direct"},
+		},
+		{
+			name: "命名 Bot 优先查找自己的变量",
+			bot:  "test",
+			env: map[string]string{
+				"YUELING_TEST_PLUGINS_DEMO_NAME": "bot",
+				"YUELING_PLUGINS_DEMO_NAME":      "shared",
+				"YUELING_PLUGINS_DEMO_MODE":      "fast",
+			},
+			want: testConfig{Name: "bot", Mode: "fast", Inner: innerConfig{Count: 2}},
+		},
+		{
+			name:    "_FILE 文件不存在",
+			env:     map[string]string{"YUELING_PLUGINS_DEMO_TOKEN_FILE": filepath.Join(dir, "missing")},
+			wantErr: true,
+		},
+		{
+			name:    "值无法解析",
+			env:     map[string]string{"YUELING_PLUGINS_DEMO_RATIO": "high"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			cm, err := NewManager(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cm.SetName(tt.bot)
+			cm.registerSchema("plugins.demo", testConfig{})
+
+			var got testConfig
+			err = cm.GetSection("plugins.demo", &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSection error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScalarSettingsEnvOverlay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `
+[plugins.demo]
+name = "file"
+count = 2
+debug = false
+tags = ["a"]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cm, err := NewManager(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	With(cm, func() {
+		// 只有配置文件
+		if got := GetString("demo", "name", "def"); got != "file" {
+			t.Errorf("GetString = %q, want file", got)
+		}
+		if got := GetInt("demo", "missing", 7); got != 7 {
+			t.Errorf("GetInt missing = %d, want 7", got)
+		}
+
+		t.Setenv("YUELING_PLUGINS_DEMO_NAME", "env")
+		t.Setenv("YUELING_PLUGINS_DEMO_COUNT", "5")
+		t.Setenv("YUELING_PLUGINS_DEMO_DEBUG", "true")
+		t.Setenv("YUELING_PLUGINS_DEMO_TAGS", "x, y")
+		t.Setenv("YUELING_PLUGINS_DEMO_MISSING", "9")
+
+		if got := GetString("demo", "name", "def"); got != "env" {
+			t.Errorf("GetString = %q, want env", got)
+		}
+		if got := GetInt("demo", "count", 0); got != 5 {
+			t.Errorf("GetInt = %d, want 5", got)
+		}
+		if got := GetInt("demo", "missing", 7); got != 9 {
+			t.Errorf("GetInt missing = %d, want 9", got)
+		}
+		if got := GetBool("demo", "debug", false); !got {
+			t.Error("GetBool = false, want true")
+		}
+		if got := GetStringSlice("demo", "tags", nil); !reflect.DeepEqual(got, []string{"x", "y"}) {
+			t.Errorf("GetStringSlice = %q, want [x y]", got)
+		}
+
+		// 无法解析的值被忽略，回退到配置文件
+		t.Setenv("YUELING_PLUGINS_DEMO_COUNT", "many")
+		if got := GetInt("demo", "count", 0); got != 2 {
+			t.Errorf("GetInt invalid env = %d, want 2", got)
+		}
+	})
+}
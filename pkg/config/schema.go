@@ -0,0 +1,336 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// -------------------- 配置结构注册 --------------------
+//
+// 插件与全局配置段注册自己的配置结构及默认值，结构体字段可使用以下标签：
+//
+//	mapstructure:"max_members"        配置键名
+//	doc:"每个群最多保留多少活跃成员"     说明，生成默认配置时作为注释
+//	validate:"required,min=1,max=500" 校验规则
+//
+// 支持的校验规则：
+//   - required       不能为零值
+//   - min=N / max=N  数字的取值范围，字符串、数组与 map 的长度范围
+//   - oneof=a b c    只能取列出的值
+//   - url            必须是带协议与主机的地址（空值跳过，需要时配合 required）
+
+// Schema 已注册的配置结构
+type Schema struct {
+	Key     string // 配置键，如 "log"、"plugins.chat"
+	Default any    // 默认值（结构体）
+}
+
+var (
+	schemas   = make(map[string]Schema)
+	schemasMu sync.RWMutex
+)
+
+// RegisterSchema 注册全局配置段的结构与默认值，重复注册时覆盖
+func RegisterSchema(key string, defaults any) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	schemas[key] = Schema{Key: key, Default: defaults}
+}
+
+// RegisterPluginSchema 注册插件配置（plugins.<id>）的结构与默认值。
+// 注册到当前配置管理器，未初始化时注册为全局结构
+func RegisterPluginSchema(pluginID string, defaults any) {
+	if cm := Current(); cm != nil {
+		cm.registerSchema("plugins."+pluginID, defaults)
+		return
+	}
+	RegisterSchema("plugins."+pluginID, defaults)
+}
+
+// registerSchema 注册只属于本实例的配置结构。插件默认值通常包含数据路径，各 Bot 不同
+func (cm *ConfigManager) registerSchema(key string, defaults any) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	if cm.schemas == nil {
+		cm.schemas = make(map[string]Schema)
+	}
+	cm.schemas[key] = Schema{Key: key, Default: defaults}
+}
+
+// Schemas 返回当前配置管理器可见的全部配置结构：全局配置段在前，插件在后，各自按键名排序
+func Schemas() []Schema {
+	return Current().Schemas()
+}
+
+// Schemas 返回本实例可见的全部配置结构（本实例注册的优先于全局注册的），cm 可为 nil
+func (cm *ConfigManager) Schemas() []Schema {
+	schemasMu.RLock()
+	merged := make(map[string]Schema, len(schemas))
+	for k, s := range schemas {
+		merged[k] = s
+	}
+	if cm != nil {
+		for k, s := range cm.schemas {
+			merged[k] = s
+		}
+	}
+	schemasMu.RUnlock()
+
+	list := make([]Schema, 0, len(merged))
+	for _, s := range merged {
+		list = append(list, s)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		pi, pj := strings.HasPrefix(list[i].Key, "plugins."), strings.HasPrefix(list[j].Key, "plugins.")
+		if pi != pj {
+			return !pi
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list
+}
+
+// lookupSchema 查找配置段对应的结构，本实例注册的优先
+func (cm *ConfigManager) lookupSchema(key string) (Schema, bool) {
+	schemasMu.RLock()
+	defer schemasMu.RUnlock()
+	if s, ok := cm.schemas[key]; ok {
+		return s, ok
+	}
+	s, ok := schemas[key]
+	return s, ok
+}
+
+// validateRaw 按已注册的结构解析并校验原始配置，未注册时跳过
+func (cm *ConfigManager) validateRaw(key string, raw any) error {
+	s, ok := cm.lookupSchema(key)
+	if !ok || s.Default == nil {
+		return nil
+	}
+
+	target := reflect.New(reflect.TypeOf(s.Default))
+	target.Elem().Set(reflect.ValueOf(s.Default))
+	if err := Decode(raw, target.Interface()); err != nil {
+		return err
+	}
+	return Validate(target.Interface())
+}
+
+// Check 校验当前配置管理器的全部配置段，见 (*ConfigManager).Check
+func Check() []error {
+	return GetManager().Check()
+}
+
+// Check 按已注册的结构校验全部配置段，并报告没有对应结构的配置段（通常是拼写错误或未启用的插件）。
+// 与 Problems 不同，Check 每次都重新校验，适合在创建全部插件后调用
+func (cm *ConfigManager) Check() []error {
+	var errs []error
+	for _, s := range cm.Schemas() {
+		raw, err := cm.effective(s.Key)
+		if err == nil {
+			err = cm.validateRaw(s.Key, raw)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("[%s] %w", s.Key, err))
+		}
+	}
+
+	cm.mu.RLock()
+	settings := cm.viper.AllSettings()
+	cm.mu.RUnlock()
+
+	var unknown []string
+	for key, v := range settings {
+		if key != "plugins" {
+			if _, ok := cm.lookupSchema(key); !ok {
+				unknown = append(unknown, key)
+			}
+			continue
+		}
+		plugins, _ := v.(map[string]any)
+		for id := range plugins {
+			if _, ok := cm.lookupSchema("plugins." + id); !ok {
+				unknown = append(unknown, "plugins."+id)
+			}
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		errs = append(errs, fmt.Errorf("[%s] 未知的配置段", key))
+	}
+	return errs
+}
+
+// -------------------- 校验 --------------------
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field string // 字段路径，如 categories[0].count
+	Rule  string // 未通过的规则
+	Msg   string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Msg)
+}
+
+// Validate 按 validate 标签校验结构体（可为指针），返回全部错误；
+// 结构体实现了 Validate() error 时一并调用
+func Validate(v any) error {
+	var errs []error
+	validateValue(reflect.ValueOf(v), "", &errs)
+	return errors.Join(errs...)
+}
+
+func validateValue(v reflect.Value, path string, errs *[]error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() || fieldKey(f) == "-" {
+				continue
+			}
+			name := joinPath(path, fieldKey(f))
+			fv := v.Field(i)
+			if rules := f.Tag.Get("validate"); rules != "" {
+				checkRules(fv, name, rules, errs)
+			}
+			validateValue(fv, name, errs)
+		}
+
+		if v.CanAddr() {
+			if c, ok := v.Addr().Interface().(interface{ Validate() error }); ok {
+				if err := c.Validate(); err != nil {
+					field := path
+					if field == "" {
+						field = "配置"
+					}
+					*errs = append(*errs, &FieldError{Field: field, Rule: "custom", Msg: err.Error()})
+				}
+			}
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			validateValue(iter.Value(), joinPath(path, fmt.Sprint(iter.Key().Interface())), errs)
+		}
+	}
+}
+
+// checkRules 检查单个字段的全部规则
+func checkRules(v reflect.Value, field, rules string, errs *[]error) {
+	fail := func(rule, format string, args ...any) {
+		*errs = append(*errs, &FieldError{Field: field, Rule: rule, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "required":
+			if v.IsZero() {
+				fail(name, "不能为空")
+			}
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				fail(name, "规则 %s 的参数无效", rule)
+				continue
+			}
+			n, isLen, ok := measure(v)
+			if !ok {
+				continue
+			}
+			switch {
+			case name == "min" && n < limit && isLen:
+				fail(name, "长度不能小于 %s", arg)
+			case name == "min" && n < limit:
+				fail(name, "不能小于 %s（当前为 %v）", arg, v.Interface())
+			case name == "max" && n > limit && isLen:
+				fail(name, "长度不能大于 %s", arg)
+			case name == "max" && n > limit:
+				fail(name, "不能大于 %s（当前为 %v）", arg, v.Interface())
+			}
+		case "oneof":
+			if v.IsZero() {
+				continue
+			}
+			options := strings.Fields(arg)
+			current := fmt.Sprint(v.Interface())
+			matched := false
+			for _, opt := range options {
+				if strings.EqualFold(opt, current) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				fail(name, "只能是 %s 之一（当前为 %s）", strings.Join(options, " / "), current)
+			}
+		case "url":
+			if v.Kind() != reflect.String || v.String() == "" {
+				continue
+			}
+			if u, err := url.Parse(v.String()); err != nil || u.Scheme == "" || u.Host == "" {
+				fail(name, "不是有效的地址: %s", v.Interface())
+			}
+		default:
+			fail(name, "未知的校验规则 %s", name)
+		}
+	}
+}
+
+// measure 返回用于 min/max 比较的数值：数字取值，字符串、数组与 map 取长度
+func measure(v reflect.Value) (n float64, isLen bool, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	case reflect.String:
+		return float64(len([]rune(v.String()))), true, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true, true
+	}
+	return 0, false, false
+}
+
+// fieldKey 字段对应的配置键：mapstructure 标签，缺省时为小写字段名
+func fieldKey(f reflect.StructField) string {
+	if tag := f.Tag.Get("mapstructure"); tag != "" {
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(f.Name)
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
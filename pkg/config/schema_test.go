@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type innerConfig struct {
+	Count int `mapstructure:"count" validate:"min=1,max=10"`
+}
+
+type testConfig struct {
+	Name     string                 `mapstructure:"name" validate:"required"`
+	Mode     string                 `mapstructure:"mode" validate:"oneof=fast slow"`
+	Endpoint string                 `mapstructure:"endpoint" validate:"url"`
+	Ratio    float64                `mapstructure:"ratio" validate:"min=0,max=1"`
+	Tags     []string               `mapstructure:"tags" validate:"max=2"`
+	Inner    innerConfig            `mapstructure:"inner"`
+	Items    []innerConfig          `mapstructure:"items"`
+	Chats    map[string]innerConfig `mapstructure:"chats"`
+	Token    Secret                 `mapstructure:"token"`
+	Level    string                 `mapstructure:"level"`
+}
+
+// Validate 自定义校验
+func (c *testConfig) Validate() error {
+	if c.Level == "bad" {
+		return errors.New("level 不能为 bad")
+	}
+	return nil
+}
+
+func validTestConfig() testConfig {
+	return testConfig{Name: "bot", Inner: innerConfig{Count: 1}}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *testConfig)
+		want   []string // 出错的字段与规则，空表示有效
+	}{
+		{"有效", func(c *testConfig) {}, nil},
+		{"required", func(c *testConfig) { c.Name = "" }, []string{"name:required"}},
+		{"oneof 空值跳过", func(c *testConfig) { c.Mode = "" }, nil},
+		{"oneof 忽略大小写", func(c *testConfig) { c.Mode = "FAST" }, nil},
+		{"oneof", func(c *testConfig) { c.Mode = "medium" }, []string{"mode:oneof"}},
+		{"url", func(c *testConfig) { c.Endpoint = "localhost" }, []string{"endpoint:url"}},
+		{"url 有效", func(c *testConfig) { c.Endpoint = "http://localhost:4318/v1" }, nil},
+		{"数字上限", func(c *testConfig) { c.Ratio = 1.5 }, []string{"ratio:max"}},
+		{"数字下限", func(c *testConfig) { c.Ratio = -1 }, []string{"ratio:min"}},
+		{"长度上限", func(c *testConfig) { c.Tags = []string{"a", "b", "c"} }, []string{"tags:max"}},
+		{"嵌套结构", func(c *testConfig) { c.Inner.Count = 0 }, []string{"inner.count:min"}},
+		{"数组元素", func(c *testConfig) { c.Items = []innerConfig{{Count: 1}, {Count: 11}} }, []string{"items[1].count:max"}},
+		{"map 值", func(c *testConfig) { c.Chats = map[string]innerConfig{"-100": {Count: 0}} }, []string{"chats.-100.count:min"}},
+		{"自定义校验", func(c *testConfig) { c.Level = "bad" }, []string{"配置:custom"}},
+		{"多个错误", func(c *testConfig) { c.Name, c.Mode = "", "x" }, []string{"name:required", "mode:oneof"}},
+	}
+	for _, tt := range tests {
+		c := validTestConfig()
+		tt.modify(&c)
+
+		var got []string
+		if err := Validate(&c); err != nil {
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				var fe *FieldError
+				if !errors.As(e, &fe) {
+					t.Fatalf("%s: unexpected error type %T", tt.name, e)
+				}
+				got = append(got, fe.Field+":"+fe.Rule)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: errors = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	var c struct {
+		Name string `mapstructure:"name" validate:"email"`
+	}
+	if err := Validate(&c); err == nil || !strings.Contains(err.Error(), "未知的校验规则") {
+		t.Errorf("Validate = %v, want unknown rule error", err)
+	}
+}
+
+func TestDecodeStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     any
+		wantErr bool
+	}{
+		{"已知键", map[string]any{"name": "bot", "inner": map[string]any{"count": 3}}, false},
+		{"nil 不修改", nil, false},
+		{"未知键", map[string]any{"nmae": "bot"}, true},
+		{"嵌套未知键", map[string]any{"inner": map[string]any{"cnt": 3}}, true},
+		{"类型错误", map[string]any{"ratio": "high"}, true},
+	}
+	for _, tt := range tests {
+		c := validTestConfig()
+		err := Decode(tt.raw, &c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Decode error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
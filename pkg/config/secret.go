@@ -0,0 +1,40 @@
+package config
+
+// Secret 敏感配置（密钥、令牌、带账号的代理地址等）。
+// 打印、JSON/TOML 序列化与日志中都只显示 ******，取原值需调用 Value
+type Secret string
+
+const redacted = "******"
+
+// Value 返回原值
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// IsSet 是否已设置
+func (s Secret) IsSet() bool {
+	return s != ""
+}
+
+// String 实现 fmt.Stringer，未设置时为空字符串
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString 实现 fmt.GoStringer，避免 %#v 泄露原值
+func (s Secret) GoString() string {
+	return `config.Secret("` + s.String() + `")`
+}
+
+// MarshalText 实现 encoding.TextMarshaler
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// MarshalJSON 实现 json.Marshaler
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + s.String() + `"`), nil
+}
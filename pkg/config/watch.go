@@ -0,0 +1,297 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"yueling_tg/internal/core/log"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+var watchLogger = log.NewSystem("配置热更新")
+
+// DefaultDebounce 文件变更后等待多久再重新加载，合并编辑器的多次写入
+const DefaultDebounce = 500 * time.Millisecond
+
+// -------------------- 变更监听 --------------------
+
+// Listener 配置段变更监听器
+type Listener struct {
+	// Validate 应用前校验新配置，任一监听器校验失败则整个修改被拒绝（可为空）
+	Validate func(raw any) error
+	// Apply 应用新配置，old/new 为原始配置（配置段不存在时为 nil）。
+	// 返回错误时已应用的监听器会以 Apply(new, old) 回滚，配置恢复为修改前的内容
+	Apply func(old, new any) error
+}
+
+type subscription struct {
+	key      string
+	listener Listener
+}
+
+// Executor 执行配置变更回调的方式，运行时会将回调放到事件循环中执行，避免与处理器并发
+type Executor func(fn func())
+
+// WatchSection 监听顶层配置段（如 "log"）的变更
+func (cm *ConfigManager) WatchSection(name string, l Listener) {
+	cm.subMu.Lock()
+	defer cm.subMu.Unlock()
+	cm.subs = append(cm.subs, subscription{key: name, listener: l})
+}
+
+// WatchPlugin 监听插件配置段 plugins.<id> 的变更
+func (cm *ConfigManager) WatchPlugin(pluginID string, l Listener) {
+	cm.WatchSection("plugins."+pluginID, l)
+}
+
+// SetExecutor 设置回调执行方式，nil 表示在监听协程中直接执行
+func (cm *ConfigManager) SetExecutor(exec Executor) {
+	cm.subMu.Lock()
+	defer cm.subMu.Unlock()
+	cm.exec = exec
+}
+
+// OnPluginConfigChange 以类型化的方式监听插件配置变更：新配置先解析为 T
+// 并按 validate 标签与 Validate() error 校验，通过后才调用 fn
+func OnPluginConfigChange[T any](pluginID string, fn func(old, new T) error) {
+	manager := GetManager()
+	decode := func(raw any) (T, error) {
+		var cfg T
+		if schema, ok := manager.lookupSchema("plugins." + pluginID); ok {
+			if def, ok := schema.Default.(T); ok {
+				cfg = def
+			}
+		}
+		if err := Decode(raw, &cfg); err != nil {
+			return cfg, fmt.Errorf("解析插件 %s 的配置失败: %w", pluginID, err)
+		}
+		if err := Validate(&cfg); err != nil {
+			return cfg, fmt.Errorf("插件 %s 的配置无效: %w", pluginID, err)
+		}
+		return cfg, nil
+	}
+
+	manager.WatchPlugin(pluginID, Listener{
+		Validate: func(raw any) error {
+			_, err := decode(raw)
+			return err
+		},
+		Apply: func(oldRaw, newRaw any) error {
+			oldCfg, _ := decode(oldRaw)
+			newCfg, err := decode(newRaw)
+			if err != nil {
+				return err
+			}
+			return fn(oldCfg, newCfg)
+		},
+	})
+}
+
+// -------------------- 文件监听 --------------------
+
+// Watch 开始监听配置文件，变更在 debounce 时间内合并后重新加载
+func (cm *ConfigManager) Watch(debounce time.Duration) error {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听失败: %w", err)
+	}
+
+	// 监听所在目录：编辑器常以"写临时文件 + 重命名"的方式保存
+	target := filepath.Clean(cm.path)
+	if err := watcher.Add(filepath.Dir(target)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("监听配置目录失败: %w", err)
+	}
+
+	cm.subMu.Lock()
+	if cm.watcher != nil {
+		cm.watcher.Close()
+	}
+	cm.watcher = watcher
+	cm.subMu.Unlock()
+
+	go func() {
+		var (
+			timer *time.Timer
+			mu    sync.Mutex
+		)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != target || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+
+				mu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(debounce, func() {
+					if err := cm.ReloadAndNotify(); err != nil {
+						watchLogger.Error().Err(err).Str("path", cm.path).Msg("配置热更新失败，已保留修改前的配置")
+					}
+				})
+				mu.Unlock()
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				watchLogger.Warn().Err(err).Msg("配置文件监听出错")
+			}
+		}
+	}()
+
+	watchLogger.Info().Str("path", cm.path).Msg("已开始监听配置文件")
+	return nil
+}
+
+// StopWatch 停止监听配置文件
+func (cm *ConfigManager) StopWatch() {
+	cm.subMu.Lock()
+	defer cm.subMu.Unlock()
+	if cm.watcher != nil {
+		cm.watcher.Close()
+		cm.watcher = nil
+	}
+}
+
+// ReloadAndNotify 重新读取配置文件，校验并通知发生变化的配置段。
+// 解析失败、校验失败或任一监听器应用失败时，配置保持（或回滚到）修改前的内容
+func (cm *ConfigManager) ReloadAndNotify() error {
+	cm.reloadMu.Lock()
+	defer cm.reloadMu.Unlock()
+
+	nv := viper.New()
+	nv.SetConfigFile(cm.path)
+	nv.SetConfigType(cm.format)
+	if err := nv.ReadInConfig(); err != nil {
+		return fmt.Errorf("解析配置文件失败: %w", err)
+	}
+
+	cm.mu.RLock()
+	ov := cm.viper
+	cm.mu.RUnlock()
+
+	cm.subMu.Lock()
+	subs := append([]subscription(nil), cm.subs...)
+	exec := cm.exec
+	cm.subMu.Unlock()
+
+	// 比较叠加环境变量后的内容，_FILE 指向的密钥文件也会被重新读取
+	var errs []error
+	type diff struct {
+		old, new any
+		changed  bool
+	}
+	diffs := make(map[string]diff)
+	get := func(key string) (oldRaw, newRaw any, ok bool) {
+		if d, seen := diffs[key]; seen {
+			return d.old, d.new, d.changed
+		}
+		oldRaw, _ = cm.overlay(ov, key)
+		newRaw, err := cm.overlay(nv, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+		d := diff{old: oldRaw, new: newRaw, changed: err == nil && !reflect.DeepEqual(oldRaw, newRaw)}
+		diffs[key] = d
+		return d.old, d.new, d.changed
+	}
+
+	// 找出发生变化的配置段
+	type change struct {
+		sub      subscription
+		old, new any
+	}
+	var changes []change
+	for _, sub := range subs {
+		if oldRaw, newRaw, ok := get(sub.key); ok {
+			changes = append(changes, change{sub: sub, old: oldRaw, new: newRaw})
+		}
+	}
+
+	// 校验阶段：不产生任何副作用。已注册结构的配置段即使没有监听者也要校验
+	for _, schema := range cm.Schemas() {
+		_, newRaw, ok := get(schema.Key)
+		if !ok {
+			continue
+		}
+		if err := cm.validateRaw(schema.Key, newRaw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", schema.Key, err))
+		}
+	}
+	for _, c := range changes {
+		if c.sub.listener.Validate == nil {
+			continue
+		}
+		if err := c.sub.listener.Validate(c.new); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.sub.key, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// 应用阶段：先切换配置，使回调中读取到的是新配置
+	var applyErr error
+	run := func() {
+		cm.mu.Lock()
+		cm.viper = nv
+		cm.mu.Unlock()
+
+		for i, c := range changes {
+			if c.sub.listener.Apply == nil {
+				continue
+			}
+			if err := c.sub.listener.Apply(c.old, c.new); err != nil {
+				applyErr = fmt.Errorf("%s: %w", c.sub.key, err)
+
+				// 回滚：恢复旧配置并逆序撤销已应用的监听器
+				cm.mu.Lock()
+				cm.viper = ov
+				cm.mu.Unlock()
+				for j := i - 1; j >= 0; j-- {
+					prev := changes[j]
+					if prev.sub.listener.Apply == nil {
+						continue
+					}
+					if err := prev.sub.listener.Apply(prev.new, prev.old); err != nil {
+						watchLogger.Error().Err(err).Str("key", prev.sub.key).Msg("回滚配置失败")
+					}
+				}
+				return
+			}
+		}
+	}
+
+	if exec != nil {
+		exec(run)
+	} else {
+		run()
+	}
+
+	if applyErr != nil {
+		return applyErr
+	}
+
+	keys := make([]string, 0, len(changes))
+	for _, c := range changes {
+		keys = append(keys, c.sub.key)
+	}
+	watchLogger.Info().Str("path", cm.path).Strs("changed", keys).Msg("配置已重新加载")
+	return nil
+}
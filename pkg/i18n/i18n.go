@@ -0,0 +1,410 @@
+// Package i18n 提供插件级别的多语言消息目录。
+//
+// 核心功能：
+//   - 每个插件拥有独立的命名空间，目录文件为 <locale>.toml 或 <locale>.json
+//   - 支持复数规则（zero / one / two / few / many / other）与命名参数 {name}
+//   - 语言解析顺序：群组设置 → 用户语言代码 → 默认语言
+//   - 查找顺序：插件命名空间 → 公共命名空间 → 默认语言 → 键名本身
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"yueling_tg/internal/core/log"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	// DefaultLocale 未配置时使用的默认语言
+	DefaultLocale = "zh-CN"
+	// Common 公共命名空间，所有插件都可以使用其中的键
+	Common = "common"
+)
+
+// Args 命名参数
+type Args map[string]any
+
+var logger = log.NewSystem("i18n")
+
+// Bundle 消息目录集合：命名空间 → 语言 → 键 → 消息
+type Bundle struct {
+	catalogs      map[string]map[string]map[string]Message
+	defaultLocale string
+	mu            sync.RWMutex
+}
+
+// NewBundle 创建消息目录集合
+func NewBundle(defaultLocale string) *Bundle {
+	if defaultLocale == "" {
+		defaultLocale = DefaultLocale
+	}
+	return &Bundle{
+		catalogs:      make(map[string]map[string]map[string]Message),
+		defaultLocale: defaultLocale,
+	}
+}
+
+// DefaultLocale 返回默认语言
+func (b *Bundle) DefaultLocale() string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.defaultLocale
+}
+
+// SetDefaultLocale 设置默认语言
+func (b *Bundle) SetDefaultLocale(locale string) {
+	if locale == "" {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.defaultLocale = locale
+}
+
+// Add 向指定命名空间与语言添加消息，已存在的键会被覆盖
+func (b *Bundle) Add(namespace, locale string, messages map[string]Message) {
+	b.add(namespace, locale, messages, true)
+}
+
+func (b *Bundle) add(namespace, locale string, messages map[string]Message, override bool) {
+	if namespace == "" {
+		namespace = Common
+	}
+	locale = canonical(locale)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	locales, ok := b.catalogs[namespace]
+	if !ok {
+		locales = make(map[string]map[string]Message)
+		b.catalogs[namespace] = locales
+	}
+	catalog, ok := locales[locale]
+	if !ok {
+		catalog = make(map[string]Message)
+		locales[locale] = catalog
+	}
+	for k, m := range messages {
+		if _, exists := catalog[k]; exists && !override {
+			continue
+		}
+		catalog[k] = m
+	}
+}
+
+// Parse 解析目录文件内容，format 为 "toml" 或 "json"
+func Parse(data []byte, format string) (map[string]Message, error) {
+	raw := make(map[string]any)
+
+	switch format {
+	case "toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("解析 TOML 失败: %w", err)
+		}
+	case "json":
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("解析 JSON 失败: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的目录格式: %s", format)
+	}
+
+	messages := make(map[string]Message)
+	if err := flatten("", raw, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// Register 从文件系统中加载命名空间的目录，dir 下的每个 <locale>.toml / <locale>.json 对应一种语言。
+// 内置目录只补充缺失的键，不会覆盖 LoadDir 加载的外部翻译
+//
+// 插件通常配合 embed.FS 在 New 中调用：
+//
+//	//go:embed locales
+//	var locales embed.FS
+//
+//	i18n.Register("help", locales, "locales")
+func (b *Bundle) Register(namespace string, fsys fs.FS, dir string) error {
+	return b.load(namespace, fsys, dir, false)
+}
+
+func (b *Bundle) load(namespace string, fsys fs.FS, dir string, override bool) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("读取目录 %s 失败: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		format := strings.TrimPrefix(path.Ext(name), ".")
+		if format != "toml" && format != "json" {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("读取 %s 失败: %w", name, err)
+		}
+
+		messages, err := Parse(data, format)
+		if err != nil {
+			return fmt.Errorf("%s/%s: %w", namespace, name, err)
+		}
+
+		b.add(namespace, strings.TrimSuffix(name, path.Ext(name)), messages, override)
+	}
+
+	return nil
+}
+
+// LoadDir 加载外部目录，结构为 <dir>/<命名空间>/<locale>.toml，用于覆盖或补充插件自带的翻译
+func (b *Bundle) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取语言目录失败: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := b.load(entry.Name(), os.DirFS(filepath.Join(dir, entry.Name())), ".", true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Locales 返回已加载的全部语言
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	set := make(map[string]struct{})
+	for _, locales := range b.catalogs {
+		for l := range locales {
+			set[l] = struct{}{}
+		}
+	}
+
+	list := make([]string, 0, len(set))
+	for l := range set {
+		list = append(list, l)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Match 将任意语言代码匹配到已加载的语言，如 "en-US" → "en"、"zh-hans" → "zh-CN"，匹配失败返回空字符串
+func (b *Bundle) Match(code string) string {
+	if code == "" {
+		return ""
+	}
+	code = canonical(code)
+	locales := b.Locales()
+
+	for _, l := range locales {
+		if l == code {
+			return l
+		}
+	}
+
+	lang := base(code)
+	if alias, ok := aliases[strings.ToLower(code)]; ok {
+		for _, l := range locales {
+			if l == alias {
+				return l
+			}
+		}
+	}
+	for _, l := range locales {
+		if base(l) == lang {
+			return l
+		}
+	}
+	return ""
+}
+
+// Lookup 查找消息，依次尝试 命名空间/语言 → 公共/语言 → 命名空间/默认语言 → 公共/默认语言
+func (b *Bundle) Lookup(locale, namespace, key string) (Message, string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	locale = canonical(locale)
+	candidates := []string{locale}
+	if lang := base(locale); lang != locale {
+		candidates = append(candidates, lang)
+	}
+	if locale != b.defaultLocale {
+		candidates = append(candidates, b.defaultLocale)
+	}
+
+	for _, l := range candidates {
+		for _, ns := range []string{namespace, Common} {
+			if ns == "" {
+				continue
+			}
+			if m, ok := b.catalogs[ns][l][key]; ok {
+				return m, l, true
+			}
+		}
+	}
+	return Message{}, "", false
+}
+
+// Has 判断消息是否存在
+func (b *Bundle) Has(locale, namespace, key string) bool {
+	_, _, ok := b.Lookup(locale, namespace, key)
+	return ok
+}
+
+// Translate 翻译消息，找不到时返回键名本身
+func (b *Bundle) Translate(locale, namespace, key string, args ...Args) string {
+	m, found, ok := b.Lookup(locale, namespace, key)
+	if !ok {
+		logger.Debug().Str("locale", locale).Str("namespace", namespace).Str("key", key).Msg("缺少翻译")
+		return key
+	}
+	return m.Format(found, merge(args))
+}
+
+// -------------------- 全局目录 --------------------
+
+var defaultBundle = NewBundle(DefaultLocale)
+
+// Default 返回全局消息目录
+func Default() *Bundle {
+	return defaultBundle
+}
+
+// Register 向全局目录注册命名空间
+func Register(namespace string, fsys fs.FS, dir string) error {
+	return defaultBundle.Register(namespace, fsys, dir)
+}
+
+// MustRegister 向全局目录注册命名空间，失败时 panic（用于加载内嵌目录）
+func MustRegister(namespace string, fsys fs.FS, dir string) {
+	if err := defaultBundle.Register(namespace, fsys, dir); err != nil {
+		panic(err)
+	}
+}
+
+// Translate 使用全局目录翻译
+func Translate(locale, namespace, key string, args ...Args) string {
+	return defaultBundle.Translate(locale, namespace, key, args...)
+}
+
+// Has 判断全局目录中消息是否存在
+func Has(locale, namespace, key string) bool {
+	return defaultBundle.Has(locale, namespace, key)
+}
+
+// -------------------- 辅助函数 --------------------
+
+// flatten 将嵌套表展开为点号分隔的键，仅包含复数分类的表视为复数消息
+func flatten(prefix string, raw map[string]any, out map[string]Message) error {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		switch val := v.(type) {
+		case string:
+			out[key] = Message{Other: val}
+		case map[string]any:
+			if m, ok := pluralMessage(val); ok {
+				out[key] = m
+				continue
+			}
+			if err := flatten(key, val, out); err != nil {
+				return err
+			}
+		case []any:
+			// 数组按行拼接，便于书写多行文本
+			lines := make([]string, 0, len(val))
+			for _, item := range val {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("键 %s 的数组元素必须是字符串", key)
+				}
+				lines = append(lines, s)
+			}
+			out[key] = Message{Other: strings.Join(lines, "\n")}
+		default:
+			return fmt.Errorf("键 %s 的值类型不支持: %T", key, v)
+		}
+	}
+	return nil
+}
+
+func merge(args []Args) Args {
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0]
+	}
+	merged := make(Args)
+	for _, a := range args {
+		for k, v := range a {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// 常见但不规范的语言代码别名
+var aliases = map[string]string{
+	"zh":         "zh-CN",
+	"zh-hans":    "zh-CN",
+	"zh-sg":      "zh-CN",
+	"zh-hant":    "zh-TW",
+	"zh-hk":      "zh-TW",
+	"zh-mo":      "zh-TW",
+	"pt":         "pt-BR",
+	"en-us":      "en",
+	"en-gb":      "en",
+	"zh-hans-cn": "zh-CN",
+}
+
+// canonical 规范化语言代码：en_us → en-US
+func canonical(code string) string {
+	code = strings.ReplaceAll(strings.TrimSpace(code), "_", "-")
+	parts := strings.Split(code, "-")
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) == 2 {
+			parts[i] = strings.ToUpper(parts[i])
+		} else {
+			parts[i] = strings.ToLower(parts[i])
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// base 返回主语言部分：zh-CN → zh
+func base(code string) string {
+	if i := strings.IndexByte(code, '-'); i >= 0 {
+		return code[:i]
+	}
+	return code
+}
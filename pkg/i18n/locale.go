@@ -0,0 +1,164 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"yueling_tg/pkg/config"
+)
+
+// Config 多语言配置（config.toml 中的 [i18n] 段）
+type Config struct {
+	DefaultLocale string `mapstructure:"default_locale" doc:"默认语言" validate:"required"`
+	Dir           string `mapstructure:"dir" doc:"外部翻译目录，结构为 <dir>/<命名空间>/<locale>.toml"`
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		DefaultLocale: DefaultLocale,
+		Dir:           "./locales",
+	}
+}
+
+// Setup 按配置初始化全局目录。群组语言设置属于各个 Bot，见 For
+func Setup(cfg Config) error {
+	if cfg.DefaultLocale == "" {
+		cfg.DefaultLocale = DefaultLocale
+	}
+
+	defaultBundle.SetDefaultLocale(canonical(cfg.DefaultLocale))
+
+	if cfg.Dir != "" {
+		return defaultBundle.LoadDir(cfg.Dir)
+	}
+	return nil
+}
+
+// -------------------- 群组语言设置 --------------------
+
+// ChatLocales 群组语言设置，同一进程运行多个 Bot 时每个 Bot 一份
+type ChatLocales struct {
+	path    string
+	locales map[int64]string
+	mu      sync.RWMutex
+}
+
+// NewChatLocales 创建群组语言设置，path 为空时只保存在内存中
+func NewChatLocales(path string) *ChatLocales {
+	return &ChatLocales{
+		path:    path,
+		locales: make(map[int64]string),
+	}
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[*config.ConfigManager]*ChatLocales)
+)
+
+// For 返回 cm 对应 Bot 的群组语言设置（<数据目录>/i18n/chat_locales.json），首次调用时创建并加载
+func For(cm *config.ConfigManager) *ChatLocales {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if s, ok := stores[cm]; ok {
+		return s
+	}
+
+	s := NewChatLocales(filepath.Join(cm.DataDir(), "i18n", "chat_locales.json"))
+	if err := s.Load(); err != nil {
+		logger.Warn().Err(err).Msg("加载群组语言设置失败，使用空设置")
+	}
+	stores[cm] = s
+	return s
+}
+
+// Load 从文件加载，文件不存在时为空
+func (s *ChatLocales) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.locales = make(map[int64]string)
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取群组语言设置失败: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.locales); err != nil {
+		return fmt.Errorf("解析群组语言设置失败: %w", err)
+	}
+	return nil
+}
+
+// save 写入文件（调用方需持有锁）
+func (s *ChatLocales) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	if dir := filepath.Dir(s.path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(s.locales, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化数据失败: %w", err)
+	}
+
+	// 使用临时文件 + 原子重命名
+	tmpFile := s.path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Rename(tmpFile, s.path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("重命名文件失败: %w", err)
+	}
+	return nil
+}
+
+// Get 获取群组设置的语言
+func (s *ChatLocales) Get(chatID int64) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	l, ok := s.locales[chatID]
+	return l, ok
+}
+
+// Set 设置群组语言，locale 为空时恢复自动检测
+func (s *ChatLocales) Set(chatID int64, locale string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if locale == "" {
+		delete(s.locales, chatID)
+	} else {
+		s.locales[chatID] = canonical(locale)
+	}
+	return s.save()
+}
+
+// Resolve 解析最终使用的语言：群组设置 → 用户语言代码 → 默认语言，s 为空时跳过群组设置
+func (s *ChatLocales) Resolve(chatID int64, languageCode string) string {
+	if s != nil {
+		if l, ok := s.Get(chatID); ok {
+			return l
+		}
+	}
+	if l := defaultBundle.Match(languageCode); l != "" {
+		return l
+	}
+	return defaultBundle.DefaultLocale()
+}
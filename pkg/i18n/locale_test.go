@@ -0,0 +1,72 @@
+package i18n
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"yueling_tg/pkg/config"
+)
+
+func TestChatLocalesPerBot(t *testing.T) {
+	a, b := config.NewEmptyManager(), config.NewEmptyManager()
+	a.SetDataDir(t.TempDir())
+	b.SetDataDir(t.TempDir())
+
+	if For(a) != For(a) || For(a) == For(b) {
+		t.Fatal("each bot should have its own chat locales")
+	}
+	if err := For(a).Set(-100, "en"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := For(b).Get(-100); ok {
+		t.Error("locale set on one bot leaked to another")
+	}
+
+	// 保存在本 Bot 的数据目录中
+	loaded := NewChatLocales(filepath.Join(a.DataDir(), "i18n", "chat_locales.json"))
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if l, ok := loaded.Get(-100); !ok || l != "en" {
+		t.Errorf("loaded locale = %q, %v, want en", l, ok)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	MustRegister("locale_test", fstest.MapFS{
+		"locales/en.toml":    {Data: []byte(`hello = "Hello"`)},
+		"locales/zh-CN.toml": {Data: []byte(`hello = "你好"`)},
+	}, "locales")
+
+	s := NewChatLocales("")
+	if err := s.Set(-100, "zh-TW"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		s      *ChatLocales
+		chatID int64
+		lang   string
+		want   string
+	}{
+		{"群组设置优先", s, -100, "en", "zh-TW"},
+		{"用户语言代码", s, -200, "en-US", "en"},
+		{"默认语言", s, -200, "xx", DefaultLocale},
+		{"未注入群组设置", nil, -100, "en", "en"},
+	}
+	for _, tt := range tests {
+		if got := tt.s.Resolve(tt.chatID, tt.lang); got != tt.want {
+			t.Errorf("%s: Resolve = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// 恢复自动检测
+	if err := s.Set(-100, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get(-100); ok {
+		t.Error("empty locale should clear the chat setting")
+	}
+}
@@ -0,0 +1,238 @@
+package i18n
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 复数分类（CLDR）
+const (
+	Zero  = "zero"
+	One   = "one"
+	Two   = "two"
+	Few   = "few"
+	Many  = "many"
+	Other = "other"
+)
+
+// CountArg 用于选择复数形式的参数名
+const CountArg = "count"
+
+// Message 单条消息，Other 为必填形式
+type Message struct {
+	Zero  string
+	One   string
+	Two   string
+	Few   string
+	Many  string
+	Other string
+}
+
+// pluralMessage 判断表是否为复数消息（仅包含复数分类且必须有 other）
+func pluralMessage(raw map[string]any) (Message, bool) {
+	if _, ok := raw[Other]; !ok {
+		return Message{}, false
+	}
+
+	var m Message
+	for k, v := range raw {
+		s, ok := v.(string)
+		if !ok {
+			return Message{}, false
+		}
+		switch k {
+		case Zero:
+			m.Zero = s
+		case One:
+			m.One = s
+		case Two:
+			m.Two = s
+		case Few:
+			m.Few = s
+		case Many:
+			m.Many = s
+		case Other:
+			m.Other = s
+		default:
+			return Message{}, false
+		}
+	}
+	return m, true
+}
+
+// Format 按语言选择复数形式并替换命名参数
+func (m Message) Format(locale string, args Args) string {
+	text := m.Other
+	if n, ok := count(args); ok {
+		text = m.form(PluralCategory(locale, n))
+	}
+	return replace(text, args)
+}
+
+// form 返回分类对应的文本，缺失时回退到 other
+func (m Message) form(category string) string {
+	var s string
+	switch category {
+	case Zero:
+		s = m.Zero
+	case One:
+		s = m.One
+	case Two:
+		s = m.Two
+	case Few:
+		s = m.Few
+	case Many:
+		s = m.Many
+	}
+	if s == "" {
+		return m.Other
+	}
+	return s
+}
+
+// replace 替换 {name} 形式的参数，{{ 与 }} 转义为花括号，未提供的参数保持原样
+func replace(text string, args Args) string {
+	if !strings.ContainsAny(text, "{}") {
+		return text
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(text))
+
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case ch == '{' && i+1 < len(text) && text[i+1] == '{':
+			sb.WriteByte('{')
+			i++
+		case ch == '}' && i+1 < len(text) && text[i+1] == '}':
+			sb.WriteByte('}')
+			i++
+		case ch == '{':
+			end := strings.IndexByte(text[i+1:], '}')
+			if end < 0 {
+				sb.WriteString(text[i:])
+				return sb.String()
+			}
+			name := text[i+1 : i+1+end]
+			if v, ok := args[name]; ok {
+				sb.WriteString(fmt.Sprint(v))
+			} else {
+				sb.WriteString(text[i : i+2+end])
+			}
+			i += end + 1
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return sb.String()
+}
+
+// count 从参数中取出用于复数选择的数值
+func count(args Args) (float64, bool) {
+	v, ok := args[CountArg]
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// PluralCategory 返回数值在指定语言下的复数分类（整数规则，覆盖常用语言）
+func PluralCategory(locale string, n float64) string {
+	n = math.Abs(n)
+	integer := n == math.Trunc(n)
+	i := int64(n)
+
+	switch base(canonical(locale)) {
+	case "zh", "ja", "ko", "vi", "th", "id", "ms":
+		return Other
+
+	case "fr", "pt":
+		if i == 0 || i == 1 {
+			return One
+		}
+		return Other
+
+	case "ru", "uk", "be":
+		if !integer {
+			return Other
+		}
+		mod10, mod100 := i%10, i%100
+		switch {
+		case mod10 == 1 && mod100 != 11:
+			return One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return Few
+		default:
+			return Many
+		}
+
+	case "pl":
+		if !integer {
+			return Other
+		}
+		mod10, mod100 := i%10, i%100
+		switch {
+		case i == 1:
+			return One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return Few
+		default:
+			return Many
+		}
+
+	case "ar":
+		if !integer {
+			return Other
+		}
+		mod100 := i % 100
+		switch {
+		case i == 0:
+			return Zero
+		case i == 1:
+			return One
+		case i == 2:
+			return Two
+		case mod100 >= 3 && mod100 <= 10:
+			return Few
+		case mod100 >= 11:
+			return Many
+		default:
+			return Other
+		}
+
+	default:
+		// en、de、es、it 等
+		if integer && i == 1 {
+			return One
+		}
+		return Other
+	}
+}
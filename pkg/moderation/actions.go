@@ -0,0 +1,99 @@
+package moderation
+
+import (
+	"fmt"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+
+	"github.com/mymmrac/telego"
+)
+
+// -------------------- Bot API 操作 --------------------
+//
+// 以下操作作用于 ctx 所在的群组，时长为 common.Forever 表示永久。
+// Telegram 将少于 30 秒或超过 366 天的限制视为永久。
+
+// 限制时长的有效范围，超出时会被 Telegram 视为永久
+const (
+	MinDuration = 30 * time.Second
+	MaxDuration = 366 * 24 * time.Hour
+)
+
+// CheckDuration 检查禁言、封禁时长是否在有效范围内，common.Forever 总是有效
+func CheckDuration(d time.Duration) error {
+	if d == common.Forever || (d >= MinDuration && d <= MaxDuration) {
+		return nil
+	}
+	return fmt.Errorf("时长需在 %s 到 %s 之间", common.FormatDuration(MinDuration), common.FormatDuration(MaxDuration))
+}
+
+// KickDuration 踢出时的临时封禁时长，到期后用户可以重新加入
+const KickDuration = time.Minute
+
+// UntilDate 时长对应的 UntilDate，永久为 0
+func UntilDate(d time.Duration) int64 {
+	if d == common.Forever {
+		return 0
+	}
+	return time.Now().Add(d).Unix()
+}
+
+// Mute 禁言（移除发送消息权限）
+func Mute(ctx *context.Context, userID int64, d time.Duration) error {
+	return ctx.Api.RestrictChatMember(ctx.Ctx, &telego.RestrictChatMemberParams{
+		ChatID:      ctx.GetChatID(),
+		UserID:      userID,
+		Permissions: SendPermissions(false),
+		UntilDate:   UntilDate(d),
+	})
+}
+
+// Unmute 恢复发送消息权限
+func Unmute(ctx *context.Context, userID int64) error {
+	return ctx.Api.RestrictChatMember(ctx.Ctx, &telego.RestrictChatMemberParams{
+		ChatID:      ctx.GetChatID(),
+		UserID:      userID,
+		Permissions: SendPermissions(true),
+	})
+}
+
+// Ban 封禁
+func Ban(ctx *context.Context, userID int64, d time.Duration) error {
+	return ctx.Api.BanChatMember(ctx.Ctx, &telego.BanChatMemberParams{
+		ChatID:    ctx.GetChatID(),
+		UserID:    userID,
+		UntilDate: UntilDate(d),
+	})
+}
+
+// Unban 解除封禁，不在封禁列表中的成员不会被移出群组
+func Unban(ctx *context.Context, userID int64) error {
+	return ctx.Api.UnbanChatMember(ctx.Ctx, &telego.UnbanChatMemberParams{
+		ChatID:       ctx.GetChatID(),
+		UserID:       userID,
+		OnlyIfBanned: true,
+	})
+}
+
+// Kick 踢出：封禁 KickDuration，到期后可以重新加入
+func Kick(ctx *context.Context, userID int64) error {
+	return Ban(ctx, userID, KickDuration)
+}
+
+// SendPermissions 发送消息相关的权限全部设为 allow
+func SendPermissions(allow bool) telego.ChatPermissions {
+	return telego.ChatPermissions{
+		CanSendMessages:       &allow,
+		CanSendAudios:         &allow,
+		CanSendDocuments:      &allow,
+		CanSendPhotos:         &allow,
+		CanSendVideos:         &allow,
+		CanSendVideoNotes:     &allow,
+		CanSendVoiceNotes:     &allow,
+		CanSendPolls:          &allow,
+		CanSendOtherMessages:  &allow,
+		CanAddWebPagePreviews: &allow,
+	}
+}
@@ -0,0 +1,123 @@
+// Package moderation 记录群管操作（禁言、封禁、踢出、删除消息、设置管理员、警告等）。
+//
+// 每次操作记为一条处罚记录（Case），包含操作人、目标、群组、理由、时长与时间，
+// 保存在本 Bot 的数据目录中，并可同步到配置的日志频道。
+// 插件通过 Default 获取当前 Bot 的记录存储，使用 Record 写入。
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"yueling_tg/pkg/common"
+)
+
+// Action 操作类型
+type Action string
+
+const (
+	ActionMute    Action = "mute"
+	ActionUnmute  Action = "unmute"
+	ActionBan     Action = "ban"
+	ActionUnban   Action = "unban"
+	ActionKick    Action = "kick"
+	ActionDelete  Action = "delete"
+	ActionPromote Action = "promote"
+	ActionDemote  Action = "demote"
+	ActionWarn    Action = "warn"
+	ActionUnwarn  Action = "unwarn"
+)
+
+// actionNames 操作的中文名称
+var actionNames = map[Action]string{
+	ActionMute:    "禁言",
+	ActionUnmute:  "解除禁言",
+	ActionBan:     "封禁",
+	ActionUnban:   "解封",
+	ActionKick:    "踢出",
+	ActionDelete:  "删除消息",
+	ActionPromote: "设置管理员",
+	ActionDemote:  "取消管理员",
+	ActionWarn:    "警告",
+	ActionUnwarn:  "撤销警告",
+}
+
+// String 返回操作的中文名称
+func (a Action) String() string {
+	if name, ok := actionNames[a]; ok {
+		return name
+	}
+	return string(a)
+}
+
+// Case 一条处罚记录
+type Case struct {
+	ID         int           `json:"id"`
+	ChatID     int64         `json:"chat_id"`
+	Action     Action        `json:"action"`
+	ActorID    int64         `json:"actor_id"` // 为 0 表示由 Bot 自动执行
+	ActorName  string        `json:"actor_name"`
+	TargetID   int64         `json:"target_id"`
+	TargetName string        `json:"target_name"`
+	Reason     string        `json:"reason,omitempty"`
+	Duration   time.Duration `json:"duration,omitempty"` // 0 表示不适用，common.Forever 表示永久
+	Source     string        `json:"source,omitempty"`   // 记录来源的插件 ID
+	Time       time.Time     `json:"time"`
+
+	Reverts    int `json:"reverts,omitempty"`     // 本记录撤销的记录 ID
+	RevertedBy int `json:"reverted_by,omitempty"` // 撤销本记录的记录 ID
+}
+
+// Reverted 是否已被撤销
+func (c Case) Reverted() bool {
+	return c.RevertedBy != 0
+}
+
+// Summary 单行摘要，用于记录列表
+func (c Case) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%d %s %s", c.ID, c.Time.Local().Format("01-02 15:04"), c.Action)
+	if c.Duration != 0 {
+		fmt.Fprintf(&sb, "（%s）", common.FormatDuration(c.Duration))
+	}
+	fmt.Fprintf(&sb, " · %s", c.ActorName)
+	if c.Reason != "" {
+		fmt.Fprintf(&sb, " · %s", c.Reason)
+	}
+	if c.Reverts != 0 {
+		fmt.Fprintf(&sb, " · 撤销 #%d", c.Reverts)
+	}
+	if c.Reverted() {
+		fmt.Fprintf(&sb, " · 已被 #%d 撤销", c.RevertedBy)
+	}
+	return sb.String()
+}
+
+// Details 多行详情，用于同步到日志频道
+func (c Case) Details(chatTitle string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📋 处罚记录 #%d\n", c.ID)
+	fmt.Fprintf(&sb, "操作：%s\n", c.Action)
+	if chatTitle != "" {
+		fmt.Fprintf(&sb, "群组：%s (%d)\n", chatTitle, c.ChatID)
+	} else {
+		fmt.Fprintf(&sb, "群组：%d\n", c.ChatID)
+	}
+	fmt.Fprintf(&sb, "目标：%s (%d)\n", c.TargetName, c.TargetID)
+	fmt.Fprintf(&sb, "操作人：%s", c.ActorName)
+	if c.ActorID != 0 {
+		fmt.Fprintf(&sb, " (%d)", c.ActorID)
+	}
+	if c.Duration != 0 {
+		fmt.Fprintf(&sb, "\n时长：%s", common.FormatDuration(c.Duration))
+	}
+	if c.Reason != "" {
+		fmt.Fprintf(&sb, "\n理由：%s", c.Reason)
+	}
+	if c.Reverts != 0 {
+		fmt.Fprintf(&sb, "\n撤销：#%d", c.Reverts)
+	}
+	fmt.Fprintf(&sb, "\n时间：%s", c.Time.Local().Format("2006-01-02 15:04:05"))
+	return sb.String()
+}
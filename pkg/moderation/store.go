@@ -0,0 +1,323 @@
+package moderation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/internal/core/log"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/plugin"
+
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+var logger = log.NewSystem("处罚记录")
+
+// saveDelay 记录变化后延迟写入，合并短时间内的多次变化（如刷屏时的连续自动处罚）
+const saveDelay = 2 * time.Second
+
+// Config 处罚记录配置（config.toml 中的 [moderation] 段）
+type Config struct {
+	Path       string `mapstructure:"path" doc:"处罚记录的存储文件，为空时为 <数据目录>/moderation/cases.json，修改后需重启"`
+	LogChannel int64  `mapstructure:"log_channel" doc:"同步处罚记录的频道或群组 ID，0 表示不同步（Bot 需有发言权限）"`
+	MaxCases   int    `mapstructure:"max_cases" doc:"最多保留的记录数，超出时删除最早的记录，0 表示不限制" validate:"min=0"`
+
+	Warnings     WarnPolicy            `mapstructure:"warnings" doc:"警告策略"`
+	ChatWarnings map[string]WarnPolicy `mapstructure:"chat_warnings" doc:"按会话 ID 覆盖警告策略，如 [moderation.chat_warnings.\"-1001234567890\"]"`
+}
+
+// DefaultConfig 返回默认配置
+func DefaultConfig() Config {
+	return Config{
+		MaxCases: 10000,
+		Warnings: DefaultWarnPolicy(),
+	}
+}
+
+func init() {
+	config.RegisterSchema("moderation", DefaultConfig())
+}
+
+// -------------------- 存储 --------------------
+
+// Store 处罚记录存储，并发安全
+type Store struct {
+	path string
+
+	mu     sync.RWMutex
+	cfg    Config
+	cases  []Case
+	next   int
+	saving *time.Timer // 等待中的延迟保存
+
+	fileMu sync.Mutex // 串行化文件写入
+}
+
+type storeFile struct {
+	NextID int    `json:"next_id"`
+	Cases  []Case `json:"cases"`
+}
+
+// NewStore 创建保存在 path 的存储，需调用 Load 读取已有记录
+func NewStore(path string, cfg Config) *Store {
+	return &Store{path: path, cfg: cfg, next: 1}
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = make(map[*config.ConfigManager]*Store)
+)
+
+// For 返回 cm 对应 Bot 的存储，首次调用时按 [moderation] 段创建并订阅配置变更。
+// 同一 Bot 的插件共享同一个存储
+func For(cm *config.ConfigManager) *Store {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if s, ok := stores[cm]; ok {
+		return s
+	}
+
+	cfg := DefaultConfig()
+	_ = cm.GetSection("moderation", &cfg) // 错误已记录到 Problems 中
+	path := cfg.Path
+	if path == "" {
+		path = filepath.Join(cm.DataDir(), "moderation", "cases.json")
+	}
+
+	s := NewStore(path, cfg)
+	if err := s.Load(); err != nil {
+		logger.Warn().Err(err).Msg("加载处罚记录失败，使用空记录")
+	}
+
+	cm.WatchSection("moderation", config.Listener{
+		Apply: func(_, raw any) error {
+			cfg := DefaultConfig()
+			if err := config.Decode(raw, &cfg); err != nil {
+				return err
+			}
+			s.SetConfig(cfg)
+			return nil
+		},
+	})
+
+	stores[cm] = s
+	return s
+}
+
+// Default 返回当前 Bot（当前配置管理器）的存储，应在插件构造函数中调用
+func Default() *Store {
+	return For(config.GetManager())
+}
+
+// Path 返回存储文件路径
+func (s *Store) Path() string {
+	return s.path
+}
+
+// SetConfig 更新配置（存储路径不变）
+func (s *Store) SetConfig(cfg Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// Load 从文件读取记录，文件不存在时为空
+func (s *Store) Load() error {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var f storeFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("解析处罚记录失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cases = f.Cases
+	s.next = max(f.NextID, 1)
+	for _, c := range s.cases {
+		s.next = max(s.next, c.ID+1)
+	}
+	return nil
+}
+
+// scheduleSave 延迟保存（调用方需持有锁）
+func (s *Store) scheduleSave() {
+	if s.saving != nil {
+		return
+	}
+	s.saving = time.AfterFunc(saveDelay, func() {
+		if err := s.Save(); err != nil {
+			logger.Error().Err(err).Msg("保存处罚记录失败")
+		}
+	})
+}
+
+// Save 立即写入文件
+func (s *Store) Save() error {
+	s.mu.Lock()
+	if s.saving != nil {
+		s.saving.Stop()
+		s.saving = nil
+	}
+	data, err := json.MarshalIndent(storeFile{NextID: s.next, Cases: s.cases}, "", "  ")
+	s.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("序列化数据失败: %w", err)
+	}
+
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	// 使用临时文件 + 原子重命名
+	tmpFile := s.path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Rename(tmpFile, s.path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("重命名文件失败: %w", err)
+	}
+	return nil
+}
+
+// Add 分配编号并保存记录，Time 为空时取当前时间；写入文件在稍后批量进行
+func (s *Store) Add(c Case) (Case, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.ID = s.next
+	s.next++
+	if c.Time.IsZero() {
+		c.Time = time.Now()
+	}
+
+	s.cases = append(s.cases, c)
+	if s.cfg.MaxCases > 0 && len(s.cases) > s.cfg.MaxCases {
+		s.cases = slices.Delete(s.cases, 0, len(s.cases)-s.cfg.MaxCases)
+	}
+	s.scheduleSave()
+	return c, nil
+}
+
+// Get 按编号获取记录
+func (s *Store) Get(id int) (Case, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.index(id); i >= 0 {
+		return s.cases[i], true
+	}
+	return Case{}, false
+}
+
+// History 返回 chatID 中针对 userID 的记录，最新的在前；chatID 为 0 时不限群组
+func (s *Store) History(chatID, userID int64) []Case {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []Case
+	for i := len(s.cases) - 1; i >= 0; i-- {
+		c := s.cases[i]
+		if c.TargetID == userID && (chatID == 0 || c.ChatID == chatID) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// MarkReverted 将记录 id 标记为被记录 by 撤销
+func (s *Store) MarkReverted(id, by int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return fmt.Errorf("处罚记录 #%d 不存在", id)
+	}
+	s.cases[i].RevertedBy = by
+	s.scheduleSave()
+	return nil
+}
+
+// index 返回记录 id 的下标，记录按编号递增排列
+func (s *Store) index(id int) int {
+	i, found := slices.BinarySearchFunc(s.cases, id, func(c Case, id int) int {
+		return c.ID - id
+	})
+	if !found {
+		return -1
+	}
+	return i
+}
+
+// -------------------- 记录 --------------------
+
+// Record 记录一次操作：未填写的群组、操作人与来源取自 ctx，保存后同步到日志频道。
+// 由 Bot 自动执行的操作应将 ActorName 设为说明（如 "自动"），ActorID 保持为 0
+func (s *Store) Record(ctx *context.Context, c Case) (Case, error) {
+	if c.ChatID == 0 {
+		c.ChatID = ctx.GetChatID().ID
+	}
+	if c.ActorID == 0 && c.ActorName == "" {
+		c.ActorID = ctx.GetUserID()
+		c.ActorName = ctx.GetFullName()
+	}
+	if c.Source == "" {
+		if p := plugin.PluginOf(ctx); p != nil {
+			c.Source = p.PluginInfo().ID
+		}
+	}
+
+	c, err := s.Add(c)
+	if err != nil {
+		return c, err
+	}
+
+	s.mirror(ctx, c)
+	return c, nil
+}
+
+// RecordAuto 记录由 Bot 自动执行的操作：操作人为“自动”，未填写的目标取自 ctx 中的用户。
+// 操作本身已经生效，保存失败时只记录日志
+func (s *Store) RecordAuto(ctx *context.Context, c Case) Case {
+	c.ActorID, c.ActorName = 0, "自动"
+	if c.TargetID == 0 {
+		c.TargetID = ctx.GetUserID()
+		c.TargetName = ctx.GetFullName()
+	}
+	c, err := s.Record(ctx, c)
+	if err != nil {
+		ctx.Logger(logger).Error().Err(err).Int64("user_id", c.TargetID).Msg("保存处罚记录失败")
+	}
+	return c
+}
+
+// mirror 将记录同步到日志频道，失败只记录日志
+func (s *Store) mirror(ctx *context.Context, c Case) {
+	s.mu.RLock()
+	channel := s.cfg.LogChannel
+	s.mu.RUnlock()
+	if channel == 0 {
+		return
+	}
+
+	text := c.Details(ctx.GetChat().Title)
+	if _, err := ctx.Api.SendMessage(ctx.Ctx, tu.Message(tu.ID(channel), text)); err != nil {
+		logger.Warn().Err(err).Int64("channel", channel).Int("case", c.ID).Msg("同步处罚记录到日志频道失败")
+	}
+}
@@ -0,0 +1,101 @@
+package moderation
+
+import (
+	stdctx "context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/config"
+
+	"github.com/mymmrac/telego"
+)
+
+func TestStoreSaveBatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cases.json")
+	s := NewStore(path, Config{MaxCases: 3})
+
+	for i := 0; i < 5; i++ {
+		if _, err := s.Add(Case{ChatID: -100, TargetID: 42, Action: ActionDelete}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.MarkReverted(5, 6); err != nil {
+		t.Fatal(err)
+	}
+	// 记录只在延迟后写入，连续记录不会逐条写文件
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("cases written before the save delay: %v", err)
+	}
+
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewStore(path, Config{})
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	history := loaded.History(-100, 42)
+	if len(history) != 3 {
+		t.Fatalf("got %d cases, want 3 (max_cases)", len(history))
+	}
+	if history[0].ID != 5 || history[2].ID != 3 {
+		t.Errorf("ids = %d..%d, want 5..3", history[0].ID, history[2].ID)
+	}
+	if history[0].RevertedBy != 6 {
+		t.Errorf("RevertedBy = %d, want 6", history[0].RevertedBy)
+	}
+	if c, _ := loaded.Add(Case{}); c.ID != 6 {
+		t.Errorf("next id = %d, want 6", c.ID)
+	}
+}
+
+func TestForPerBot(t *testing.T) {
+	a, b := config.NewEmptyManager(), config.NewEmptyManager()
+	a.SetDataDir(t.TempDir())
+	b.SetDataDir(t.TempDir())
+
+	if For(a) != For(a) {
+		t.Error("plugins of the same bot should share one store")
+	}
+	if For(a) == For(b) {
+		t.Error("each bot should have its own store")
+	}
+	if got, want := For(b).Path(), filepath.Join(b.DataDir(), "moderation", "cases.json"); got != want {
+		t.Errorf("Path = %q, want %q", got, want)
+	}
+}
+
+func TestRecordAuto(t *testing.T) {
+	s := NewStore("", Config{})
+	ctx := context.NewContext(stdctx.Background(), nil, telego.Update{
+		Message: &telego.Message{
+			Chat: telego.Chat{ID: -100, Type: telego.ChatTypeSupergroup},
+			From: &telego.User{ID: 42, FirstName: "Alice", LastName: "L"},
+		},
+	})
+
+	tests := []struct {
+		name       string
+		c          Case
+		wantTarget int64
+		wantName   string
+	}{
+		{"目标取自更新", Case{Action: ActionDelete, ActorID: 7}, 42, "Alice L"},
+		{"指定目标", Case{Action: ActionKick, TargetID: 9, TargetName: "Bob"}, 9, "Bob"},
+	}
+	for _, tt := range tests {
+		c := s.RecordAuto(ctx, tt.c)
+		if c.ID == 0 {
+			t.Fatalf("%s: case not saved", tt.name)
+		}
+		if c.ChatID != -100 || c.ActorID != 0 || c.ActorName != "自动" {
+			t.Errorf("%s: chat %d, actor %d %q, want -100, 0 自动", tt.name, c.ChatID, c.ActorID, c.ActorName)
+		}
+		if c.TargetID != tt.wantTarget || c.TargetName != tt.wantName {
+			t.Errorf("%s: target %d %q, want %d %q", tt.name, c.TargetID, c.TargetName, tt.wantTarget, tt.wantName)
+		}
+	}
+}
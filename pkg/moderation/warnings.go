@@ -0,0 +1,242 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+)
+
+// -------------------- 警告 --------------------
+//
+// 警告是 Action 为 warn 的处罚记录：在有效期内且未被撤销的警告计入次数，
+// 次数达到策略中的某一级时自动执行该级处罚（禁言、封禁或踢出），并记为新的处罚记录。
+
+// WarnPolicy 警告策略
+type WarnPolicy struct {
+	Expire string     `mapstructure:"expire" doc:"警告有效期，如 7d、30d，永久表示不过期；会话覆盖中为空时沿用全局"`
+	Steps  []WarnStep `mapstructure:"steps" doc:"升级处罚，达到次数后每次警告都执行次数最高的一级；会话覆盖中为空时沿用全局"`
+}
+
+// WarnStep 一级升级处罚
+type WarnStep struct {
+	Count    int    `mapstructure:"count" doc:"有效警告次数" validate:"min=1"`
+	Action   string `mapstructure:"action" doc:"处罚：mute / ban / kick" validate:"oneof=mute ban kick"`
+	Duration string `mapstructure:"duration" doc:"禁言或封禁时长，如 1h、1d，为空表示永久"`
+}
+
+// DefaultWarnPolicy 默认策略：警告 30 天有效，3 次禁言 1 小时，5 次封禁
+func DefaultWarnPolicy() WarnPolicy {
+	return WarnPolicy{
+		Expire: "30d",
+		Steps: []WarnStep{
+			{Count: 3, Action: string(ActionMute), Duration: "1h"},
+			{Count: 5, Action: string(ActionBan)},
+		},
+	}
+}
+
+// Validate 检查时长格式
+func (p *WarnPolicy) Validate() error {
+	if p.Expire != "" {
+		if _, err := common.ParseDuration(p.Expire); err != nil {
+			return fmt.Errorf("expire: %w", err)
+		}
+	}
+	for i, step := range p.Steps {
+		if step.Duration == "" {
+			continue
+		}
+		if _, err := common.ParseDuration(step.Duration); err != nil {
+			return fmt.Errorf("steps[%d].duration: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// ExpireAfter 警告有效期，未设置或为永久时返回 common.Forever
+func (p WarnPolicy) ExpireAfter() time.Duration {
+	d, err := common.ParseDuration(p.Expire)
+	if err != nil {
+		return common.Forever
+	}
+	return d
+}
+
+// StepFor 返回 n 次警告对应的处罚（次数不超过 n 的最高一级）
+func (p WarnPolicy) StepFor(n int) (WarnStep, bool) {
+	var (
+		best  WarnStep
+		found bool
+	)
+	for _, step := range p.Steps {
+		if step.Count <= n && (!found || step.Count > best.Count) {
+			best, found = step, true
+		}
+	}
+	return best, found
+}
+
+// NextStep 返回 n 次警告之后的下一级处罚
+func (p WarnPolicy) NextStep(n int) (WarnStep, bool) {
+	var (
+		next  WarnStep
+		found bool
+	)
+	for _, step := range p.Steps {
+		if step.Count > n && (!found || step.Count < next.Count) {
+			next, found = step, true
+		}
+	}
+	return next, found
+}
+
+// duration 处罚时长，踢出为 0，未设置或格式错误时为永久
+func (s WarnStep) duration() time.Duration {
+	if Action(s.Action) == ActionKick {
+		return 0
+	}
+	d, err := common.ParseDuration(s.Duration)
+	if err != nil {
+		return common.Forever
+	}
+	return d
+}
+
+// String 处罚说明，如 "禁言（1小时）"
+func (s WarnStep) String() string {
+	if d := s.duration(); d != 0 {
+		return fmt.Sprintf("%s（%s）", Action(s.Action), common.FormatDuration(d))
+	}
+	return Action(s.Action).String()
+}
+
+// WarnPolicy 返回会话 chatID 生效的警告策略
+func (s *Store) WarnPolicy(chatID int64) WarnPolicy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	policy := s.cfg.Warnings
+	if chat, ok := s.cfg.ChatWarnings[fmt.Sprint(chatID)]; ok {
+		if chat.Expire != "" {
+			policy.Expire = chat.Expire
+		}
+		if len(chat.Steps) > 0 {
+			policy.Steps = chat.Steps
+		}
+	}
+	return policy
+}
+
+// ActiveWarnings 返回 chatID 中 userID 有效期内未被撤销的警告，最新的在前
+func (s *Store) ActiveWarnings(chatID, userID int64) []Case {
+	expire := s.WarnPolicy(chatID).ExpireAfter()
+	now := time.Now()
+
+	var out []Case
+	for _, c := range s.History(chatID, userID) {
+		if c.Action != ActionWarn || c.Reverted() {
+			continue
+		}
+		if expire != common.Forever && now.Sub(c.Time) > expire {
+			break // 按时间倒序，之后的都已过期
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// WarnResult 一次警告的结果
+type WarnResult struct {
+	Case       Case      // 警告记录
+	Count      int       // 当前有效警告次数
+	Next       *WarnStep // 下一级处罚，没有时为 nil
+	Punishment *Case     // 本次触发的升级处罚，没有时为 nil
+}
+
+// Summary 结果说明，如 "⚠️ 有效警告 2 次，再 1 次将禁言（1小时）"
+func (r WarnResult) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⚠️ 有效警告 %d 次", r.Count)
+	if p := r.Punishment; p != nil {
+		fmt.Fprintf(&sb, "，已自动%s", p.Action)
+		if p.Duration != 0 {
+			fmt.Fprintf(&sb, "（%s）", common.FormatDuration(p.Duration))
+		}
+	}
+	if r.Next != nil {
+		fmt.Fprintf(&sb, "，再 %d 次将%s", r.Next.Count-r.Count, r.Next)
+	}
+	return sb.String()
+}
+
+// Warn 记录一次警告，有效次数达到策略中的某一级时执行该级处罚。
+// c 中的群组、操作人与来源按 Record 的规则补全；处罚失败时返回错误，警告本身已记录
+func (s *Store) Warn(ctx *context.Context, c Case) (WarnResult, error) {
+	c.Action = ActionWarn
+	c, err := s.Record(ctx, c)
+	if err != nil {
+		return WarnResult{Case: c}, err
+	}
+
+	n := len(s.ActiveWarnings(c.ChatID, c.TargetID))
+	res := WarnResult{Case: c, Count: n}
+
+	policy := s.WarnPolicy(c.ChatID)
+	if next, ok := policy.NextStep(n); ok {
+		res.Next = &next
+	}
+	step, ok := policy.StepFor(n)
+	if !ok {
+		return res, nil
+	}
+
+	d := step.duration()
+	switch Action(step.Action) {
+	case ActionMute:
+		err = Mute(ctx, c.TargetID, d)
+	case ActionBan:
+		err = Ban(ctx, c.TargetID, d)
+	case ActionKick:
+		err = Kick(ctx, c.TargetID)
+	}
+	if err != nil {
+		return res, fmt.Errorf("执行警告升级处罚失败: %w", err)
+	}
+
+	p, err := s.Record(ctx, Case{
+		ChatID:     c.ChatID,
+		Action:     Action(step.Action),
+		ActorName:  "自动（警告升级）",
+		TargetID:   c.TargetID,
+		TargetName: c.TargetName,
+		Reason:     fmt.Sprintf("累计 %d 次警告", n),
+		Duration:   d,
+	})
+	res.Punishment = &p
+	return res, err
+}
+
+// RevokeWarning 撤销 chatID 中 userID 最近一次有效警告，返回被撤销的警告与撤销记录
+func (s *Store) RevokeWarning(ctx *context.Context, chatID, userID int64, reason string) (Case, Case, error) {
+	active := s.ActiveWarnings(chatID, userID)
+	if len(active) == 0 {
+		return Case{}, Case{}, fmt.Errorf("没有有效的警告")
+	}
+	warn := active[0]
+
+	revoke, err := s.Record(ctx, Case{
+		ChatID:     chatID,
+		Action:     ActionUnwarn,
+		TargetID:   userID,
+		TargetName: warn.TargetName,
+		Reason:     reason,
+		Reverts:    warn.ID,
+	})
+	if err != nil {
+		return warn, revoke, err
+	}
+	return warn, revoke, s.MarkReverted(warn.ID, revoke.ID)
+}
@@ -0,0 +1,197 @@
+// Package paginator 提供通用的分页消息组件。
+//
+// 核心功能：
+//   - 使用 New 传入数据源与页面渲染函数构造分页器
+//   - 使用 Send / Reply 发送第一页，并自动附带 ◀ ▶ 翻页按钮
+//   - 翻页回调由框架中间件统一处理，插件无需注册回调匹配器
+//   - 支持仅限发起者翻页、超时自动失效
+package paginator
+
+import (
+	"fmt"
+	"time"
+
+	"yueling_tg/internal/core/context"
+
+	"github.com/mymmrac/telego"
+)
+
+const (
+	DefaultPageSize = 10
+	DefaultTimeout  = 5 * time.Minute
+)
+
+// Page 渲染时传入的单页数据
+type Page[T any] struct {
+	Items  []T // 当前页数据
+	Index  int // 当前页码（从 0 开始）
+	Total  int // 总页数
+	Count  int // 数据总条数
+	Offset int // 当前页第一条数据在全部数据中的下标
+
+	// Ctx 触发渲染的更新：首页为发起命令的消息，翻页时为按钮回调（可能来自其他用户与群组），
+	// 需要按用户语言渲染时应使用它而不是发起命令时的 ctx
+	Ctx *context.Context
+}
+
+// Number 当前页码（从 1 开始）
+func (p Page[T]) Number() int {
+	return p.Index + 1
+}
+
+// Source 分页数据源，返回从 offset 开始最多 limit 条数据以及数据总数
+type Source[T any] func(offset, limit int) (items []T, total int, err error)
+
+// Renderer 页面渲染函数
+type Renderer[T any] func(page Page[T]) string
+
+// FromSlice 使用切片作为数据源
+func FromSlice[T any](items []T) Source[T] {
+	return func(offset, limit int) ([]T, int, error) {
+		if offset >= len(items) {
+			return nil, len(items), nil
+		}
+		end := offset + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		return items[offset:end], len(items), nil
+	}
+}
+
+// Paginator 分页器
+type Paginator struct {
+	pageSize       int
+	timeout        time.Duration
+	onlyRequester  bool
+	parseMode      string
+	disablePreview bool
+
+	// 泛型擦除后的渲染函数：渲染指定页，返回文本、实际页码与总页数
+	render func(c *context.Context, index, pageSize int) (string, int, int, error)
+}
+
+// New 创建分页器
+func New[T any](source Source[T], render Renderer[T]) *Paginator {
+	return &Paginator{
+		pageSize:      DefaultPageSize,
+		timeout:       DefaultTimeout,
+		onlyRequester: true,
+		render: func(c *context.Context, index, pageSize int) (string, int, int, error) {
+			if index < 0 {
+				index = 0
+			}
+
+			offset := index * pageSize
+			items, count, err := source(offset, pageSize)
+			if err != nil {
+				return "", 0, 0, err
+			}
+
+			total := (count + pageSize - 1) / pageSize
+			if total == 0 {
+				total = 1
+			}
+
+			// 数据变少导致页码越界时回到最后一页
+			if index >= total {
+				index = total - 1
+				offset = index * pageSize
+				if items, count, err = source(offset, pageSize); err != nil {
+					return "", 0, 0, err
+				}
+			}
+
+			return render(Page[T]{
+				Items:  items,
+				Index:  index,
+				Total:  total,
+				Count:  count,
+				Offset: offset,
+				Ctx:    c,
+			}), index, total, nil
+		},
+	}
+}
+
+// PageSize 设置每页条数
+func (p *Paginator) PageSize(n int) *Paginator {
+	if n > 0 {
+		p.pageSize = n
+	}
+	return p
+}
+
+// Timeout 设置翻页按钮的有效期（自最后一次操作起计算）
+func (p *Paginator) Timeout(d time.Duration) *Paginator {
+	if d > 0 {
+		p.timeout = d
+	}
+	return p
+}
+
+// OnlyRequester 是否仅允许发起者翻页（默认开启）
+func (p *Paginator) OnlyRequester(b bool) *Paginator {
+	p.onlyRequester = b
+	return p
+}
+
+// ParseMode 设置消息解析模式（telego.ModeMarkdown / telego.ModeHTML 等）
+func (p *Paginator) ParseMode(mode string) *Paginator {
+	p.parseMode = mode
+	return p
+}
+
+// DisablePreview 关闭链接预览
+func (p *Paginator) DisablePreview(b bool) *Paginator {
+	p.disablePreview = b
+	return p
+}
+
+// Send 发送第一页
+func (p *Paginator) Send(c *context.Context) (*telego.Message, error) {
+	return p.send(c, false)
+}
+
+// Reply 以回复当前消息的方式发送第一页
+func (p *Paginator) Reply(c *context.Context) (*telego.Message, error) {
+	return p.send(c, true)
+}
+
+func (p *Paginator) send(c *context.Context, reply bool) (*telego.Message, error) {
+	text, _, total, err := p.render(c, 0, p.pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("渲染分页失败: %w", err)
+	}
+
+	// 只有一页时无需翻页按钮
+	var sess *session
+	if total > 1 {
+		sess = defaultManager.newSession(p, c.GetUserID())
+	}
+
+	msg, err := c.SendWithOptions(text, func(params *telego.SendMessageParams) {
+		params.ParseMode = p.parseMode
+		if p.disablePreview {
+			params.LinkPreviewOptions = &telego.LinkPreviewOptions{IsDisabled: true}
+		}
+		if reply {
+			params.ReplyParameters = &telego.ReplyParameters{MessageID: c.GetMessageID()}
+		}
+		if sess != nil {
+			params.ReplyMarkup = sess.keyboard(0, total)
+		}
+	})
+	if err != nil {
+		if sess != nil {
+			defaultManager.remove(sess.id)
+		}
+		return nil, err
+	}
+
+	if sess != nil {
+		defaultManager.activate(sess, c.Api, msg)
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,208 @@
+package paginator
+
+import (
+	ctx "context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/internal/core/log"
+	"yueling_tg/internal/middleware"
+	"yueling_tg/pkg/common"
+
+	"github.com/mymmrac/telego"
+)
+
+// 回调数据前缀，格式: pg:<会话ID>:<页码>
+const callbackPrefix = "pg:"
+
+var logger = log.NewHandler("分页器")
+
+var defaultManager = &manager{
+	sessions: make(map[string]*session),
+}
+
+// -------------------- 会话 --------------------
+
+type session struct {
+	id        string
+	paginator *Paginator
+	ownerID   int64
+
+	mu        sync.Mutex
+	api       *telego.Bot
+	chatID    telego.ChatID
+	messageID int
+	timer     *time.Timer
+}
+
+// keyboard 生成翻页键盘
+func (s *session) keyboard(index, total int) *telego.InlineKeyboardMarkup {
+	prev := index - 1
+	if prev < 0 {
+		prev = total - 1
+	}
+	next := index + 1
+	if next >= total {
+		next = 0
+	}
+
+	return &telego.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telego.InlineKeyboardButton{{
+			{Text: "◀", CallbackData: fmt.Sprintf("%s%s:%d", callbackPrefix, s.id, prev)},
+			{Text: fmt.Sprintf("%d / %d", index+1, total), CallbackData: fmt.Sprintf("%s%s:noop", callbackPrefix, s.id)},
+			{Text: "▶", CallbackData: fmt.Sprintf("%s%s:%d", callbackPrefix, s.id, next)},
+		}},
+	}
+}
+
+// -------------------- 会话管理器 --------------------
+
+type manager struct {
+	sessions map[string]*session
+	mu       sync.RWMutex
+}
+
+func (m *manager) newSession(p *Paginator, ownerID int64) *session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := common.RandomString(8)
+	for m.sessions[id] != nil {
+		id = common.RandomString(8)
+	}
+
+	s := &session{
+		id:        id,
+		paginator: p,
+		ownerID:   ownerID,
+	}
+	m.sessions[id] = s
+	return s
+}
+
+// activate 消息发送成功后记录位置并开始计时
+func (m *manager) activate(s *session, api *telego.Bot, msg *telego.Message) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.api = api
+	s.chatID = msg.Chat.ChatID()
+	s.messageID = msg.MessageID
+	s.timer = time.AfterFunc(s.paginator.timeout, func() {
+		m.expire(s)
+	})
+}
+
+func (m *manager) get(id string) *session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sessions[id]
+}
+
+func (m *manager) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
+
+// expire 会话超时：移除翻页按钮
+func (m *manager) expire(s *session) {
+	m.remove(s.id)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.api == nil {
+		return
+	}
+
+	_, err := s.api.EditMessageReplyMarkup(ctx.Background(), &telego.EditMessageReplyMarkupParams{
+		ChatID:    s.chatID,
+		MessageID: s.messageID,
+	})
+	if err != nil {
+		logger.Debug().Err(err).Str("session", s.id).Msg("移除翻页按钮失败")
+	}
+}
+
+// -------------------- 回调处理 --------------------
+
+// Middleware 处理分页按钮回调的中间件，由运行时默认注册
+func Middleware() middleware.Middleware {
+	return middleware.MiddlewareFunc("分页中间件", func(c *context.Context, next middleware.HandlerFunc) error {
+		data := c.GetCallbackData()
+		if !strings.HasPrefix(data, callbackPrefix) {
+			return next(c)
+		}
+		return defaultManager.handle(c, strings.TrimPrefix(data, callbackPrefix))
+	})
+}
+
+func (m *manager) handle(c *context.Context, data string) error {
+	parts := strings.SplitN(data, ":", 2)
+	if len(parts) != 2 {
+		return c.AnswerCallback("参数错误")
+	}
+
+	s := m.get(parts[0])
+	if s == nil {
+		c.AnswerCallback("翻页已过期，请重新查询")
+		// 顺手移除失效按钮
+		if msg := c.GetCallbackQuery().Message; msg != nil {
+			c.EditMessageReplyMarkup(msg.GetMessageID(), nil)
+		}
+		return nil
+	}
+
+	if s.paginator.onlyRequester && c.GetUserID() != s.ownerID {
+		return c.AnswerCallback("只有发起者可以翻页哦~")
+	}
+
+	if parts[1] == "noop" {
+		return c.AnswerCallback("")
+	}
+
+	index, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return c.AnswerCallback("参数错误")
+	}
+
+	text, index, total, err := s.paginator.render(c, index, s.paginator.pageSize)
+	if err != nil {
+		c.AnswerCallback("加载失败")
+		return fmt.Errorf("渲染分页失败: %w", err)
+	}
+
+	s.mu.Lock()
+	if s.timer != nil {
+		s.timer.Reset(s.paginator.timeout)
+	}
+	chatID, messageID := s.chatID, s.messageID
+	s.mu.Unlock()
+
+	_, err = c.EditMessageTextWithOptions(messageID, text, func(params *telego.EditMessageTextParams) {
+		params.ChatID = chatID
+		params.ParseMode = s.paginator.parseMode
+		if s.paginator.disablePreview {
+			params.LinkPreviewOptions = &telego.LinkPreviewOptions{IsDisabled: true}
+		}
+		if total > 1 {
+			params.ReplyMarkup = s.keyboard(index, total)
+		}
+	})
+	if err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		c.AnswerCallback("翻页失败")
+		return fmt.Errorf("编辑分页消息失败: %w", err)
+	}
+
+	// 数据减少到只剩一页时结束会话
+	if total <= 1 {
+		m.remove(s.id)
+	}
+
+	return c.AnswerCallback("")
+}
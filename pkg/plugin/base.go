@@ -1,7 +1,9 @@
 package plugin
 
 import (
+	"yueling_tg/internal/core/context"
 	"yueling_tg/internal/core/log"
+	"yueling_tg/internal/middleware"
 
 	"github.com/rs/zerolog"
 )
@@ -10,6 +12,9 @@ type Base struct {
 	Info     *PluginInfo
 	Log      zerolog.Logger
 	matchers []*Matcher
+	ctx      *PluginContext
+
+	middlewares []middleware.Middleware
 }
 
 func NewBase(info *PluginInfo) *Base {
@@ -19,8 +24,9 @@ func NewBase(info *PluginInfo) *Base {
 
 	return &Base{
 		Info:     info,
-		Log:      log.NewPlugin(info.Name),
+		Log:      log.NewPluginWithID(info.ID, info.Name),
 		matchers: make([]*Matcher, 0),
+		ctx:      NewPluginContext(info.ID),
 	}
 }
 
@@ -28,6 +34,11 @@ func (b *Base) PluginInfo() *PluginInfo {
 	return b.Info
 }
 
+// PluginContext 返回插件的数据、缓存与资源目录
+func (b *Base) PluginContext() *PluginContext {
+	return b.ctx
+}
+
 func (b *Base) Matchers() []*Matcher {
 	return b.matchers
 }
@@ -39,3 +50,18 @@ func (b *Base) SetMatchers(matchers []*Matcher) {
 func (b *Base) AddMatcher(m *Matcher) {
 	b.matchers = append(b.matchers, m)
 }
+
+// Use 添加插件级中间件，作用于本插件的全部匹配器
+func (b *Base) Use(middlewares ...middleware.Middleware) {
+	b.middlewares = append(b.middlewares, middlewares...)
+}
+
+// Middlewares 返回插件级中间件
+func (b *Base) Middlewares() []middleware.Middleware {
+	return b.middlewares
+}
+
+// Logger 返回附加了当前更新字段（update_id、chat_id、user_id、plugin）的插件日志记录器
+func (b *Base) Logger(ctx *context.Context) *zerolog.Logger {
+	return ctx.Logger(b.Log)
+}
@@ -2,6 +2,8 @@ package plugin
 
 import (
 	"reflect"
+	"time"
+	"yueling_tg/internal/middleware"
 	"yueling_tg/pkg/plugin/dsl/condition"
 	"yueling_tg/pkg/plugin/dsl/permission"
 	"yueling_tg/pkg/plugin/handler"
@@ -13,9 +15,11 @@ import (
 // -----------------------------------------------------------------------------
 
 type pluginBuilder struct {
-	info      *PluginInfo
-	matchers  []*Matcher
-	providers []provider.Provider
+	info        *PluginInfo
+	matchers    []*Matcher
+	providers   []provider.Provider
+	ctx         *PluginContext
+	middlewares []middleware.Middleware
 }
 
 // New returns a new plugin builder.
@@ -28,6 +32,18 @@ func (p *pluginBuilder) Info(info *PluginInfo) *pluginBuilder {
 	return p
 }
 
+// Context 使用构造函数中已创建的插件运行环境（默认配置需要用到插件目录时）
+func (p *pluginBuilder) Context(ctx *PluginContext) *pluginBuilder {
+	p.ctx = ctx
+	return p
+}
+
+// Use 添加插件级中间件，作用于本插件的全部匹配器
+func (p *pluginBuilder) Use(middlewares ...middleware.Middleware) *pluginBuilder {
+	p.middlewares = append(p.middlewares, middlewares...)
+	return p
+}
+
 func (p *pluginBuilder) Provide(provider provider.Provider) *pluginBuilder {
 	p.providers = append(p.providers, provider)
 	return p
@@ -41,6 +57,10 @@ func (p *pluginBuilder) addMatcher(m *Matcher) *pluginBuilder {
 // Build finalizes and returns the plugin instance.
 func (p *pluginBuilder) Go(parents ...any) Plugin {
 	plg := NewBase(p.info)
+	if p.ctx != nil {
+		plg.ctx = p.ctx
+	}
+	plg.Use(p.middlewares...)
 
 	// 注册匹配器
 	for _, m := range p.matchers {
@@ -92,8 +112,10 @@ type matcherBuilder struct {
 	parent      *pluginBuilder
 	makeMatcher func(fn any) *Matcher
 	perms       []permission.Permission
+	middlewares []middleware.Middleware
 	priority    int
 	block       bool
+	timeout     time.Duration
 }
 
 // 创建新的 matcher builder
@@ -107,12 +129,24 @@ func (m *matcherBuilder) When(perms ...permission.Permission) *matcherBuilder {
 	return m
 }
 
+// 添加匹配器级中间件
+func (m *matcherBuilder) Use(middlewares ...middleware.Middleware) *matcherBuilder {
+	m.middlewares = append(m.middlewares, middlewares...)
+	return m
+}
+
 // 设置优先级
 func (m *matcherBuilder) Priority(n int) *matcherBuilder {
 	m.priority = n
 	return m
 }
 
+// 设置处理器截止时间
+func (m *matcherBuilder) Timeout(d time.Duration) *matcherBuilder {
+	m.timeout = d
+	return m
+}
+
 // 是否阻止事件传播
 func (m *matcherBuilder) Block(b bool) *matcherBuilder {
 	m.block = b
@@ -131,6 +165,10 @@ func (m *matcherBuilder) Do(fn any) *pluginBuilder {
 		matcher.Priority = m.priority
 	}
 	matcher.Block = m.block
+	matcher.Use(m.middlewares...)
+	if m.timeout > 0 {
+		matcher.Timeout = m.timeout
+	}
 
 	return m.parent.addMatcher(matcher)
 }
@@ -0,0 +1,242 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"strings"
+
+	"yueling_tg/pkg/config"
+)
+
+// -------------------- 插件目录与资源 --------------------
+//
+// 每个插件在数据根目录（-data-dir，默认 ./data）下拥有三个目录：
+//
+//	<数据根目录>/<插件ID>/           DataDir    持久化数据，会被 export-data 导出
+//	<数据根目录>/cache/<插件ID>/     CacheDir   可随时删除的缓存
+//	<数据根目录>/assets/<插件ID>/    AssetsDir  字体、图片等资源，首次运行时从嵌入的默认资源复制
+//
+// 目录在插件注册时创建；声明为必需的资源缺失时，注册与 check-config 会逐项报告。
+
+// Asset 插件需要的资源
+type Asset struct {
+	Path     string // 相对 AssetsDir 的路径或绝对路径，文件或目录
+	Desc     string // 说明，出现在缺失提示中
+	Required bool   // 缺失时插件无法正常工作
+}
+
+// PluginContext 插件运行环境，提供插件专属的数据、缓存与资源目录
+type PluginContext struct {
+	id   string
+	root string
+	cm   *config.ConfigManager
+
+	assets    []Asset
+	defaults  []embedded
+	migration [][2]string
+}
+
+type embedded struct {
+	fsys fs.FS
+	dir  string
+}
+
+// NewPluginContext 创建插件运行环境，记录当前配置管理器，目录以其数据根目录为基准（见 config.DataDir）
+func NewPluginContext(id string) *PluginContext {
+	return &PluginContext{id: id, root: config.DataDir(), cm: config.Current()}
+}
+
+// Config 返回创建时的配置管理器（即插件所属 Bot 的配置），之后的 config.With 不影响结果；
+// 处理器、goroutine 与定时器中应通过它而不是 config.GetManager 访问本 Bot 的配置与存储。
+// 创建时未初始化配置则为空
+func (c *PluginContext) Config() *config.ConfigManager {
+	return c.cm
+}
+
+// DataDir 持久化数据目录，elem 非空时返回其下的路径
+func (c *PluginContext) DataDir(elem ...string) string {
+	return filepath.Join(append([]string{c.root, c.id}, elem...)...)
+}
+
+// CacheDir 缓存目录，elem 非空时返回其下的路径
+func (c *PluginContext) CacheDir(elem ...string) string {
+	return filepath.Join(append([]string{c.root, "cache", c.id}, elem...)...)
+}
+
+// AssetsDir 资源目录，elem 非空时返回其下的路径
+func (c *PluginContext) AssetsDir(elem ...string) string {
+	return filepath.Join(append([]string{c.root, "assets", c.id}, elem...)...)
+}
+
+// EmbedAssets 登记嵌入的默认资源：fsys 中 dir 目录下的文件在准备阶段复制到 AssetsDir，
+// 已存在的文件不会被覆盖，用户可以替换默认资源
+func (c *PluginContext) EmbedAssets(fsys fs.FS, dir string) *PluginContext {
+	c.defaults = append(c.defaults, embedded{fsys: fsys, dir: dir})
+	return c
+}
+
+// RequireAsset 声明必需的资源，path 相对 AssetsDir（也可以是绝对路径）；目录需至少包含一个文件
+func (c *PluginContext) RequireAsset(path, desc string) *PluginContext {
+	c.assets = append(c.assets, Asset{Path: path, Desc: desc, Required: true})
+	return c
+}
+
+// Migrate 登记旧版本使用的路径：准备阶段 from 存在而 to 不存在时将其移动到 to，用于平滑迁移默认路径
+func (c *PluginContext) Migrate(from, to string) *PluginContext {
+	if filepath.Clean(from) != filepath.Clean(to) {
+		c.migration = append(c.migration, [2]string{from, to})
+	}
+	return c
+}
+
+// Assets 返回已声明的资源
+func (c *PluginContext) Assets() []Asset {
+	return append([]Asset(nil), c.assets...)
+}
+
+// Prepare 迁移旧路径、创建目录并复制默认资源，在插件注册时调用
+func (c *PluginContext) Prepare() error {
+	var errs []error
+
+	for _, m := range c.migration {
+		if err := migrate(m[0], m[1]); err != nil {
+			errs = append(errs, fmt.Errorf("迁移 %s 失败: %w", m[0], err))
+		}
+	}
+
+	for _, dir := range []string{c.DataDir(), c.CacheDir(), c.AssetsDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, e := range c.defaults {
+		if err := c.install(e); err != nil {
+			errs = append(errs, fmt.Errorf("复制默认资源失败: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check 检查必需的资源，返回每一项缺失的错误；嵌入的默认资源或待迁移的旧路径中已有的视为存在
+func (c *PluginContext) Check() []error {
+	var errs []error
+	for _, a := range c.assets {
+		if !a.Required {
+			continue
+		}
+		path := c.assetPath(a.Path)
+		if !present(path) && !c.embedded(path) && !c.pending(path) {
+			errs = append(errs, fmt.Errorf("缺少资源 %s（%s）", path, a.Desc))
+		}
+	}
+	return errs
+}
+
+// assetPath 将相对 AssetsDir 的路径转换为完整路径
+func (c *PluginContext) assetPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return c.AssetsDir(path)
+}
+
+// embedded 嵌入的默认资源中是否包含 path（位于 AssetsDir 下的完整路径）
+func (c *PluginContext) embedded(path string) bool {
+	rel, ok := within(c.AssetsDir(), path)
+	if !ok {
+		return false
+	}
+	for _, e := range c.defaults {
+		if _, err := fs.Stat(e.fsys, pathpkg.Join(e.dir, filepath.ToSlash(rel))); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// pending path 是否会在准备阶段从旧路径迁移而来
+func (c *PluginContext) pending(path string) bool {
+	for _, m := range c.migration {
+		if rel, ok := within(m[1], path); ok && present(filepath.Join(m[0], rel)) {
+			return true
+		}
+	}
+	return false
+}
+
+// install 将嵌入的默认资源复制到 AssetsDir，跳过已存在的文件
+func (c *PluginContext) install(e embedded) error {
+	return fs.WalkDir(e.fsys, e.dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, e.dir), "/")
+		dst := c.AssetsDir(filepath.FromSlash(rel))
+		if _, err := os.Stat(dst); err == nil {
+			return nil
+		}
+
+		src, err := e.fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		out, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, src); err != nil {
+			out.Close()
+			os.Remove(dst)
+			return err
+		}
+		return out.Close()
+	})
+}
+
+// migrate 将 from 移动到 to，from 不存在或 to 已存在时跳过
+func migrate(from, to string) error {
+	if _, err := os.Stat(from); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(to); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// within 返回 path 相对 base 的路径，path 不在 base 之下时返回 false
+func within(base, path string) (string, bool) {
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// present 文件存在，或目录存在且非空
+func present(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if !info.IsDir() {
+		return true
+	}
+	entries, err := os.ReadDir(path)
+	return err == nil && len(entries) > 0
+}
@@ -0,0 +1,27 @@
+package plugin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"yueling_tg/pkg/config"
+)
+
+func TestPluginContextConfig(t *testing.T) {
+	a, b := config.NewEmptyManager(), config.NewEmptyManager()
+	a.SetDataDir(t.TempDir())
+	b.SetDataDir(t.TempDir())
+
+	var pctx *PluginContext
+	config.With(a, func() { pctx = NewPluginContext("demo") })
+
+	// 创建之后切换当前配置不影响插件所属的 Bot
+	config.With(b, func() {
+		if pctx.Config() != a {
+			t.Error("Config should stay the manager current at creation")
+		}
+		if got, want := pctx.DataDir("x.json"), filepath.Join(a.DataDir(), "demo", "x.json"); got != want {
+			t.Errorf("DataDir = %q, want %q", got, want)
+		}
+	})
+}
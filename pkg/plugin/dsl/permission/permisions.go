@@ -29,7 +29,7 @@ func SuperUser(superUsers ...int64) Permission {
 // GroupOwner 仅群主权限
 func GroupOwner() Permission {
 	return PermissionFunc(func(ctx *context.Context) bool {
-		return getUserRole(ctx) == "owner"
+		return getUserRole(ctx) == "creator"
 	})
 }
 
@@ -6,10 +6,10 @@ import (
 	"yueling_tg/pkg/plugin/provider"
 )
 
-// 全局容器实例（应用启动时初始化一次）
-var GlobalContainer *Container
+// containerKey 运行时容器在 context.Storage 中的键
+const containerKey = "handler_container"
 
-// Container 依赖注入容器（应用级别，全局单例）
+// Container 依赖注入容器（每个 Bot 的运行时一个，另有每个 Handler 一个插件级容器）
 type Container struct {
 	// 静态 Provider（启动时注册，整个应用生命周期有效）
 	staticProviders []provider.Provider
@@ -58,9 +58,16 @@ func (c *Container) NewMergedResolver(ctx *context.Context, parentContainers ...
 	}
 }
 
-// InitGlobalContainer 初始化全局容器（应用启动时调用一次）
-func InitGlobalContainer() *Container {
-	GlobalContainer = NewContainer()
+// Bind 将运行时容器绑定到上下文，处理器解析参数时以其作为最外层容器
+func Bind(ctx *context.Context, c *Container) {
+	ctx.Storage.Set(containerKey, c)
+}
 
-	return GlobalContainer
+// ContainerOf 返回绑定到上下文的运行时容器，未绑定时返回 nil
+func ContainerOf(ctx *context.Context) *Container {
+	if v, ok := ctx.Storage.Get(containerKey); ok {
+		c, _ := v.(*Container)
+		return c
+	}
+	return nil
 }
@@ -51,20 +51,25 @@ func NewHandler(fn any) *Handler {
 	}
 }
 
-// Call 执行处理器（合并全局容器和插件容器）
+// Call 执行处理器（合并运行时容器和插件容器）
 func (h *Handler) Call(ctx *context.Context, providers ...provider.Provider) error {
 	var resolver *Resolver
 
+	var parents []*Container
+	if rc := ContainerOf(ctx); rc != nil {
+		parents = append(parents, rc)
+	}
+
 	// 如果有临时 providers，创建临时容器并设置为最高优先级
 	if len(providers) > 0 {
 		tempContainer := NewContainer()
 		tempContainer.RegisterStatic(providers...)
 
-		// 创建解析器：临时容器 > 插件容器 > 全局容器
-		resolver = tempContainer.NewMergedResolver(ctx, h.container, GlobalContainer)
+		// 创建解析器：临时容器 > 插件容器 > 运行时容器
+		resolver = tempContainer.NewMergedResolver(ctx, append([]*Container{h.container}, parents...)...)
 	} else {
-		// 没有临时 providers，直接合并：插件容器 > 全局容器
-		resolver = h.container.NewMergedResolver(ctx, GlobalContainer)
+		// 没有临时 providers，直接合并：插件容器 > 运行时容器
+		resolver = h.container.NewMergedResolver(ctx, parents...)
 	}
 
 	// 解析所有参数
@@ -1,7 +1,9 @@
 package plugin
 
 import (
+	"time"
 	"yueling_tg/internal/core/context"
+	"yueling_tg/internal/middleware"
 	"yueling_tg/pkg/plugin/dsl/condition"
 	"yueling_tg/pkg/plugin/dsl/permission"
 	"yueling_tg/pkg/plugin/dsl/rule"
@@ -9,13 +11,31 @@ import (
 	"yueling_tg/pkg/plugin/provider"
 )
 
+// matcherKey 当前匹配器在 context.Storage 中的键
+const matcherKey = "plugin_matcher"
+
 type Matcher struct {
-	plugin     Plugin                // 插件
-	Rule       rule.Rule             // 规则(必须全部满足)
-	Permission permission.Permission // 权限(任意满足即可)
-	Priority   int                   // 优先级(越大越优先)
-	Block      bool                  // 是否阻止事件传播
-	Handlers   []*handler.Handler    // 处理器
+	plugin      Plugin                  // 插件
+	Rule        rule.Rule               // 规则(必须全部满足)
+	Permission  permission.Permission   // 权限(任意满足即可)
+	Priority    int                     // 优先级(越大越优先)
+	Block       bool                    // 是否阻止事件传播
+	Handlers    []*handler.Handler      // 处理器
+	Middlewares []middleware.Middleware // 匹配器级中间件，在全局与插件级中间件之后执行
+	Timeout     time.Duration           // 处理器截止时间，0 时使用运行时的默认值
+	Trigger     Trigger                 // 触发方式（仅用于展示）
+}
+
+// Trigger 匹配器的触发方式，由 On* 系列函数设置，用于 list-plugins 等展示场景
+type Trigger struct {
+	Kind     string   // command / keyword / regex / message / callback 等，自定义规则为 custom
+	Patterns []string // 命令、关键词、正则或前缀
+}
+
+// withTrigger 设置触发方式
+func (m *Matcher) withTrigger(kind string, patterns ...string) *Matcher {
+	m.Trigger = Trigger{Kind: kind, Patterns: patterns}
+	return m
 }
 
 func NewMatcher(rule rule.Rule, handlers ...*handler.Handler) *Matcher {
@@ -62,6 +82,40 @@ func (m *Matcher) AppendHandler(handler *handler.Handler) *Matcher {
 	return m
 }
 
+// SetTimeout 设置处理器截止时间，通过 ctx.Ctx 传递给 API 调用与 HTTP 请求
+func (m *Matcher) SetTimeout(timeout time.Duration) *Matcher {
+	m.Timeout = timeout
+	return m
+}
+
+// Use 添加匹配器级中间件
+func (m *Matcher) Use(middlewares ...middleware.Middleware) *Matcher {
+	m.Middlewares = append(m.Middlewares, middlewares...)
+	return m
+}
+
+// BindMatcher 将匹配成功的匹配器绑定到更新上下文，中间件可通过 MatcherOf 读取
+func BindMatcher(ctx *context.Context, m *Matcher) {
+	ctx.Storage.Set(matcherKey, m)
+}
+
+// MatcherOf 返回当前正在执行的匹配器，未绑定时返回 nil
+func MatcherOf(ctx *context.Context) *Matcher {
+	if v, ok := ctx.Storage.Get(matcherKey); ok {
+		m, _ := v.(*Matcher)
+		return m
+	}
+	return nil
+}
+
+// PluginOf 返回当前正在执行的插件，未绑定时返回 nil
+func PluginOf(ctx *context.Context) Plugin {
+	if m := MatcherOf(ctx); m != nil {
+		return m.Plugin()
+	}
+	return nil
+}
+
 func (m *Matcher) Match(ctx *context.Context) bool {
 	if m.Rule != nil && !m.Rule.Match(ctx) {
 		return false
@@ -1,5 +1,11 @@
 package plugin
 
+import (
+	"strings"
+
+	"yueling_tg/pkg/i18n"
+)
+
 // PluginInfo 插件信息
 type PluginInfo struct {
 	ID          string         // 插件标识(必填)
@@ -12,3 +18,34 @@ type PluginInfo struct {
 	Group       string         // 插件分组
 	Extra       map[string]any // 额外信息
 }
+
+// 插件信息在消息目录中的键，位于插件自己的命名空间下
+const (
+	InfoNameKey        = "plugin.name"
+	InfoDescriptionKey = "plugin.description"
+	InfoUsageKey       = "plugin.usage"
+	InfoGroupKey       = "plugin.group"
+	InfoExamplesKey    = "plugin.examples" // 多个示例按行分隔
+)
+
+// Localize 返回指定语言下的插件信息副本，未翻译的字段保持原值
+func (info *PluginInfo) Localize(locale string) *PluginInfo {
+	localized := *info
+
+	translate := func(key string, field *string) {
+		if i18n.Has(locale, info.ID, key) {
+			*field = i18n.Translate(locale, info.ID, key)
+		}
+	}
+
+	translate(InfoNameKey, &localized.Name)
+	translate(InfoDescriptionKey, &localized.Description)
+	translate(InfoUsageKey, &localized.Usage)
+	translate(InfoGroupKey, &localized.Group)
+
+	if i18n.Has(locale, info.ID, InfoExamplesKey) {
+		localized.Examples = strings.Split(i18n.Translate(locale, info.ID, InfoExamplesKey), "\n")
+	}
+
+	return &localized
+}
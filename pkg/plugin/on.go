@@ -7,61 +7,61 @@ import (
 )
 
 func On(rule rule.Rule, handler *handler.Handler) *Matcher {
-	return NewMatcher(rule, handler)
+	return NewMatcher(rule, handler).withTrigger("custom")
 }
 
 func OnCallback(handler *handler.Handler) *Matcher {
 	handler.RegisterDynamicProvider(provider.CallbackDataProvider())
-	return NewMatcher(rule.IsCallbackEvent(), handler)
+	return NewMatcher(rule.IsCallbackEvent(), handler).withTrigger("callback")
 }
 
 func OnCallbackFullMatch(patterns []string, handler *handler.Handler) *Matcher {
 	handler.RegisterDynamicProvider(provider.CallbackDataProvider())
-	return NewMatcher(rule.CallbackFullMatch(patterns...), handler)
+	return NewMatcher(rule.CallbackFullMatch(patterns...), handler).withTrigger("callback", patterns...)
 }
 
 func OnCallbackStartsWith(patterns []string, handler *handler.Handler) *Matcher {
 	handler.RegisterDynamicProvider(provider.CallbackDataProvider())
-	return NewMatcher(rule.CallBackStartsWith(patterns...), handler)
+	return NewMatcher(rule.CallBackStartsWith(patterns...), handler).withTrigger("callback_prefix", patterns...)
 }
 
 func OnNotice(handler *handler.Handler) *Matcher {
-	return NewMatcher(rule.IsNoticeEvent(), handler)
+	return NewMatcher(rule.IsNoticeEvent(), handler).withTrigger("notice")
 }
 
 func OnMessage(handler *handler.Handler) *Matcher {
 	handler.RegisterDynamicProvider(provider.MessageProvider())
-	return NewMatcher(rule.IsMessageEvent(), handler)
+	return NewMatcher(rule.IsMessageEvent(), handler).withTrigger("message")
 }
 
 // 命令
 func OnStartsWith(prefixes []string, handler *handler.Handler) *Matcher {
-	return NewMatcher(rule.StartsWith(prefixes...), handler)
+	return NewMatcher(rule.StartsWith(prefixes...), handler).withTrigger("prefix", prefixes...)
 }
 
 func OnEndsWith(suffixes []string, handler *handler.Handler) *Matcher {
-	return NewMatcher(rule.EndsWith(suffixes...), handler)
+	return NewMatcher(rule.EndsWith(suffixes...), handler).withTrigger("suffix", suffixes...)
 }
 
 func OnFullMatch(patterns []string, handler *handler.Handler) *Matcher {
-	return NewMatcher(rule.FullMatch(patterns...), handler)
+	return NewMatcher(rule.FullMatch(patterns...), handler).withTrigger("fullmatch", patterns...)
 }
 
 func OnKeyword(keywords []string, handler *handler.Handler) *Matcher {
-	return NewMatcher(rule.Keyword(keywords...), handler)
+	return NewMatcher(rule.Keyword(keywords...), handler).withTrigger("keyword", keywords...)
 }
 
 func OnCommand(cmds []string, caseSensitive bool, handler *handler.Handler) *Matcher {
 	handler.RegisterDynamicProviders(provider.CommandArgsProvider(cmds), provider.CommandContextProvider(cmds))
-	return NewMatcher(rule.Command(caseSensitive, cmds...), handler)
+	return NewMatcher(rule.Command(caseSensitive, cmds...), handler).withTrigger("command", cmds...)
 }
 
 func OnRegex(patterns []string, handler *handler.Handler) *Matcher {
-	return NewMatcher(rule.Regex(patterns...), handler)
+	return NewMatcher(rule.Regex(patterns...), handler).withTrigger("regex", patterns...)
 }
 
 // OnInlineQuery 创建一个 InlineQuery Matcher
 func OnInlineQuery(handler *handler.Handler) *Matcher {
 	handler.RegisterDynamicProvider(provider.InlineQueryProvider())
-	return NewMatcher(rule.IsInlineQueryEvent(), handler)
+	return NewMatcher(rule.IsInlineQueryEvent(), handler).withTrigger("inline_query")
 }
@@ -1,5 +1,7 @@
 package plugin
 
+import "yueling_tg/internal/middleware"
+
 // 插件接口
 type Plugin interface {
 	// 插件信息
@@ -39,3 +41,23 @@ type PluginValidator interface {
 type PluginHealthChecker interface {
 	HealthCheck() error
 }
+
+// 支持配置热更新的插件，plugins.<id> 配置段变化时被调用，返回错误则本次修改被回滚
+type PluginConfigWatcher interface {
+	OnConfigChange(old, new map[string]any) error
+}
+
+// 拥有持久化数据的插件，返回数据文件或目录（用于导出与导入）
+type PluginDataProvider interface {
+	DataPaths() []string
+}
+
+// 拥有插件级中间件的插件（嵌入 Base 的插件均实现）
+type PluginMiddlewareProvider interface {
+	Middlewares() []middleware.Middleware
+}
+
+// 拥有专属目录与资源的插件（嵌入 Base 的插件均实现），注册时创建目录并检查必需资源
+type PluginContextProvider interface {
+	PluginContext() *PluginContext
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 	"yueling_tg/internal/core/log"
+	"yueling_tg/pkg/config"
 
 	"github.com/rs/zerolog"
 )
@@ -106,6 +107,22 @@ func (pr *PluginRegistry) RegisterPlugins(ps ...Plugin) error {
 			return fmt.Errorf("存在同名插件: %s", metadata.Name)
 		}
 
+		// 准备插件目录与默认资源，报告缺失的必需资源
+		if cp, ok := p.(PluginContextProvider); ok && cp.PluginContext() != nil {
+			pctx := cp.PluginContext()
+			if err := pctx.Prepare(); err != nil {
+				pr.logger.Warn().
+					Err(err).
+					Str("插件ID", metadata.ID).
+					Msg("准备插件目录失败")
+			}
+			for _, err := range pctx.Check() {
+				pr.logger.Error().
+					Str("插件ID", metadata.ID).
+					Msg(err.Error())
+			}
+		}
+
 		// 初始化插件（如果支持）
 		if initializer, ok := p.(PluginInitializer); ok {
 			if err := initializer.Init(); err != nil {
@@ -139,6 +156,21 @@ func (pr *PluginRegistry) RegisterPlugins(ps ...Plugin) error {
 			}
 		}
 
+		// 订阅配置热更新（如果支持），订阅插件创建时所属 Bot 的配置
+		if watcher, ok := p.(PluginConfigWatcher); ok {
+			cm := config.GetManager()
+			if cp, ok := p.(PluginContextProvider); ok && cp.PluginContext() != nil && cp.PluginContext().Config() != nil {
+				cm = cp.PluginContext().Config()
+			}
+			cm.WatchPlugin(metadata.ID, config.Listener{
+				Apply: func(old, new any) error {
+					oldMap, _ := old.(map[string]any)
+					newMap, _ := new.(map[string]any)
+					return watcher.OnConfigChange(oldMap, newMap)
+				},
+			})
+		}
+
 		// 注册插件
 		pr.plugins[metadata.ID] = p
 		pr.pluginMap[metadata.Name] = p
@@ -278,8 +310,21 @@ func (pr *PluginRegistry) HealthCheck() map[string]error {
 	results := make(map[string]error)
 
 	for id, plugin := range pr.plugins {
+		var errs []error
+		checked := false
 		if checker, ok := plugin.(PluginHealthChecker); ok {
-			results[id] = checker.HealthCheck()
+			errs = append(errs, checker.HealthCheck())
+			checked = true
+		}
+		// 缺失必需资源的插件视为不健康
+		if cp, ok := plugin.(PluginContextProvider); ok && cp.PluginContext() != nil {
+			if missing := cp.PluginContext().Check(); len(missing) > 0 {
+				errs = append(errs, missing...)
+				checked = true
+			}
+		}
+		if checked {
+			results[id] = errors.Join(errs...)
 		}
 	}
 
@@ -0,0 +1,105 @@
+package textmatch
+
+// automaton 以 rune 为单位的 Aho–Corasick 自动机
+type automaton struct {
+	nodes []acNode
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	out  []int // 以本节点结尾的模式编号
+	// link 沿失败链最近的有输出的节点，0 表示没有
+	link int32
+	// depth 节点深度，即匹配到的模式长度（rune 数）
+	depth int
+}
+
+// newAutomaton 构建自动机，patterns[i] 匹配时回调的编号为 i，空模式被忽略
+func newAutomaton(patterns []string) *automaton {
+	a := &automaton{nodes: []acNode{{}}}
+
+	for i, p := range patterns {
+		if p == "" {
+			continue
+		}
+		cur := int32(0)
+		for _, r := range p {
+			next, ok := a.nodes[cur].next[r]
+			if !ok {
+				next = int32(len(a.nodes))
+				a.nodes = append(a.nodes, acNode{depth: a.nodes[cur].depth + 1})
+				if a.nodes[cur].next == nil {
+					a.nodes[cur].next = make(map[rune]int32)
+				}
+				a.nodes[cur].next[r] = next
+			}
+			cur = next
+		}
+		a.nodes[cur].out = append(a.nodes[cur].out, i)
+	}
+
+	// 按广度优先计算失败指针与输出链
+	queue := make([]int32, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[cur].next {
+			f := a.nodes[cur].fail
+			for f != 0 {
+				if _, ok := a.nodes[f].next[r]; ok {
+					break
+				}
+				f = a.nodes[f].fail
+			}
+			if next, ok := a.nodes[f].next[r]; ok && next != child {
+				a.nodes[child].fail = next
+			}
+			fail := a.nodes[child].fail
+			if len(a.nodes[fail].out) > 0 {
+				a.nodes[child].link = fail
+			} else {
+				a.nodes[child].link = a.nodes[fail].link
+			}
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+// empty 没有任何模式
+func (a *automaton) empty() bool {
+	return len(a.nodes) == 1
+}
+
+// search 扫描 text，每次命中回调模式编号与命中区间 [start, end)（rune 下标），
+// 回调返回 true 时停止扫描
+func (a *automaton) search(text []rune, hit func(pattern, start, end int) bool) {
+	if a.empty() {
+		return
+	}
+
+	cur := int32(0)
+	for i, r := range text {
+		for cur != 0 {
+			if _, ok := a.nodes[cur].next[r]; ok {
+				break
+			}
+			cur = a.nodes[cur].fail
+		}
+		if next, ok := a.nodes[cur].next[r]; ok {
+			cur = next
+		}
+
+		for n := cur; n != 0; n = a.nodes[n].link {
+			for _, p := range a.nodes[n].out {
+				if hit(p, i+1-a.nodes[n].depth, i+1) {
+					return
+				}
+			}
+		}
+	}
+}
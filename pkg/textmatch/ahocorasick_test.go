@@ -0,0 +1,48 @@
+package textmatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAutomaton(t *testing.T) {
+	type hit struct{ pattern, start, end int }
+	tests := []struct {
+		name     string
+		patterns []string
+		text     string
+		want     []hit
+	}{
+		{"没有模式", nil, "abc", nil},
+		{"单个模式", []string{"ab"}, "xabx", []hit{{0, 1, 3}}},
+		{"重复命中", []string{"ab"}, "abab", []hit{{0, 0, 2}, {0, 2, 4}}},
+		{"重叠与后缀", []string{"he", "she", "his", "hers"}, "ushers", []hit{{1, 1, 4}, {0, 2, 4}, {3, 2, 6}}},
+		{"失败指针", []string{"abcd", "bc"}, "abce", []hit{{1, 1, 3}}},
+		{"空模式被忽略", []string{"", "a"}, "a", []hit{{1, 0, 1}}},
+		{"多字节字符", []string{"加群"}, "快来加群吧", []hit{{0, 2, 4}}},
+		{"未命中", []string{"abc"}, "ab", nil},
+	}
+	for _, tt := range tests {
+		a := newAutomaton(tt.patterns)
+		var got []hit
+		a.search([]rune(tt.text), func(p, start, end int) bool {
+			got = append(got, hit{p, start, end})
+			return false
+		})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: hits = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAutomatonStop(t *testing.T) {
+	a := newAutomaton([]string{"a"})
+	n := 0
+	a.search([]rune("aaaa"), func(int, int, int) bool {
+		n++
+		return true
+	})
+	if n != 1 {
+		t.Errorf("search continued after the callback returned true: %d hits", n)
+	}
+}
@@ -0,0 +1,251 @@
+package textmatch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Mode 匹配方式
+type Mode int
+
+const (
+	// ModeSubstring 子串：忽略中日韩文字旁的空白、标点与表情后包含即命中（默认），
+	// 拉丁字母与数字之间的分隔不忽略，视为一个空格
+	ModeSubstring Mode = iota
+	// ModeWord 整词：命中位置前后不能是字母或数字
+	ModeWord
+	// ModeRegex 正则表达式，在规范化后的文本中查找
+	ModeRegex
+	// ModeGlob 通配符：* 匹配任意字符，? 匹配单个字符，需匹配整条消息
+	ModeGlob
+)
+
+// modePrefixes 规则前缀与匹配方式，py: 为同时按拼音匹配的子串
+var modePrefixes = []struct {
+	prefix string
+	mode   Mode
+	pinyin bool
+}{
+	{"re:", ModeRegex, false},
+	{"glob:", ModeGlob, false},
+	{"word:", ModeWord, false},
+	{"py:", ModeSubstring, true},
+}
+
+// String 匹配方式名称
+func (m Mode) String() string {
+	switch m {
+	case ModeWord:
+		return "整词"
+	case ModeRegex:
+		return "正则"
+	case ModeGlob:
+		return "通配符"
+	default:
+		return "子串"
+	}
+}
+
+// Rule 一条匹配规则
+type Rule struct {
+	Raw     string // 原始写法，如 re:^加群.*
+	Mode    Mode
+	Pattern string // 去掉前缀后的模式
+	Pinyin  bool   // 子串规则是否同时按拼音匹配
+}
+
+// ParseRule 解析规则，按前缀确定匹配方式：
+//
+//	关键词        子串
+//	py:关键词     子串，同时按拼音匹配（可识别同音字与全拼）
+//	word:单词     整词
+//	re:表达式     正则
+//	glob:模式     通配符
+func ParseRule(raw string) (Rule, error) {
+	rule := Rule{Raw: raw, Pattern: raw}
+	lower := strings.ToLower(raw)
+	for _, p := range modePrefixes {
+		if strings.HasPrefix(lower, p.prefix) {
+			rule.Mode, rule.Pinyin = p.mode, p.pinyin
+			rule.Pattern = raw[len(p.prefix):]
+			break
+		}
+	}
+
+	if strings.TrimSpace(rule.Pattern) == "" {
+		return rule, fmt.Errorf("规则 %q 为空", raw)
+	}
+	switch rule.Mode {
+	case ModeRegex, ModeGlob:
+		if _, err := rule.compile(); err != nil {
+			return rule, err
+		}
+	default:
+		if Compact(rule.Pattern) == "" {
+			return rule, fmt.Errorf("规则 %q 规范化后没有字母或数字", raw)
+		}
+	}
+	return rule, nil
+}
+
+// String 返回原始写法
+func (r Rule) String() string {
+	return r.Raw
+}
+
+// compile 将正则与通配符规则编译为正则表达式
+func (r Rule) compile() (*regexp.Regexp, error) {
+	var expr string
+	switch r.Mode {
+	case ModeRegex:
+		expr = "(?i)" + r.Pattern
+	case ModeGlob:
+		var sb strings.Builder
+		sb.WriteString("(?s)^")
+		for _, c := range Fold(r.Pattern) {
+			switch c {
+			case '*':
+				sb.WriteString(".*")
+			case '?':
+				sb.WriteString(".")
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+			}
+		}
+		sb.WriteString("$")
+		expr = sb.String()
+	default:
+		return nil, fmt.Errorf("规则 %q 不是正则或通配符", r.Raw)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("规则 %q 无效: %w", r.Raw, err)
+	}
+	return re, nil
+}
+
+// -------------------- 匹配器 --------------------
+
+// Matcher 一组规则编译后的匹配器，创建后只读，可并发使用；规则变化时重新编译
+type Matcher struct {
+	rules []Rule
+
+	// 自动机中的模式编号即规则编号
+	substring *automaton // Squeeze 后文本上的子串规则
+	pinyin    *automaton // Squeeze 后文本拼音上的拼音规则
+	word      *automaton // 规范化文本上的整词规则
+
+	regexps []compiledRegexp
+}
+
+type compiledRegexp struct {
+	rule int
+	re   *regexp.Regexp
+}
+
+// Compile 编译规则，无效的规则被跳过，其错误合并返回
+func Compile(raws []string) (*Matcher, error) {
+	m := &Matcher{}
+	var errs []error
+	for _, raw := range raws {
+		rule, err := ParseRule(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.rules = append(m.rules, rule)
+	}
+
+	// 不属于某个自动机的规则留空，建树时被忽略
+	substring := make([]string, len(m.rules))
+	pinyins := make([]string, len(m.rules))
+	words := make([]string, len(m.rules))
+	for i, rule := range m.rules {
+		switch rule.Mode {
+		case ModeSubstring:
+			substring[i] = Squeeze(rule.Pattern)
+			if rule.Pinyin {
+				pinyins[i] = Pinyin(substring[i])
+			}
+		case ModeWord:
+			words[i] = collapseSpaces(Fold(rule.Pattern))
+		case ModeRegex, ModeGlob:
+			re, _ := rule.compile() // ParseRule 已检查
+			m.regexps = append(m.regexps, compiledRegexp{rule: i, re: re})
+		}
+	}
+
+	m.substring = newAutomaton(substring)
+	m.pinyin = newAutomaton(pinyins)
+	m.word = newAutomaton(words)
+	return m, errors.Join(errs...)
+}
+
+// Len 有效规则数
+func (m *Matcher) Len() int {
+	return len(m.rules)
+}
+
+// Match 返回 text 命中的第一条规则
+func (m *Matcher) Match(text string) (Rule, bool) {
+	if m == nil || len(m.rules) == 0 || text == "" {
+		return Rule{}, false
+	}
+
+	folded := Fold(text)
+	squeezed := squeeze(folded)
+
+	if i, ok := first(m.substring, []rune(squeezed), nil); ok {
+		return m.rules[i], true
+	}
+	if !m.pinyin.empty() {
+		if i, ok := first(m.pinyin, []rune(Pinyin(squeezed)), nil); ok {
+			return m.rules[i], true
+		}
+	}
+	if !m.word.empty() {
+		if i, ok := first(m.word, []rune(collapseSpaces(folded)), wordBoundary); ok {
+			return m.rules[i], true
+		}
+	}
+	for _, cr := range m.regexps {
+		if cr.re.MatchString(folded) {
+			return m.rules[cr.rule], true
+		}
+	}
+	return Rule{}, false
+}
+
+// first 返回自动机在 text 上第一个满足 accept 的命中的模式编号，accept 为空时接受任意命中
+func first(a *automaton, text []rune, accept func(text []rune, start, end int) bool) (int, bool) {
+	pattern, found := -1, false
+	a.search(text, func(p, start, end int) bool {
+		if accept != nil && !accept(text, start, end) {
+			return false
+		}
+		pattern, found = p, true
+		return true
+	})
+	return pattern, found
+}
+
+// wordBoundary 命中区间前后都不是字母或数字
+func wordBoundary(text []rune, start, end int) bool {
+	isWord := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	if start > 0 && isWord(text[start-1]) {
+		return false
+	}
+	if end < len(text) && isWord(text[end]) {
+		return false
+	}
+	return true
+}
+
+// collapseSpaces 将连续空白合并为一个空格
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
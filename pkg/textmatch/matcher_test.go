@@ -0,0 +1,100 @@
+package textmatch
+
+import "testing"
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		raw     string
+		mode    Mode
+		pattern string
+		pinyin  bool
+		wantErr bool
+	}{
+		{"加群", ModeSubstring, "加群", false, false},
+		{"py:法轮", ModeSubstring, "法轮", true, false},
+		{"PY:法轮", ModeSubstring, "法轮", true, false},
+		{"word:spam", ModeWord, "spam", false, false},
+		{"re:^加群.*", ModeRegex, "^加群.*", false, false},
+		{"glob:*.apk", ModeGlob, "*.apk", false, false},
+		{"re:(", ModeRegex, "(", false, true},
+		{"word:", ModeWord, "", false, true},
+		{"!!!", ModeSubstring, "!!!", false, true},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRule(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRule(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if rule.Mode != tt.mode || rule.Pattern != tt.pattern || rule.Pinyin != tt.pinyin {
+			t.Errorf("ParseRule(%q) = %+v, want mode %v pattern %q pinyin %v", tt.raw, rule, tt.mode, tt.pattern, tt.pinyin)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		text  string
+		want  string // 命中的规则，空表示不命中
+	}{
+		// 子串
+		{"子串", []string{"加群"}, "快来加群", "加群"},
+		{"子串忽略汉字间的分隔", []string{"加群"}, "加 . 群", "加群"},
+		{"子串忽略汉字与字母间的分隔", []string{"加v信"}, "加 V 信", "加v信"},
+		{"子串繁体与全角", []string{"发财"}, "發　財", "发财"},
+		{"子串拉丁字母", []string{"sb"}, "you are SB", "sb"},
+		{"子串不跨越单词", []string{"sb"}, "this bus", ""},
+		{"子串不跨越标点", []string{"ass"}, "a. ss", ""},
+		{"子串多词规则", []string{"free money"}, "FREE,  money!", "free money"},
+		{"子串形近字母", []string{"spam"}, "ѕраm", "spam"},
+
+		// 拼音
+		{"拼音同音字", []string{"py:傻逼"}, "沙 比", "py:傻逼"},
+		{"拼音全拼", []string{"py:加群"}, "jiaqun", "py:加群"},
+		{"没有 py: 不按拼音", []string{"加群"}, "jiaqun", ""},
+
+		// 整词
+		{"整词", []string{"word:spam"}, "no spam here", "word:spam"},
+		{"整词不命中单词内部", []string{"word:spam"}, "spammer", ""},
+		{"整词多词", []string{"word:buy now"}, "BUY   NOW!", "word:buy now"},
+
+		// 正则
+		{"正则", []string{`re:^加群\d+`}, "加群123", `re:^加群\d+`},
+		{"正则忽略大小写", []string{"re:t\\.me/"}, "T.ME/xxx", "re:t\\.me/"},
+		{"正则不命中", []string{`re:^加群\d+`}, "请加群", ""},
+
+		// 通配符
+		{"通配符", []string{"glob:*.apk"}, "setup.APK", "glob:*.apk"},
+		{"通配符问号", []string{"glob:a?c"}, "abc", "glob:a?c"},
+		{"通配符需匹配整条消息", []string{"glob:a?c"}, "abcd", ""},
+
+		// 多条规则与无效规则
+		{"返回第一条命中", []string{"foo", "加群"}, "加群", "加群"},
+		{"跳过无效规则", []string{"re:(", "加群"}, "加群", "加群"},
+		{"空消息", []string{"加群"}, "", ""},
+	}
+	for _, tt := range tests {
+		m, _ := Compile(tt.rules)
+		rule, ok := m.Match(tt.text)
+		if got := map[bool]string{true: rule.Raw}[ok]; got != tt.want {
+			t.Errorf("%s: Match(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	m, err := Compile([]string{"re:(", "加群", "!!!"})
+	if err == nil {
+		t.Error("Compile should report invalid rules")
+	}
+	if m.Len() != 1 {
+		t.Errorf("Len = %d, want 1", m.Len())
+	}
+
+	var nilMatcher *Matcher
+	if _, ok := nilMatcher.Match("加群"); ok {
+		t.Error("nil matcher should not match")
+	}
+}
@@ -0,0 +1,165 @@
+// Package textmatch 文本规范化与关键词匹配，用于屏蔽词等内容过滤。
+//
+// 匹配前先对文本做规范化，抵抗常见的绕过手段：
+//   - NFKC 兼容分解（全角转半角、数学字母与带圈字母转普通字母等）
+//   - 去除零宽字符、格式控制字符与组合附加符号
+//   - 大小写折叠与常见形近字母（西里尔、希腊字母）折叠
+//   - 繁体转简体（字符级）
+//   - 子串匹配忽略中日韩文字旁插入的空白、标点与表情
+//   - 可选转换为无声调拼音
+//
+// 子串与整词规则编译为 Aho–Corasick 自动机，规则数量较多时仍能一次扫描完成匹配。
+package textmatch
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mozillazg/go-pinyin"
+	"golang.org/x/text/unicode/norm"
+)
+
+// tsCharacters 繁体到简体的字符对照表，来自 OpenCC（Apache License 2.0）
+//
+//go:embed tscharacters.txt
+var tsCharacters string
+
+var (
+	tsOnce sync.Once
+	tsMap  map[rune]rune
+)
+
+// traditionalToSimplified 返回繁简对照表，首次使用时解析
+func traditionalToSimplified() map[rune]rune {
+	tsOnce.Do(func() {
+		tsMap = make(map[rune]rune, 4096)
+		scanner := bufio.NewScanner(strings.NewReader(tsCharacters))
+		for scanner.Scan() {
+			from, to, ok := strings.Cut(scanner.Text(), "\t")
+			if !ok || utf8.RuneCountInString(from) != 1 {
+				continue
+			}
+			// 一个繁体字对应多个简体字时取第一个
+			to, _, _ = strings.Cut(to, " ")
+			if utf8.RuneCountInString(to) != 1 {
+				continue
+			}
+			f, _ := utf8.DecodeRuneInString(from)
+			t, _ := utf8.DecodeRuneInString(to)
+			tsMap[f] = t
+		}
+	})
+	return tsMap
+}
+
+// homoglyphs 与拉丁字母形近的常见字母（小写），NFKC 不会处理这些字符
+var homoglyphs = map[rune]rune{
+	// 西里尔字母
+	'а': 'a', 'в': 'b', 'с': 'c', 'е': 'e', 'ё': 'e', 'һ': 'h', 'н': 'h', 'і': 'i', 'ј': 'j',
+	'к': 'k', 'м': 'm', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'т': 't', 'у': 'y', 'х': 'x',
+	'ԝ': 'w', 'ӏ': 'l',
+	// 希腊字母
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+}
+
+// invisible 规范化时去除的字符：格式控制字符（零宽空格、零宽连接符、BOM 等）、
+// 组合附加符号与变体选择符
+func invisible(r rune) bool {
+	return unicode.In(r, unicode.Cf, unicode.Mn, unicode.Me, unicode.Variation_Selector)
+}
+
+// Fold 规范化文本：NFKC、去除不可见字符、小写、形近字母与繁体折叠，保留空白与标点
+func Fold(s string) string {
+	s = norm.NFKC.String(s)
+	ts := traditionalToSimplified()
+
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for _, r := range s {
+		if invisible(r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if h, ok := homoglyphs[r]; ok {
+			r = h
+		} else if t, ok := ts[r]; ok {
+			r = t
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// Compact 规范化文本并只保留字母与数字，用于抵抗插入空格、标点或表情的绕过
+func Compact(s string) string {
+	return compact(Fold(s))
+}
+
+// compact 只保留已规范化文本中的字母与数字
+func compact(folded string) string {
+	var sb strings.Builder
+	sb.Grow(len(folded))
+	for _, r := range folded {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// cjk 汉字、假名与谚文，这些文字不以空格分词，插入的分隔符可以安全忽略
+func cjk(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Squeeze 规范化文本并去除中日韩文字旁的空白、标点与表情，用于子串匹配：
+// 两侧至少一侧为中日韩文字的分隔被去除，拉丁字母与数字之间的分隔保留为一个空格，
+// 以免 "sb" 这样的规则跨越单词边界命中
+func Squeeze(s string) string {
+	return squeeze(Fold(s))
+}
+
+// squeeze 见 Squeeze，输入为已规范化文本
+func squeeze(folded string) string {
+	var sb strings.Builder
+	sb.Grow(len(folded))
+	var last rune // 上一个写入的字母或数字，0 表示还没有
+	gap := false
+	for _, r := range folded {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			gap = last != 0
+			continue
+		}
+		if gap && !cjk(last) && !cjk(r) {
+			sb.WriteByte(' ')
+		}
+		gap = false
+		last = r
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// pinyinArgs 无声调、不区分多音字
+var pinyinArgs = pinyin.NewArgs()
+
+// Pinyin 将已规范化文本中的汉字转换为无声调拼音（多音字取第一个读音），其余字符保留
+func Pinyin(folded string) string {
+	var sb strings.Builder
+	sb.Grow(len(folded) * 2)
+	for _, r := range folded {
+		if unicode.Is(unicode.Han, r) {
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 {
+				sb.WriteString(py[0])
+				continue
+			}
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,78 @@
+package textmatch
+
+import "testing"
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"大小写", "HeLLo", "hello"},
+		{"全角", "ＡＢＣ１２３", "abc123"},
+		{"数学字母", "𝐟𝐫𝐞𝐞", "free"},
+		{"带圈字母", "ⓐⓑ", "ab"},
+		{"零宽字符", "加​群", "加群"},
+		{"组合附加符号", "café", "café"},
+		{"西里尔形近字母", "рорn", "popn"},
+		{"希腊形近字母", "ΑΒΟ", "abo"},
+		{"繁体", "加群發財", "加群发财"},
+		{"保留空白与标点", "a b, c!", "a b, c!"},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("%s: Fold(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSqueeze(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"汉字之间的空白", "加 群", "加群"},
+		{"汉字之间的标点与表情", "加.😀.群", "加群"},
+		{"汉字与字母之间", "加 V 信", "加v信"},
+		{"假名", "あ い", "あい"},
+		{"谚文", "한 국", "한국"},
+		{"字母之间保留一个空格", "this   bus", "this bus"},
+		{"字母之间的标点视为空格", "a. ss", "a ss"},
+		{"数字之间", "1-2-3", "1 2 3"},
+		{"首尾分隔", "  !hi!  ", "hi"},
+		{"只有分隔", " .. ", ""},
+	}
+	for _, tt := range tests {
+		if got := Squeeze(tt.in); got != tt.want {
+			t.Errorf("%s: Squeeze(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompact(t *testing.T) {
+	tests := map[string]string{
+		"a. ss":  "ass",
+		"加 群!!":  "加群",
+		"😀":      "",
+		"Ｆｒｅｅ 1": "free1",
+	}
+	for in, want := range tests {
+		if got := Compact(in); got != want {
+			t.Errorf("Compact(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPinyin(t *testing.T) {
+	tests := map[string]string{
+		"加群":    "jiaqun",
+		"傻b":    "shab",
+		"hello": "hello",
+	}
+	for in, want := range tests {
+		if got := Pinyin(in); got != want {
+			t.Errorf("Pinyin(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
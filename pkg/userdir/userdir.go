@@ -0,0 +1,405 @@
+// Package userdir 本地用户目录：记录 Bot 见过的用户 ID、用户名与显示名称。
+//
+// Bot API 无法通过 @username 查询用户，目录从收到的每个更新中收集用户信息
+// （发送者、回复的作者、转发来源、新成员、提到的用户、回调与成员变更等），
+// 使插件可以把 @username 解析为用户 ID。用户名或名称变化时保留变更历史。
+package userdir
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/internal/core/log"
+	"yueling_tg/internal/middleware"
+	"yueling_tg/pkg/config"
+
+	"github.com/mymmrac/telego"
+)
+
+var logger = log.NewSystem("用户目录")
+
+const (
+	// maxHistory 每个用户最多保留的变更历史条数
+	maxHistory = 20
+	// saveDelay 数据变化后延迟写入，合并短时间内的多次变化
+	saveDelay = 5 * time.Second
+	// seenInterval LastSeen 变化超过该间隔时才视为需要保存
+	seenInterval = time.Hour
+)
+
+// User 目录中的用户
+type User struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username,omitempty"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name,omitempty"`
+	IsBot     bool      `json:"is_bot,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	History   []Change  `json:"history,omitempty"` // 最早的在前
+}
+
+// Change 一次用户名或名称变更，记录变更前的值
+type Change struct {
+	Time      time.Time `json:"time"`
+	Username  string    `json:"username,omitempty"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name,omitempty"`
+}
+
+// Name 显示名称
+func (u User) Name() string {
+	if u.LastName != "" {
+		return u.FirstName + " " + u.LastName
+	}
+	return u.FirstName
+}
+
+// Mention 有用户名时为 @username，否则为显示名称
+func (u User) Mention() string {
+	if u.Username != "" {
+		return "@" + u.Username
+	}
+	return u.Name()
+}
+
+// TelegoUser 转换为 telego.User
+func (u User) TelegoUser() *telego.User {
+	return &telego.User{
+		ID:        u.ID,
+		IsBot:     u.IsBot,
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Username:  u.Username,
+	}
+}
+
+// -------------------- 目录 --------------------
+
+// Directory 用户目录，并发安全
+type Directory struct {
+	path string
+
+	mu         sync.RWMutex
+	users      map[int64]*User
+	byUsername map[string]int64 // 小写用户名 -> 用户 ID
+	saving     *time.Timer
+
+	fileMu sync.Mutex // 串行化文件写入
+}
+
+// New 创建保存在 path 的目录，需调用 Load 读取已有数据；path 为空时只保存在内存中
+func New(path string) *Directory {
+	return &Directory{
+		path:       path,
+		users:      make(map[int64]*User),
+		byUsername: make(map[string]int64),
+	}
+}
+
+var (
+	dirsMu sync.Mutex
+	dirs   = make(map[*config.ConfigManager]*Directory)
+)
+
+// For 返回 cm 对应 Bot 的目录（<数据目录>/userdir/users.json），首次调用时创建并加载
+func For(cm *config.ConfigManager) *Directory {
+	dirsMu.Lock()
+	defer dirsMu.Unlock()
+	if d, ok := dirs[cm]; ok {
+		return d
+	}
+
+	d := New(filepath.Join(cm.DataDir(), "userdir", "users.json"))
+	if err := d.Load(); err != nil {
+		logger.Warn().Err(err).Msg("加载用户目录失败，使用空目录")
+	}
+	dirs[cm] = d
+	return d
+}
+
+// Default 返回当前 Bot（当前配置管理器）的目录，应在插件构造函数中调用
+func Default() *Directory {
+	return For(config.GetManager())
+}
+
+// Path 返回数据文件路径
+func (d *Directory) Path() string {
+	return d.path
+}
+
+// Len 返回用户数
+func (d *Directory) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.users)
+}
+
+// Get 按 ID 查询用户
+func (d *Directory) Get(id int64) (User, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if u, ok := d.users[id]; ok {
+		return clone(u), true
+	}
+	return User{}, false
+}
+
+// Lookup 按用户名查询用户，忽略大小写与开头的 @
+func (d *Directory) Lookup(username string) (User, bool) {
+	key := normalize(username)
+	if key == "" {
+		return User{}, false
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if id, ok := d.byUsername[key]; ok {
+		return clone(d.users[id]), true
+	}
+	return User{}, false
+}
+
+// Observe 记录一个用户，新用户或用户名、名称变化时安排保存
+func (d *Directory) Observe(u *telego.User) {
+	if u == nil || u.ID == 0 {
+		return
+	}
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	existing, ok := d.users[u.ID]
+	if !ok {
+		d.users[u.ID] = &User{
+			ID:        u.ID,
+			Username:  u.Username,
+			FirstName: u.FirstName,
+			LastName:  u.LastName,
+			IsBot:     u.IsBot,
+			FirstSeen: now,
+			LastSeen:  now,
+		}
+		d.index(u.ID, "", u.Username)
+		d.scheduleSave()
+		return
+	}
+
+	changed := existing.Username != u.Username || existing.FirstName != u.FirstName || existing.LastName != u.LastName
+	if changed {
+		existing.History = append(existing.History, Change{
+			Time:      now,
+			Username:  existing.Username,
+			FirstName: existing.FirstName,
+			LastName:  existing.LastName,
+		})
+		if len(existing.History) > maxHistory {
+			existing.History = existing.History[len(existing.History)-maxHistory:]
+		}
+		d.index(u.ID, existing.Username, u.Username)
+		existing.Username, existing.FirstName, existing.LastName = u.Username, u.FirstName, u.LastName
+	}
+
+	stale := now.Sub(existing.LastSeen) > seenInterval
+	existing.LastSeen = now
+	if changed || stale {
+		d.scheduleSave()
+	}
+}
+
+// index 更新用户名索引（调用方需持有锁）。用户名可能被其他用户接手，以最近见到的为准
+func (d *Directory) index(id int64, old, username string) {
+	if key := normalize(old); key != "" && d.byUsername[key] == id {
+		delete(d.byUsername, key)
+	}
+	if key := normalize(username); key != "" {
+		d.byUsername[key] = id
+	}
+}
+
+// ObserveUpdate 记录更新中出现的全部用户
+func (d *Directory) ObserveUpdate(update telego.Update) {
+	for _, msg := range []*telego.Message{
+		update.Message, update.EditedMessage, update.ChannelPost, update.EditedChannelPost,
+		update.BusinessMessage, update.EditedBusinessMessage,
+	} {
+		d.observeMessage(msg)
+	}
+
+	if q := update.CallbackQuery; q != nil {
+		d.Observe(&q.From)
+	}
+	if q := update.InlineQuery; q != nil {
+		d.Observe(&q.From)
+	}
+	if r := update.ChosenInlineResult; r != nil {
+		d.Observe(&r.From)
+	}
+	if r := update.MessageReaction; r != nil {
+		d.Observe(r.User)
+	}
+	if a := update.PollAnswer; a != nil {
+		d.Observe(a.User)
+	}
+	if r := update.ChatJoinRequest; r != nil {
+		d.Observe(&r.From)
+	}
+	for _, m := range []*telego.ChatMemberUpdated{update.ChatMember, update.MyChatMember} {
+		if m == nil {
+			continue
+		}
+		d.Observe(&m.From)
+		if m.NewChatMember != nil {
+			member := m.NewChatMember.MemberUser()
+			d.Observe(&member)
+		}
+	}
+}
+
+// observeMessage 记录消息的发送者、回复的作者、转发来源、新成员与提到的用户
+func (d *Directory) observeMessage(msg *telego.Message) {
+	if msg == nil {
+		return
+	}
+
+	d.Observe(msg.From)
+	d.Observe(msg.ViaBot)
+	d.Observe(msg.LeftChatMember)
+	for i := range msg.NewChatMembers {
+		d.Observe(&msg.NewChatMembers[i])
+	}
+	if origin, ok := msg.ForwardOrigin.(*telego.MessageOriginUser); ok {
+		d.Observe(&origin.SenderUser)
+	}
+	for _, entities := range [][]telego.MessageEntity{msg.Entities, msg.CaptionEntities} {
+		for _, e := range entities {
+			d.Observe(e.User)
+		}
+	}
+	if reply := msg.ReplyToMessage; reply != nil {
+		d.Observe(reply.From)
+		if origin, ok := reply.ForwardOrigin.(*telego.MessageOriginUser); ok {
+			d.Observe(&origin.SenderUser)
+		}
+	}
+}
+
+// Middleware 返回更新级中间件，在匹配之前记录更新中的用户
+func (d *Directory) Middleware() middleware.Middleware {
+	return middleware.MiddlewareFunc("用户目录中间件", func(ctx *context.Context, next middleware.HandlerFunc) error {
+		d.ObserveUpdate(ctx.Update)
+		return next(ctx)
+	})
+}
+
+// -------------------- 持久化 --------------------
+
+// Load 从文件读取目录，文件不存在时为空
+func (d *Directory) Load() error {
+	if d.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var users []*User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return fmt.Errorf("解析用户目录失败: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.users = make(map[int64]*User, len(users))
+	d.byUsername = make(map[string]int64, len(users))
+
+	// 同一用户名出现在多个用户上时，以最近见到的为准
+	for _, u := range users {
+		d.users[u.ID] = u
+		key := normalize(u.Username)
+		if key == "" {
+			continue
+		}
+		if id, ok := d.byUsername[key]; ok && d.users[id].LastSeen.After(u.LastSeen) {
+			continue
+		}
+		d.byUsername[key] = u.ID
+	}
+	return nil
+}
+
+// scheduleSave 延迟保存（调用方需持有锁）
+func (d *Directory) scheduleSave() {
+	if d.path == "" || d.saving != nil {
+		return
+	}
+	d.saving = time.AfterFunc(saveDelay, func() {
+		if err := d.Save(); err != nil {
+			logger.Error().Err(err).Msg("保存用户目录失败")
+		}
+	})
+}
+
+// Save 立即写入文件
+func (d *Directory) Save() error {
+	if d.path == "" {
+		return nil
+	}
+
+	d.mu.Lock()
+	if d.saving != nil {
+		d.saving.Stop()
+		d.saving = nil
+	}
+	users := make([]*User, 0, len(d.users))
+	for _, u := range d.users {
+		users = append(users, u)
+	}
+	data, err := json.MarshalIndent(users, "", "  ")
+	d.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("序列化数据失败: %w", err)
+	}
+
+	d.fileMu.Lock()
+	defer d.fileMu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	// 使用临时文件 + 原子重命名
+	tmpFile := d.path + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Rename(tmpFile, d.path); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("重命名文件失败: %w", err)
+	}
+	return nil
+}
+
+// normalize 用户名索引键：去掉 @ 并转为小写
+func normalize(username string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(username), "@"))
+}
+
+// clone 复制用户，避免调用方修改目录中的数据
+func clone(u *User) User {
+	c := *u
+	c.History = append([]Change(nil), u.History...)
+	return c
+}
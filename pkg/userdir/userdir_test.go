@@ -0,0 +1,165 @@
+package userdir
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mymmrac/telego"
+)
+
+func TestLookup(t *testing.T) {
+	d := New("")
+	d.Observe(&telego.User{ID: 1, FirstName: "Alice", Username: "Alice_01"})
+	d.Observe(&telego.User{ID: 2, FirstName: "Bob"})
+	d.Observe(nil)
+	d.Observe(&telego.User{FirstName: "没有 ID"})
+
+	tests := []struct {
+		name   string
+		query  string
+		wantID int64
+		wantOK bool
+	}{
+		{"原样", "Alice_01", 1, true},
+		{"忽略大小写", "alice_01", 1, true},
+		{"带 @", "@ALICE_01", 1, true},
+		{"前后空白", " @alice_01 ", 1, true},
+		{"不存在", "carol", 0, false},
+		{"空", "@", 0, false},
+	}
+	for _, tt := range tests {
+		u, ok := d.Lookup(tt.query)
+		if ok != tt.wantOK || u.ID != tt.wantID {
+			t.Errorf("%s: Lookup(%q) = (%d, %v), want (%d, %v)", tt.name, tt.query, u.ID, ok, tt.wantID, tt.wantOK)
+		}
+	}
+	if n := d.Len(); n != 2 {
+		t.Errorf("Len = %d, want 2", n)
+	}
+}
+
+func TestObserveChanges(t *testing.T) {
+	d := New("")
+	d.Observe(&telego.User{ID: 1, FirstName: "Alice", Username: "alice"})
+	d.Observe(&telego.User{ID: 1, FirstName: "Alice", Username: "alice"})
+	d.Observe(&telego.User{ID: 1, FirstName: "Alice", LastName: "L", Username: "alice2"})
+
+	u, _ := d.Get(1)
+	if u.Name() != "Alice L" || u.Mention() != "@alice2" {
+		t.Errorf("user = %q / %q, want Alice L / @alice2", u.Name(), u.Mention())
+	}
+	if len(u.History) != 1 || u.History[0].Username != "alice" || u.History[0].LastName != "" {
+		t.Errorf("history = %+v, want one change from alice", u.History)
+	}
+	if _, ok := d.Lookup("alice"); ok {
+		t.Error("old username should no longer resolve")
+	}
+
+	// 用户名被其他用户接手时以最近见到的为准，原用户改名不影响新用户
+	d.Observe(&telego.User{ID: 2, FirstName: "Bob", Username: "alice2"})
+	if u, _ := d.Lookup("alice2"); u.ID != 2 {
+		t.Errorf("alice2 -> %d, want 2", u.ID)
+	}
+	d.Observe(&telego.User{ID: 1, FirstName: "Alice", LastName: "L", Username: "alice3"})
+	if u, _ := d.Lookup("alice2"); u.ID != 2 {
+		t.Errorf("alice2 after rename of user 1 -> %d, want 2", u.ID)
+	}
+
+	// 历史最多保留 maxHistory 条，返回的是副本
+	for i := range maxHistory + 5 {
+		d.Observe(&telego.User{ID: 3, FirstName: string(rune('a' + i))})
+	}
+	u, _ = d.Get(3)
+	if len(u.History) != maxHistory {
+		t.Errorf("history length = %d, want %d", len(u.History), maxHistory)
+	}
+	u.History[0].FirstName = "changed"
+	if again, _ := d.Get(3); again.History[0].FirstName == "changed" {
+		t.Error("Get should return a copy")
+	}
+}
+
+func TestObserveUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		update telego.Update
+		want   []int64
+	}{
+		{"消息中的用户", telego.Update{Message: &telego.Message{
+			From:           &telego.User{ID: 1},
+			ViaBot:         &telego.User{ID: 2},
+			NewChatMembers: []telego.User{{ID: 3}, {ID: 4}},
+			ForwardOrigin:  &telego.MessageOriginUser{Type: telego.OriginTypeUser, SenderUser: telego.User{ID: 5}},
+			Entities:       []telego.MessageEntity{{Type: telego.EntityTypeTextMention, User: &telego.User{ID: 6}}},
+			ReplyToMessage: &telego.Message{From: &telego.User{ID: 7}},
+		}}, []int64{1, 2, 3, 4, 5, 6, 7}},
+		{"编辑的消息", telego.Update{EditedMessage: &telego.Message{From: &telego.User{ID: 1}}}, []int64{1}},
+		{"回调", telego.Update{CallbackQuery: &telego.CallbackQuery{From: telego.User{ID: 1}}}, []int64{1}},
+		{"成员变更", telego.Update{ChatMember: &telego.ChatMemberUpdated{
+			From:          telego.User{ID: 1},
+			NewChatMember: &telego.ChatMemberMember{Status: telego.MemberStatusMember, User: telego.User{ID: 2}},
+		}}, []int64{1, 2}},
+		{"入群申请", telego.Update{ChatJoinRequest: &telego.ChatJoinRequest{From: telego.User{ID: 1}}}, []int64{1}},
+	}
+	for _, tt := range tests {
+		d := New("")
+		d.ObserveUpdate(tt.update)
+		if d.Len() != len(tt.want) {
+			t.Errorf("%s: Len = %d, want %d", tt.name, d.Len(), len(tt.want))
+		}
+		for _, id := range tt.want {
+			if _, ok := d.Get(id); !ok {
+				t.Errorf("%s: user %d not observed", tt.name, id)
+			}
+		}
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "userdir", "users.json")
+	d := New(path)
+	d.Observe(&telego.User{ID: 1, FirstName: "Alice", Username: "alice"})
+	d.Observe(&telego.User{ID: 1, FirstName: "Alice", Username: "alice_new"})
+	d.Observe(&telego.User{ID: 2, FirstName: "Bob", Username: "bob"})
+	if err := d.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := New(path)
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", loaded.Len())
+	}
+	if u, ok := loaded.Lookup("alice_new"); !ok || u.ID != 1 || len(u.History) != 1 {
+		t.Errorf("alice_new = %+v, %v", u, ok)
+	}
+
+	// 文件中同一用户名出现在多个用户上时，以最近见到的为准
+	data := `[
+		{"id": 1, "username": "dup", "first_name": "A", "last_seen": "2024-01-02T00:00:00Z"},
+		{"id": 2, "username": "dup", "first_name": "B", "last_seen": "2024-01-01T00:00:00Z"}
+	]`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if u, _ := loaded.Lookup("dup"); u.ID != 1 {
+		t.Errorf("dup -> %d, want 1", u.ID)
+	}
+
+	// 文件不存在时为空目录，内容无效时报错
+	if err := New(filepath.Join(t.TempDir(), "missing.json")).Load(); err != nil {
+		t.Errorf("Load missing file: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := New(path).Load(); err == nil {
+		t.Error("Load invalid file should fail")
+	}
+}
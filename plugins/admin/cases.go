@@ -0,0 +1,110 @@
+package admin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/moderation"
+	"yueling_tg/pkg/paginator"
+	"yueling_tg/pkg/plugin/params"
+)
+
+// -------------------- 处罚记录 --------------------
+
+// 查看用户在本群的处罚记录
+func (ap *AdminPlugin) handleHistory(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, _, ok := ap.moderationTarget(c, "查看处罚记录", false)
+	if !ok {
+		return
+	}
+
+	cases := ap.cases.History(c.GetChatID().ID, targetUser.ID)
+	if len(cases) == 0 {
+		c.Replyf("📋 %s 在本群没有处罚记录", context.FullName(targetUser))
+		return
+	}
+
+	name := context.FullName(targetUser)
+	paginator.New(paginator.FromSlice(cases), func(page paginator.Page[moderation.Case]) string {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "📋 %s 的处罚记录 (共 %d 条)：\n\n", name, page.Count)
+		for _, cs := range page.Items {
+			sb.WriteString(cs.Summary())
+			sb.WriteString("\n")
+		}
+		return sb.String()
+	}).PageSize(10).Reply(c)
+}
+
+// reverse 可撤销的操作及其反向操作
+var reverse = map[moderation.Action]moderation.Action{
+	moderation.ActionMute:    moderation.ActionUnmute,
+	moderation.ActionBan:     moderation.ActionUnban,
+	moderation.ActionPromote: moderation.ActionDemote,
+	moderation.ActionDemote:  moderation.ActionPromote,
+	moderation.ActionWarn:    moderation.ActionUnwarn,
+}
+
+// 按编号撤销处罚：执行反向操作并记录
+func (ap *AdminPlugin) handleRevert(c *context.Context, cmdCtx params.CommandContext) {
+	if !c.IsGroup() && !c.IsSuperGroup() {
+		c.Reply("❌ 此命令仅在群组中可用")
+		return
+	}
+
+	id, err := strconv.Atoi(strings.TrimPrefix(cmdCtx.Args.Get(0), "#"))
+	if err != nil {
+		c.Reply("❌ 用法：撤销处罚 <编号> [理由]")
+		return
+	}
+
+	cs, ok := ap.cases.Get(id)
+	if !ok || cs.ChatID != c.GetChatID().ID {
+		c.Replyf("❌ 本群没有编号为 #%d 的处罚记录", id)
+		return
+	}
+	if cs.Reverted() {
+		c.Replyf("ℹ️ 记录 #%d 已被 #%d 撤销", id, cs.RevertedBy)
+		return
+	}
+	undo, ok := reverse[cs.Action]
+	if !ok {
+		c.Replyf("❌ %s 操作无法撤销", cs.Action)
+		return
+	}
+
+	switch undo {
+	case moderation.ActionUnmute:
+		err = moderation.Unmute(c, cs.TargetID)
+	case moderation.ActionUnban:
+		err = moderation.Unban(c, cs.TargetID)
+	case moderation.ActionDemote:
+		err = setAdmin(c, cs.TargetID, false)
+	case moderation.ActionPromote:
+		err = setAdmin(c, cs.TargetID, true)
+	case moderation.ActionUnwarn:
+		// 警告只是记录，撤销后不再计入次数
+	}
+	if err != nil {
+		ap.Logger(c).Error().Err(err).Int("case", id).Msg("撤销处罚失败")
+		c.Replyf("❌ 撤销失败（%s），请确保机器人有足够的权限", undo)
+		return
+	}
+
+	revert := ap.record(c, moderation.Case{
+		Action:     undo,
+		TargetID:   cs.TargetID,
+		TargetName: cs.TargetName,
+		Reason:     cmdCtx.Args[1:].Join(" "),
+		Reverts:    cs.ID,
+	})
+	if revert.ID != 0 {
+		if err := ap.cases.MarkReverted(cs.ID, revert.ID); err != nil {
+			ap.Logger(c).Error().Err(err).Msg("保存处罚记录失败")
+		}
+	}
+
+	c.Replyf("✅ 已撤销记录 #%d：%s %s%s", cs.ID, undo, cs.TargetName, caseSuffix(revert))
+}
@@ -0,0 +1,218 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/moderation"
+	"yueling_tg/pkg/plugin/params"
+
+	"github.com/mymmrac/telego"
+)
+
+// -------------------- 禁言 / 封禁 / 踢出 --------------------
+
+// moderationTarget 群组检查与目标解析的公共部分，失败时已回复提示
+func (ap *AdminPlugin) moderationTarget(c *context.Context, action string, protect bool) (*telego.User, []string, bool) {
+	if !c.IsGroup() && !c.IsSuperGroup() {
+		c.Reply("❌ 此命令仅在群组中可用")
+		return nil, nil, false
+	}
+
+	msg := c.GetMessage()
+	if msg == nil {
+		return nil, nil, false
+	}
+
+	targetUser, args, unknown := resolveTarget(c, msg, ap.users)
+	if unknown != "" {
+		c.Replyf("❌ 找不到用户 %s：Bot 只能识别见过的用户（发言、入群等），请改为回复其消息或填写用户 ID", unknown)
+		return nil, nil, false
+	}
+	if targetUser == nil {
+		c.Replyf("❌ 请回复要%s的用户消息，或 @用户 / 填写用户 ID", action)
+		return nil, nil, false
+	}
+
+	if protect {
+		if reason := protectedReason(c, targetUser); reason != "" {
+			c.Reply(reason)
+			return nil, nil, false
+		}
+	}
+	return targetUser, args, true
+}
+
+// record 写入日志与处罚记录，保存失败时只记录日志（操作本身已经生效）
+func (ap *AdminPlugin) record(c *context.Context, cs moderation.Case) moderation.Case {
+	ev := ap.Logger(c).Info().
+		Int64("user_id", cs.TargetID).
+		Str("target", cs.TargetName).
+		Int64("operator_id", c.GetUserID()).
+		Str("operator", c.GetFullName()).
+		Str("reason", cs.Reason)
+	if cs.Duration != 0 {
+		ev = ev.Str("duration", common.FormatDuration(cs.Duration))
+	}
+	ev.Msg(cs.Action.String())
+
+	cs, err := ap.cases.Record(c, cs)
+	if err != nil {
+		ap.Logger(c).Error().Err(err).Msg("保存处罚记录失败")
+	}
+	return cs
+}
+
+// caseSuffix 回复中附带的记录编号，未保存时为空
+func caseSuffix(cs moderation.Case) string {
+	if cs.ID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n📋 记录 #%d", cs.ID)
+}
+
+// 禁言用户
+func (ap *AdminPlugin) handleMute(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, args, ok := ap.moderationTarget(c, "禁言", true)
+	if !ok {
+		return
+	}
+
+	d, reason, err := parseDurationArgs(args, common.Forever)
+	if err != nil {
+		c.Replyf("❌ %v", err)
+		return
+	}
+
+	if err := moderation.Mute(c, targetUser.ID, d); err != nil {
+		ap.Logger(c).Error().Err(err).Msg("禁言失败")
+		c.Reply("❌ 禁言失败，请确保机器人有足够的权限")
+		return
+	}
+
+	cs := ap.record(c, moderation.Case{
+		Action:     moderation.ActionMute,
+		TargetID:   targetUser.ID,
+		TargetName: context.FullName(targetUser),
+		Reason:     reason,
+		Duration:   d,
+	})
+	c.Reply(actionText(c, "禁言", targetUser, d, reason) + caseSuffix(cs))
+}
+
+// 解除禁言
+func (ap *AdminPlugin) handleUnmute(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, args, ok := ap.moderationTarget(c, "解除禁言", false)
+	if !ok {
+		return
+	}
+
+	if err := moderation.Unmute(c, targetUser.ID); err != nil {
+		ap.Logger(c).Error().Err(err).Msg("解除禁言失败")
+		c.Reply("❌ 解除禁言失败，请确保机器人有足够的权限")
+		return
+	}
+
+	cs := ap.record(c, moderation.Case{
+		Action:     moderation.ActionUnmute,
+		TargetID:   targetUser.ID,
+		TargetName: context.FullName(targetUser),
+		Reason:     strings.Join(args, " "),
+	})
+	c.Replyf("✅ 已解除 %s 的禁言%s", context.FullName(targetUser), caseSuffix(cs))
+}
+
+// 封禁用户
+func (ap *AdminPlugin) handleBan(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, args, ok := ap.moderationTarget(c, "封禁", true)
+	if !ok {
+		return
+	}
+
+	d, reason, err := parseDurationArgs(args, common.Forever)
+	if err != nil {
+		c.Replyf("❌ %v", err)
+		return
+	}
+
+	if err := moderation.Ban(c, targetUser.ID, d); err != nil {
+		ap.Logger(c).Error().Err(err).Msg("封禁失败")
+		c.Reply("❌ 封禁失败，请确保机器人有足够的权限")
+		return
+	}
+
+	cs := ap.record(c, moderation.Case{
+		Action:     moderation.ActionBan,
+		TargetID:   targetUser.ID,
+		TargetName: context.FullName(targetUser),
+		Reason:     reason,
+		Duration:   d,
+	})
+	c.Reply(actionText(c, "封禁", targetUser, d, reason) + caseSuffix(cs))
+}
+
+// 解除封禁
+func (ap *AdminPlugin) handleUnban(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, args, ok := ap.moderationTarget(c, "解封", false)
+	if !ok {
+		return
+	}
+
+	if err := moderation.Unban(c, targetUser.ID); err != nil {
+		ap.Logger(c).Error().Err(err).Msg("解封失败")
+		c.Reply("❌ 解封失败，请确保机器人有足够的权限")
+		return
+	}
+
+	cs := ap.record(c, moderation.Case{
+		Action:     moderation.ActionUnban,
+		TargetID:   targetUser.ID,
+		TargetName: context.FullName(targetUser),
+		Reason:     strings.Join(args, " "),
+	})
+	c.Replyf("✅ 已解封 %s%s", context.FullName(targetUser), caseSuffix(cs))
+}
+
+// 踢出群组：短时封禁，到期后可以重新加入
+func (ap *AdminPlugin) handleKick(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, args, ok := ap.moderationTarget(c, "踢出", true)
+	if !ok {
+		return
+	}
+	reason := strings.Join(args, " ")
+
+	if err := moderation.Kick(c, targetUser.ID); err != nil {
+		ap.Logger(c).Error().Err(err).Msg("踢出失败")
+		c.Reply("❌ 踢出失败，请确保机器人有足够的权限")
+		return
+	}
+
+	cs := ap.record(c, moderation.Case{
+		Action:     moderation.ActionKick,
+		TargetID:   targetUser.ID,
+		TargetName: context.FullName(targetUser),
+		Reason:     reason,
+	})
+	c.Reply(actionText(c, "踢出", targetUser, 0, reason) + caseSuffix(cs))
+}
+
+// -------------------- Bot API 操作 --------------------
+
+// setAdmin 设置或取消管理员
+func setAdmin(c *context.Context, userID int64, admin bool) error {
+	params := &telego.PromoteChatMemberParams{
+		ChatID:             c.GetChatID(),
+		UserID:             userID,
+		CanChangeInfo:      &admin,
+		CanDeleteMessages:  &admin,
+		CanInviteUsers:     &admin,
+		CanRestrictMembers: &admin,
+		CanPinMessages:     &admin,
+	}
+	if !admin {
+		params.CanPromoteMembers = &admin
+	}
+	return c.Api.PromoteChatMember(c.Ctx, params)
+}
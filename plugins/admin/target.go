@@ -0,0 +1,155 @@
+package admin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/moderation"
+	"yueling_tg/pkg/userdir"
+
+	"github.com/mymmrac/telego"
+)
+
+// -------------------- 目标用户 --------------------
+
+// resolveTarget 解析命令的目标用户，返回去掉目标后的参数。优先级：
+//
+//	text-mention（点击可跳转资料的 @）> @username > 第一个参数为用户 ID > 回复的消息
+//
+// Bot API 无法通过 @username 查询用户，改为在用户目录中查找；
+// 目录中没有该用户时返回其 @username，由调用方提示
+func resolveTarget(c *context.Context, msg *telego.Message, users *userdir.Directory) (*telego.User, []string, string) {
+	for _, e := range msg.Entities {
+		switch {
+		case e.Type == telego.EntityTypeTextMention && e.User != nil:
+			return e.User, commandArgs(cutUTF16(msg.Text, e.Offset, e.Length)), ""
+		case e.Type == telego.EntityTypeMention:
+			username := sliceUTF16(msg.Text, e.Offset, e.Length)
+			u, ok := users.Lookup(username)
+			if !ok {
+				return nil, nil, username
+			}
+			return u.TelegoUser(), commandArgs(cutUTF16(msg.Text, e.Offset, e.Length)), ""
+		}
+	}
+
+	args := commandArgs(msg.Text)
+	if len(args) > 0 {
+		if id, err := strconv.ParseInt(args[0], 10, 64); err == nil && id > 0 {
+			return lookupUser(c, id, users), args[1:], ""
+		}
+	}
+
+	if reply := msg.ReplyToMessage; reply != nil && reply.From != nil {
+		return reply.From, args, ""
+	}
+	return nil, args, ""
+}
+
+// lookupUser 通过群成员信息获取用户资料，查询失败时使用用户目录中的资料，都没有时只保留 ID
+func lookupUser(c *context.Context, id int64, users *userdir.Directory) *telego.User {
+	member, err := c.Api.GetChatMember(c.Ctx, &telego.GetChatMemberParams{
+		ChatID: c.GetChatID(),
+		UserID: id,
+	})
+	if err != nil {
+		if u, ok := users.Get(id); ok {
+			return u.TelegoUser()
+		}
+		return &telego.User{ID: id, FirstName: strconv.FormatInt(id, 10)}
+	}
+	user := member.MemberUser()
+	return &user
+}
+
+// protectedReason 目标为 Bot 自身、群主或管理员时返回不能操作的原因
+func protectedReason(c *context.Context, user *telego.User) string {
+	if bot, err := c.GetBot(); err == nil && bot.ID == user.ID {
+		return "❌ 不能对我自己执行此操作"
+	}
+
+	member, err := c.Api.GetChatMember(c.Ctx, &telego.GetChatMemberParams{
+		ChatID: c.GetChatID(),
+		UserID: user.ID,
+	})
+	if err != nil {
+		return ""
+	}
+	switch member.(type) {
+	case *telego.ChatMemberOwner:
+		return "❌ 不能对群主执行此操作"
+	case *telego.ChatMemberAdministrator:
+		return "❌ 不能对管理员执行此操作，请先取消其管理员"
+	}
+	return ""
+}
+
+// commandArgs 返回命令文本中命令之后的参数
+func commandArgs(text string) []string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields[1:]
+}
+
+// cutUTF16 删除 text 中以 UTF-16 编码单位计算的 [offset, offset+length) 区间（Telegram 实体的偏移）
+func cutUTF16(text string, offset, length int) string {
+	units := utf16.Encode([]rune(text))
+	if offset < 0 || offset+length > len(units) {
+		return text
+	}
+	rest := append(units[:offset:offset], units[offset+length:]...)
+	return string(utf16.Decode(rest))
+}
+
+// sliceUTF16 返回 text 中以 UTF-16 编码单位计算的 [offset, offset+length) 区间
+func sliceUTF16(text string, offset, length int) string {
+	units := utf16.Encode([]rune(text))
+	if offset < 0 || offset+length > len(units) {
+		return ""
+	}
+	return string(utf16.Decode(units[offset : offset+length]))
+}
+
+// -------------------- 时长与理由 --------------------
+
+// parseDurationArgs 从参数中解析可选的时长与理由，第一个参数不是时长时全部作为理由，
+// 以数字开头但无法解析时视为时长写错
+func parseDurationArgs(args []string, def time.Duration) (time.Duration, string, error) {
+	if len(args) == 0 {
+		return def, "", nil
+	}
+
+	d, err := common.ParseDuration(args[0])
+	if err != nil {
+		if args[0][0] >= '0' && args[0][0] <= '9' {
+			return 0, "", err
+		}
+		return def, strings.Join(args, " "), nil
+	}
+	// 超出有效范围时报错，永久需要明确写出
+	if err := moderation.CheckDuration(d); err != nil {
+		return 0, "", err
+	}
+	return d, strings.Join(args[1:], " "), nil
+}
+
+// actionText 操作结果的回复：目标、时长、操作人与理由
+func actionText(c *context.Context, action string, user *telego.User, d time.Duration, reason string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "✅ 已%s %s", action, context.FullName(user))
+	if d != 0 {
+		fmt.Fprintf(&sb, "（%s）", common.FormatDuration(d))
+	}
+	fmt.Fprintf(&sb, "\n操作人：%s", c.GetFullName())
+	if reason != "" {
+		fmt.Fprintf(&sb, "\n理由：%s", reason)
+	}
+	return sb.String()
+}
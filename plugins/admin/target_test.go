@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"yueling_tg/pkg/common"
+)
+
+func TestParseDurationArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantD      time.Duration
+		wantReason string
+		wantErr    bool
+	}{
+		{"没有参数使用默认值", nil, time.Hour, "", false},
+		{"时长与理由", []string{"10m", "刷屏", "广告"}, 10 * time.Minute, "刷屏 广告", false},
+		{"只有理由", []string{"刷屏"}, time.Hour, "刷屏", false},
+		{"永久", []string{"永久"}, common.Forever, "", false},
+		{"最短时长", []string{"30s"}, 30 * time.Second, "", false},
+		{"少于最短时长", []string{"10s"}, 0, "", true},
+		{"超过最长时长", []string{"400d"}, 0, "", true},
+		{"以数字开头但写错", []string{"10x", "刷屏"}, 0, "", true},
+	}
+	for _, tt := range tests {
+		d, reason, err := parseDurationArgs(tt.args, time.Hour)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if d != tt.wantD || reason != tt.wantReason {
+			t.Errorf("%s: got (%v, %q), want (%v, %q)", tt.name, d, reason, tt.wantD, tt.wantReason)
+		}
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	tests := map[string][]string{
+		"/ban":         {},
+		"/ban 10m  刷屏": {"10m", "刷屏"},
+		"":             nil,
+		"禁言 @alice 1h": {"@alice", "1h"},
+	}
+	for text, want := range tests {
+		if got := commandArgs(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("commandArgs(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestUTF16(t *testing.T) {
+	// "😀" 占两个 UTF-16 编码单位，之后的偏移需要按编码单位计算
+	text := "😀 @alice 刷屏"
+	tests := []struct {
+		name      string
+		offset    int
+		length    int
+		wantSlice string
+		wantCut   string
+	}{
+		{"表情之后的提及", 3, 6, "@alice", "😀  刷屏"},
+		{"开头", 0, 2, "😀", " @alice 刷屏"},
+		{"末尾", 10, 2, "刷屏", "😀 @alice "},
+		{"越界", 10, 5, "", text},
+		{"负偏移", -1, 2, "", text},
+	}
+	for _, tt := range tests {
+		if got := sliceUTF16(text, tt.offset, tt.length); got != tt.wantSlice {
+			t.Errorf("%s: sliceUTF16 = %q, want %q", tt.name, got, tt.wantSlice)
+		}
+		if got := cutUTF16(text, tt.offset, tt.length); got != tt.wantCut {
+			t.Errorf("%s: cutUTF16 = %q, want %q", tt.name, got, tt.wantCut)
+		}
+	}
+}
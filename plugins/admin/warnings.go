@@ -0,0 +1,99 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/moderation"
+	"yueling_tg/pkg/plugin/params"
+)
+
+// -------------------- 警告 --------------------
+
+// 警告用户，累计次数达到策略时自动升级处罚
+func (ap *AdminPlugin) handleWarn(c *context.Context, cmdCtx params.CommandContext) {
+	// “警告记录”等以“警告”开头的命令也会命中本匹配器
+	if cmdCtx.Command != "警告" {
+		return
+	}
+	targetUser, args, ok := ap.moderationTarget(c, "警告", true)
+	if !ok {
+		return
+	}
+	reason := strings.Join(args, " ")
+
+	res, err := ap.cases.Warn(c, moderation.Case{
+		TargetID:   targetUser.ID,
+		TargetName: context.FullName(targetUser),
+		Reason:     reason,
+	})
+	if res.Case.ID == 0 {
+		ap.Logger(c).Error().Err(err).Msg("保存警告失败")
+		c.Reply("❌ 警告失败")
+		return
+	}
+
+	ap.Logger(c).Info().
+		Int64("user_id", targetUser.ID).
+		Int64("operator_id", c.GetUserID()).
+		Str("reason", reason).
+		Int("count", res.Count).
+		Msg("警告用户")
+
+	text := actionText(c, "警告", targetUser, 0, reason) + "\n" + res.Summary() + caseSuffix(res.Case)
+	if err != nil {
+		ap.Logger(c).Error().Err(err).Msg("警告升级处罚失败")
+		text += "\n❌ 自动处罚失败，请确保机器人有足够的权限"
+	}
+	c.Reply(text)
+}
+
+// 撤销最近一次有效警告
+func (ap *AdminPlugin) handleUnwarn(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, args, ok := ap.moderationTarget(c, "撤销警告", false)
+	if !ok {
+		return
+	}
+
+	warn, revoke, err := ap.cases.RevokeWarning(c, c.GetChatID().ID, targetUser.ID, strings.Join(args, " "))
+	if warn.ID == 0 {
+		c.Replyf("ℹ️ %s 没有有效的警告", context.FullName(targetUser))
+		return
+	}
+	if err != nil {
+		ap.Logger(c).Error().Err(err).Msg("保存处罚记录失败")
+	}
+
+	remaining := len(ap.cases.ActiveWarnings(c.GetChatID().ID, targetUser.ID))
+	c.Replyf("✅ 已撤销 %s 的警告 #%d，剩余有效警告 %d 次%s", context.FullName(targetUser), warn.ID, remaining, caseSuffix(revoke))
+}
+
+// 查看用户的有效警告与本群的警告策略
+func (ap *AdminPlugin) handleWarnings(c *context.Context, cmdCtx params.CommandContext) {
+	targetUser, _, ok := ap.moderationTarget(c, "查看警告", false)
+	if !ok {
+		return
+	}
+
+	chatID := c.GetChatID().ID
+	warnings := ap.cases.ActiveWarnings(chatID, targetUser.ID)
+	policy := ap.cases.WarnPolicy(chatID)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "⚠️ %s 的有效警告 (共 %d 次)：\n\n", context.FullName(targetUser), len(warnings))
+	for _, w := range warnings {
+		sb.WriteString(w.Summary())
+		sb.WriteString("\n")
+	}
+
+	fmt.Fprintf(&sb, "\n📜 本群策略（警告有效期：%s）：\n", common.FormatDuration(policy.ExpireAfter()))
+	if len(policy.Steps) == 0 {
+		sb.WriteString("不自动处罚\n")
+	}
+	for _, step := range policy.Steps {
+		fmt.Fprintf(&sb, "%d 次 → %s\n", step.Count, step)
+	}
+	c.Reply(sb.String())
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	stdctx "context"
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/plugin/handler"
+	"yueling_tg/pkg/plugin/provider"
+
+	"github.com/mymmrac/telego"
+	ta "github.com/mymmrac/telego/telegoapi"
+)
+
+const (
+	testToken = "123456789:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	testChat  = -100123
+	adminID   = 1 // 发送命令的管理员
+	targetID  = 2 // 被操作的普通成员
+)
+
+// fakeCaller 发送命令的用户为管理员，其他用户为普通成员，记录发送的消息
+type fakeCaller struct {
+	mu    sync.Mutex
+	texts []string
+}
+
+func (f *fakeCaller) Call(_ stdctx.Context, url string, data *ta.RequestData) (*ta.Response, error) {
+	var params struct {
+		UserID int64  `json:"user_id"`
+		Text   string `json:"text"`
+	}
+	if err := json.Unmarshal(data.Buffer.Bytes(), &params); err != nil {
+		return nil, err
+	}
+
+	var result string
+	switch path.Base(url) {
+	case "getChatMember":
+		status := "member"
+		if params.UserID == adminID {
+			status = "administrator"
+		}
+		result = `{"status":"` + status + `","user":{"id":1,"is_bot":false,"first_name":"x"}}`
+	case "sendMessage":
+		f.mu.Lock()
+		f.texts = append(f.texts, params.Text)
+		f.mu.Unlock()
+		result = `{"message_id":1,"date":0,"chat":{"id":-100123,"type":"supergroup"}}`
+	default:
+		result = "true"
+	}
+	return &ta.Response{Ok: true, Result: []byte(result)}, nil
+}
+
+// dispatch 与运行时相同：注入当前上下文，按优先级从高到低判定匹配器，Block 的匹配器执行后停止
+func dispatch(t *testing.T, ap *AdminPlugin, api *telego.Bot, text string, entities ...telego.MessageEntity) {
+	t.Helper()
+	c := context.NewContext(stdctx.Background(), api, telego.Update{
+		Message: &telego.Message{
+			MessageID: 1,
+			Chat:      telego.Chat{ID: testChat, Type: telego.ChatTypeSupergroup},
+			From:      &telego.User{ID: adminID, FirstName: "管理员"},
+			Text:      text,
+			Entities:  entities,
+		},
+	})
+	container := handler.NewContainer()
+	container.RegisterDynamic(provider.DynamicProvider(func(ctx *context.Context) any {
+		return ctx
+	}))
+	handler.Bind(c, container)
+
+	matchers := append([]*plugin.Matcher(nil), ap.Matchers()...)
+	sort.SliceStable(matchers, func(i, j int) bool {
+		return matchers[i].Priority > matchers[j].Priority
+	})
+	for _, m := range matchers {
+		if !m.Match(c) {
+			continue
+		}
+		if err := m.Call(c); err != nil {
+			t.Fatalf("%s: %v", text, err)
+		}
+		if m.Block {
+			return
+		}
+	}
+}
+
+func TestWarnCommandPrefix(t *testing.T) {
+	caller := &fakeCaller{}
+	api, err := telego.NewBot(testToken, telego.WithAPICaller(caller), telego.WithDiscardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := config.NewEmptyManager()
+	cm.SetDataDir(t.TempDir())
+	var ap *AdminPlugin
+	config.With(cm, func() { ap = New().(*AdminPlugin) })
+	mention := func(offset int) telego.MessageEntity {
+		return telego.MessageEntity{Type: telego.EntityTypeTextMention, Offset: offset, Length: 2, User: &telego.User{ID: targetID, FirstName: "X"}}
+	}
+
+	tests := []struct {
+		name      string
+		text      string
+		entity    telego.MessageEntity
+		wantWarns int    // 之后的有效警告数
+		wantReply string // 回复中应包含的内容
+	}{
+		{"警告记录不会记录警告", "警告记录 @x", mention(5), 0, "警告"},
+		{"撤销警告不会记录警告", "撤销警告 @x", mention(5), 0, "没有有效的警告"},
+		{"警告", "警告 @x 刷屏", mention(3), 1, "已警告"},
+		{"再次查看警告记录", "警告记录 @x", mention(5), 1, "刷屏"},
+	}
+	for _, tt := range tests {
+		caller.texts = nil
+		dispatch(t, ap, api, tt.text, tt.entity)
+
+		if got := len(ap.cases.ActiveWarnings(testChat, targetID)); got != tt.wantWarns {
+			t.Errorf("%s: active warnings = %d, want %d", tt.name, got, tt.wantWarns)
+		}
+		if len(caller.texts) != 1 || !strings.Contains(caller.texts[0], tt.wantReply) {
+			t.Errorf("%s: replies = %q, want one containing %q", tt.name, caller.texts, tt.wantReply)
+		}
+	}
+}
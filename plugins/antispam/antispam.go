@@ -0,0 +1,302 @@
+package antispam
+
+import (
+	stdctx "context"
+	"fmt"
+	"slices"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/moderation"
+	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/plugin/dsl/permission"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+var _ plugin.Plugin = (*AntispamPlugin)(nil)
+
+// -------------------- 插件结构 --------------------
+
+type PluginConfig struct {
+	Detectors     Settings            `mapstructure:"detectors" doc:"各检测项的默认设置"`
+	ChatDetectors map[string]Settings `mapstructure:"chat_detectors" doc:"按会话 ID 覆盖检测项，如 [plugins.antispam.chat_detectors.\"-1001234567890\".flood]"`
+	ExemptChats   []int64             `mapstructure:"exempt_chats" doc:"不检查的会话 ID"`
+	TrustedUsers  []int64             `mapstructure:"trusted_users" doc:"不检查的用户 ID（群主与管理员总是不检查）"`
+	Notice        bool                `mapstructure:"notice" doc:"处理后在群里提醒"`
+	NoticeTTL     string              `mapstructure:"notice_ttl" doc:"提醒消息自动删除的时间，如 30s，为空表示不删除"`
+	MembersPath   string              `mapstructure:"members_path" doc:"成员入群时间的数据文件路径" validate:"required"`
+}
+
+// Validate 检查全部检测项与会话覆盖
+func (c *PluginConfig) Validate() error {
+	if _, err := c.Detectors.compile(); err != nil {
+		return fmt.Errorf("detectors.%w", err)
+	}
+	for chat, override := range c.ChatDetectors {
+		if _, err := c.Detectors.merge(override).compile(); err != nil {
+			return fmt.Errorf("chat_detectors.%s.%w", chat, err)
+		}
+	}
+	if c.NoticeTTL != "" {
+		if _, err := common.ParseDuration(c.NoticeTTL); err != nil {
+			return fmt.Errorf("notice_ttl: %w", err)
+		}
+	}
+	return nil
+}
+
+type AntispamPlugin struct {
+	*plugin.Base
+	config PluginConfig
+	cases  *moderation.Store
+
+	// 解析后的检测项，配置已在加载时校验
+	defaults  rules
+	chatRules map[int64]rules
+	noticeTTL time.Duration
+
+	recent  *tracker
+	members *members
+}
+
+func New() plugin.Plugin {
+	ap := &AntispamPlugin{
+		chatRules: make(map[int64]rules),
+		recent:    newTracker(),
+	}
+
+	info := &plugin.PluginInfo{
+		ID:          "antispam",
+		Name:        "反垃圾",
+		Description: "检测刷屏、重复消息、群发提及、新成员发链接与频道转发",
+		Version:     "1.0.0",
+		Author:      "月离",
+		Usage: "自动检测群消息，命中后按配置删除、禁言或踢出，并写入处罚记录（群主与管理员不检查）\n" +
+			"反垃圾：查看本群生效的检测设置（仅管理员）",
+		Group: "群管",
+		Extra: make(map[string]any),
+	}
+
+	// 默认配置
+	pctx := plugin.NewPluginContext(info.ID)
+	ap.cases = moderation.For(pctx.Config())
+	defaultCfg := PluginConfig{
+		Detectors:   DefaultSettings(),
+		Notice:      true,
+		NoticeTTL:   "30s",
+		MembersPath: pctx.DataDir("members.json"),
+	}
+	if err := config.GetPluginConfigOrDefault(info.ID, &ap.config, defaultCfg); err != nil {
+		panic(fmt.Sprintf("加载插件配置失败: %v", err))
+	}
+
+	ap.defaults, _ = ap.config.Detectors.compile()
+	for chat, override := range ap.config.ChatDetectors {
+		var chatID int64
+		if _, err := fmt.Sscan(chat, &chatID); err != nil {
+			continue
+		}
+		ap.chatRules[chatID], _ = ap.config.Detectors.merge(override).compile()
+	}
+	if ap.config.NoticeTTL != "" {
+		ap.noticeTTL, _ = common.ParseDuration(ap.config.NoticeTTL)
+	}
+	ap.members = newMembers(ap.config.MembersPath)
+
+	builder := plugin.New().Info(info).Context(pctx)
+
+	// 优先于屏蔽词检查，刷屏消息不必再匹配屏蔽词
+	builder.OnMessage().Priority(110).Do(ap.handleMessage)
+	builder.OnNotice().Priority(110).Do(ap.handleMemberUpdate)
+	builder.OnCommand("反垃圾").When(permission.GroupAdminOrOwner()).Priority(10).Do(ap.handleStatus)
+
+	return builder.Go(ap)
+}
+
+func (ap *AntispamPlugin) Init() error {
+	if err := ap.members.load(); err != nil {
+		ap.Log.Warn().Err(err).Msg("加载成员入群时间失败")
+	}
+	return nil
+}
+
+// DataPaths 实现 plugin.PluginDataProvider
+func (ap *AntispamPlugin) DataPaths() []string {
+	return []string{ap.config.MembersPath}
+}
+
+// rulesFor 会话生效的检测项
+func (ap *AntispamPlugin) rulesFor(chatID int64) rules {
+	if r, ok := ap.chatRules[chatID]; ok {
+		return r
+	}
+	return ap.defaults
+}
+
+// -------------------- 处理器 --------------------
+
+// handleMessage 记录消息并依次执行各检测项
+func (ap *AntispamPlugin) handleMessage(ctx *context.Context) {
+	msg := ctx.GetMessage()
+	if msg == nil || msg.From == nil || !ctx.IsGroupChat() || ctx.IsEditedMessage() {
+		return
+	}
+	// 关联频道的自动转发与匿名管理员不检查
+	if msg.IsAutomaticForward || (msg.SenderChat != nil && msg.SenderChat.ID == msg.Chat.ID) {
+		return
+	}
+	// 新成员、置顶等服务消息由 handleMemberUpdate 处理
+	if ctx.IsNotice() || ctx.IsPinnedMessage() {
+		return
+	}
+
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	if slices.Contains(ap.config.ExemptChats, chatID) || slices.Contains(ap.config.TrustedUsers, userID) {
+		return
+	}
+
+	r := ap.rulesFor(chatID)
+	key := userKey{chatID: chatID, userID: userID}
+	current := sent{
+		id:      msg.MessageID,
+		at:      time.Unix(msg.Date, 0),
+		digest:  digest(msg),
+		forward: fromChannel(msg),
+	}
+	recent := ap.recent.observe(key, current, r.history())
+
+	h, ok := ap.detect(r, msg, current, recent)
+	if !ok {
+		return
+	}
+
+	// 只在命中后查询管理员身份
+	if ctx.IsAdmin() {
+		return
+	}
+
+	ap.Logger(ctx).Info().
+		Int64("chat_id", chatID).
+		Int64("user_id", userID).
+		Str("detector", h.name).
+		Str("action", h.action.String()).
+		Int("messages", len(h.ids)).
+		Msg("检测到垃圾消息")
+
+	ap.recent.reset(key)
+	ap.execute(ctx, h)
+}
+
+// handleMemberUpdate 记录成员入群与离开，入群消息与成员状态更新都会处理
+func (ap *AntispamPlugin) handleMemberUpdate(ctx *context.Context) {
+	keep := ap.defaults.links.window
+	for _, r := range ap.chatRules {
+		keep = max(keep, r.links.window)
+	}
+
+	if msg := ctx.GetMessage(); msg != nil {
+		for _, user := range msg.NewChatMembers {
+			if user.IsBot {
+				continue
+			}
+			if err := ap.members.join(msg.Chat.ID, user.ID, time.Unix(msg.Date, 0), keep); err != nil {
+				ap.Logger(ctx).Error().Err(err).Msg("保存成员入群时间失败")
+			}
+		}
+		if user := msg.LeftChatMember; user != nil {
+			if err := ap.members.leave(msg.Chat.ID, user.ID); err != nil {
+				ap.Logger(ctx).Error().Err(err).Msg("保存成员入群时间失败")
+			}
+		}
+		return
+	}
+
+	update := ctx.Update.ChatMember
+	if update == nil {
+		return
+	}
+	user := update.NewChatMember.MemberUser()
+	if user.IsBot {
+		return
+	}
+	var err error
+	switch was, is := update.OldChatMember.MemberIsMember(), update.NewChatMember.MemberIsMember(); {
+	case !was && is:
+		err = ap.members.join(update.Chat.ID, user.ID, time.Unix(update.Date, 0), keep)
+	case was && !is:
+		err = ap.members.leave(update.Chat.ID, user.ID)
+	}
+	if err != nil {
+		ap.Logger(ctx).Error().Err(err).Msg("保存成员入群时间失败")
+	}
+}
+
+// handleStatus 查看本群生效的检测设置
+func (ap *AntispamPlugin) handleStatus(ctx *context.Context) {
+	if !ctx.IsGroupChat() {
+		ctx.Reply("❌ 此命令只能在群组中使用")
+		return
+	}
+
+	chatID := ctx.GetChat().ID
+	text := "🛡 本群反垃圾设置"
+	if slices.Contains(ap.config.ExemptChats, chatID) {
+		text += "（本群不检查）"
+	} else if _, ok := ap.chatRules[chatID]; ok {
+		text += "（本群单独配置）"
+	}
+	ctx.Reply(text + "\n" + ap.rulesFor(chatID).describe())
+}
+
+// -------------------- 执行 --------------------
+
+// execute 删除命中的消息，按动作禁言或踢出，并写入处罚记录
+func (ap *AntispamPlugin) execute(ctx *context.Context, h hit) {
+	userID, name := ctx.GetUserID(), ctx.GetFullName()
+	reason := "反垃圾：" + h.reason
+
+	if err := ctx.Api.DeleteMessages(ctx.Ctx, &telego.DeleteMessagesParams{
+		ChatID:     ctx.GetChatID(),
+		MessageIDs: h.ids,
+	}); err != nil {
+		ap.Logger(ctx).Error().Err(err).Msg("删除消息失败")
+	} else {
+		ap.cases.RecordAuto(ctx, moderation.Case{Action: moderation.ActionDelete, Reason: reason})
+	}
+
+	result := "已删除消息"
+	switch h.action.kind {
+	case actionMute:
+		if err := moderation.Mute(ctx, userID, h.action.duration); err != nil {
+			ap.Logger(ctx).Error().Err(err).Msg("禁言失败")
+			break
+		}
+		ap.cases.RecordAuto(ctx, moderation.Case{Action: moderation.ActionMute, Duration: h.action.duration, Reason: reason})
+		result = "已禁言 " + common.FormatDuration(h.action.duration)
+
+	case actionKick:
+		if err := moderation.Kick(ctx, userID); err != nil {
+			ap.Logger(ctx).Error().Err(err).Msg("踢出失败")
+			break
+		}
+		ap.cases.RecordAuto(ctx, moderation.Case{Action: moderation.ActionKick, Reason: reason})
+		result = "已踢出群组"
+	}
+
+	if !ap.config.Notice {
+		return
+	}
+	notice, err := ctx.Sendf("🛡 %s %s，%s", name, h.reason, result)
+	if err != nil || ap.noticeTTL <= 0 {
+		return
+	}
+	// 提醒消息到期删除，处理器返回后 ctx.Ctx 可能已取消
+	api, chatID := ctx.Api, ctx.GetChatID()
+	time.AfterFunc(ap.noticeTTL, func() {
+		_ = api.DeleteMessage(stdctx.Background(), tu.Delete(chatID, notice.MessageID))
+	})
+}
@@ -0,0 +1,126 @@
+package antispam
+
+import (
+	"fmt"
+	"strings"
+
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/textmatch"
+
+	"github.com/mymmrac/telego"
+)
+
+// -------------------- 检测 --------------------
+
+// hit 一次命中：检测项、说明、需要删除的消息与动作
+type hit struct {
+	name   string
+	reason string
+	ids    []int
+	action action
+}
+
+// detect 依次执行单条消息的检测（新成员链接、群发提及）与最近消息的检测（频道转发、重复、刷屏），
+// 返回第一个命中的检测项
+func (ap *AntispamPlugin) detect(r rules, msg *telego.Message, current sent, recent []sent) (hit, bool) {
+	if d := r.links; d.enabled() {
+		if n := countEntities(msg, telego.EntityTypeURL, telego.EntityTypeTextLink); n >= d.threshold {
+			if joined, ok := ap.members.joinedAt(msg.Chat.ID, msg.From.ID); ok && current.at.Sub(joined) < d.window {
+				return hit{
+					name:   "links",
+					reason: fmt.Sprintf("入群 %s 内发送链接", common.FormatDuration(d.window)),
+					ids:    []int{current.id},
+					action: d.action,
+				}, true
+			}
+		}
+	}
+
+	if d := r.mentions; d.enabled() {
+		if n := countEntities(msg, telego.EntityTypeMention, telego.EntityTypeTextMention); n >= d.threshold {
+			return hit{
+				name:   "mentions",
+				reason: fmt.Sprintf("一条消息提及 %d 人", n),
+				ids:    []int{current.id},
+				action: d.action,
+			}, true
+		}
+	}
+
+	if d := r.forwards; d.enabled() && current.forward {
+		if ids := within(recent, current.at, d.window, func(m sent) bool { return m.forward }); len(ids) >= d.threshold {
+			return hit{
+				name:   "forwards",
+				reason: fmt.Sprintf("%s 内转发 %d 条频道消息", common.FormatDuration(d.window), len(ids)),
+				ids:    ids,
+				action: d.action,
+			}, true
+		}
+	}
+
+	if d := r.repeat; d.enabled() && current.digest != "" {
+		if ids := within(recent, current.at, d.window, func(m sent) bool { return m.digest == current.digest }); len(ids) >= d.threshold {
+			return hit{
+				name:   "repeat",
+				reason: fmt.Sprintf("%s 内重复发送 %d 次", common.FormatDuration(d.window), len(ids)),
+				ids:    ids,
+				action: d.action,
+			}, true
+		}
+	}
+
+	if d := r.flood; d.enabled() {
+		if ids := within(recent, current.at, d.window, nil); len(ids) >= d.threshold {
+			return hit{
+				name:   "flood",
+				reason: fmt.Sprintf("%s 内发送 %d 条消息", common.FormatDuration(d.window), len(ids)),
+				ids:    ids,
+				action: d.action,
+			}, true
+		}
+	}
+
+	return hit{}, false
+}
+
+// countEntities 统计文本与媒体说明中指定类型的实体数
+func countEntities(msg *telego.Message, types ...string) int {
+	n := 0
+	for _, entities := range [][]telego.MessageEntity{msg.Entities, msg.CaptionEntities} {
+		for _, e := range entities {
+			for _, t := range types {
+				if e.Type == t {
+					n++
+				}
+			}
+		}
+	}
+	return n
+}
+
+// fromChannel 是否为转发自频道的消息
+func fromChannel(msg *telego.Message) bool {
+	_, ok := msg.ForwardOrigin.(*telego.MessageOriginChannel)
+	return ok
+}
+
+// digest 用于重复检测的内容摘要：文字与媒体说明规范化后的内容，没有文字时为贴纸、图片或动图的文件 ID
+func digest(msg *telego.Message) string {
+	text := strings.TrimSpace(msg.Text + "\n" + msg.Caption)
+	if text != "" {
+		if compact := textmatch.Compact(text); compact != "" {
+			return "text:" + compact
+		}
+		return "text:" + text
+	}
+
+	switch {
+	case msg.Sticker != nil:
+		return "sticker:" + msg.Sticker.FileUniqueID
+	case msg.Animation != nil:
+		return "animation:" + msg.Animation.FileUniqueID
+	case len(msg.Photo) > 0:
+		return "photo:" + msg.Photo[len(msg.Photo)-1].FileUniqueID
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package antispam
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// -------------------- 新成员 --------------------
+
+// members 成员的入群时间，用于判断新成员；保存在数据文件中，重启后仍然有效
+type members struct {
+	path string
+
+	mu     sync.Mutex
+	joined map[int64]map[int64]time.Time // chat_id -> user_id -> 入群时间
+}
+
+func newMembers(path string) *members {
+	return &members{path: path, joined: make(map[int64]map[int64]time.Time)}
+}
+
+// joinedAt 用户的入群时间，没有记录时返回 false
+func (m *members) joinedAt(chatID, userID int64) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.joined[chatID][userID]
+	return t, ok
+}
+
+// join 记录入群时间，同时删除超过 keep 的记录并保存
+func (m *members) join(chatID, userID int64, at time.Time, keep time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.joined[chatID] == nil {
+		m.joined[chatID] = make(map[int64]time.Time)
+	}
+	m.joined[chatID][userID] = at
+
+	for chat, users := range m.joined {
+		for user, t := range users {
+			if at.Sub(t) > keep {
+				delete(users, user)
+			}
+		}
+		if len(users) == 0 {
+			delete(m.joined, chat)
+		}
+	}
+	return m.save()
+}
+
+// leave 成员离开后删除记录，重新加入时按新成员处理
+func (m *members) leave(chatID, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.joined[chatID][userID]; !ok {
+		return nil
+	}
+	delete(m.joined[chatID], userID)
+	if len(m.joined[chatID]) == 0 {
+		delete(m.joined, chatID)
+	}
+	return m.save()
+}
+
+// load 从文件加载，文件不存在时为空
+func (m *members) load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, err := os.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &m.joined)
+}
+
+// save 保存到文件（需持有锁）
+func (m *members) save() error {
+	if m.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(m.joined, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, data, 0644)
+}
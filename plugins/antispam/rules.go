@@ -0,0 +1,221 @@
+package antispam
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/moderation"
+)
+
+// -------------------- 检测项设置 --------------------
+
+// Detector 一个检测项的阈值、时间窗口与动作
+type Detector struct {
+	Action    string `mapstructure:"action" doc:"动作：off 关闭、delete 删除消息、mute[:时长] 删除并禁言、kick 删除并踢出；会话覆盖中为空时沿用全局"`
+	Threshold int    `mapstructure:"threshold" doc:"阈值，含义见各检测项；会话覆盖中为 0 时沿用全局" validate:"min=0"`
+	Window    string `mapstructure:"window" doc:"时间窗口，如 10s、1m、1d；会话覆盖中为空时沿用全局"`
+}
+
+// Settings 全部检测项
+type Settings struct {
+	Flood    Detector `mapstructure:"flood" doc:"刷屏：window 内发送 threshold 条及以上消息"`
+	Repeat   Detector `mapstructure:"repeat" doc:"重复：window 内发送 threshold 条及以上内容相同的消息（文字、贴纸或图片）"`
+	Mentions Detector `mapstructure:"mentions" doc:"群发提及：一条消息提及 threshold 个及以上用户，不使用 window"`
+	Links    Detector `mapstructure:"links" doc:"新成员链接：入群不足 window 的成员发送含 threshold 个及以上链接或邀请的消息"`
+	Forwards Detector `mapstructure:"forwards" doc:"频道转发：window 内转发 threshold 条及以上频道消息"`
+}
+
+// DefaultSettings 默认设置，频道转发默认关闭
+func DefaultSettings() Settings {
+	return Settings{
+		Flood:    Detector{Action: "mute:10m", Threshold: 8, Window: "10s"},
+		Repeat:   Detector{Action: "delete", Threshold: 3, Window: "1m"},
+		Mentions: Detector{Action: "delete", Threshold: 5},
+		Links:    Detector{Action: "delete", Threshold: 1, Window: "1d"},
+		Forwards: Detector{Action: "off", Threshold: 1, Window: "1m"},
+	}
+}
+
+// merge 用 override 中非空的字段覆盖 d
+func (d Detector) merge(override Detector) Detector {
+	if override.Action != "" {
+		d.Action = override.Action
+	}
+	if override.Threshold > 0 {
+		d.Threshold = override.Threshold
+	}
+	if override.Window != "" {
+		d.Window = override.Window
+	}
+	return d
+}
+
+// merge 逐项覆盖
+func (s Settings) merge(override Settings) Settings {
+	return Settings{
+		Flood:    s.Flood.merge(override.Flood),
+		Repeat:   s.Repeat.merge(override.Repeat),
+		Mentions: s.Mentions.merge(override.Mentions),
+		Links:    s.Links.merge(override.Links),
+		Forwards: s.Forwards.merge(override.Forwards),
+	}
+}
+
+// -------------------- 解析 --------------------
+
+// actionKind 检测命中后的处理方式
+type actionKind string
+
+const (
+	actionOff    actionKind = "off"    // 不检测
+	actionDelete actionKind = "delete" // 删除消息
+	actionMute   actionKind = "mute"   // 删除并禁言
+	actionKick   actionKind = "kick"   // 删除并踢出
+)
+
+// defaultMuteDuration mute 未指定时长时的禁言时长
+const defaultMuteDuration = 10 * time.Minute
+
+type action struct {
+	kind     actionKind
+	duration time.Duration
+}
+
+// parseAction 解析 off、delete、mute[:时长]、kick
+func parseAction(spec string) (action, error) {
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(spec), ":")
+	kind := actionKind(strings.ToLower(name))
+	switch kind {
+	case actionOff, actionDelete, actionKick:
+		if hasArg {
+			return action{}, fmt.Errorf("动作 %s 不支持参数", spec)
+		}
+		return action{kind: kind}, nil
+	case actionMute:
+		a := action{kind: kind, duration: defaultMuteDuration}
+		if hasArg {
+			d, err := common.ParseDuration(arg)
+			if err == nil {
+				err = moderation.CheckDuration(d)
+			}
+			if err != nil {
+				return action{}, fmt.Errorf("动作 %s 的时长无效: %w", spec, err)
+			}
+			a.duration = d
+		}
+		return a, nil
+	}
+	return action{}, fmt.Errorf("未知动作 %q，可用：off、delete、mute[:时长]、kick", spec)
+}
+
+// String 动作的中文名称
+func (a action) String() string {
+	switch a.kind {
+	case actionOff:
+		return "关闭"
+	case actionDelete:
+		return "删除"
+	case actionMute:
+		return "删除并禁言 " + common.FormatDuration(a.duration)
+	case actionKick:
+		return "删除并踢出"
+	}
+	return string(a.kind)
+}
+
+// detector 解析后的检测项
+type detector struct {
+	action    action
+	threshold int
+	window    time.Duration
+}
+
+// enabled 检测项是否开启
+func (d detector) enabled() bool {
+	return d.action.kind != actionOff && d.threshold > 0
+}
+
+// parse 解析动作与时间窗口，windowed 为 false 时忽略时间窗口
+func (d Detector) parse(windowed bool) (detector, error) {
+	a, err := parseAction(d.Action)
+	if err != nil {
+		return detector{}, err
+	}
+	det := detector{action: a, threshold: d.Threshold}
+	if !windowed || !det.enabled() {
+		return det, nil
+	}
+
+	if d.Window == "" {
+		return detector{}, fmt.Errorf("window 不能为空")
+	}
+	w, err := common.ParseDuration(d.Window)
+	if err != nil {
+		return detector{}, fmt.Errorf("window: %w", err)
+	}
+	if w == common.Forever {
+		return detector{}, fmt.Errorf("window 不能为永久")
+	}
+	det.window = w
+	return det, nil
+}
+
+// rules 一个会话生效的全部检测项
+type rules struct {
+	flood, repeat, mentions, links, forwards detector
+}
+
+// compile 解析全部检测项，返回第一个错误
+func (s Settings) compile() (rules, error) {
+	var r rules
+	items := []struct {
+		name     string
+		src      Detector
+		dst      *detector
+		windowed bool
+	}{
+		{"flood", s.Flood, &r.flood, true},
+		{"repeat", s.Repeat, &r.repeat, true},
+		{"mentions", s.Mentions, &r.mentions, false},
+		{"links", s.Links, &r.links, true},
+		{"forwards", s.Forwards, &r.forwards, true},
+	}
+	for _, item := range items {
+		d, err := item.src.parse(item.windowed)
+		if err != nil {
+			return rules{}, fmt.Errorf("%s: %w", item.name, err)
+		}
+		*item.dst = d
+	}
+	return r, nil
+}
+
+// history 需要保留的最近消息时长，即刷屏、重复与转发检测中最长的时间窗口
+func (r rules) history() time.Duration {
+	var keep time.Duration
+	for _, d := range []detector{r.flood, r.repeat, r.forwards} {
+		if d.enabled() && d.window > keep {
+			keep = d.window
+		}
+	}
+	return keep
+}
+
+// describe 检测项的中文说明
+func (r rules) describe() string {
+	line := func(name string, d detector, format string, args ...any) string {
+		if !d.enabled() {
+			return fmt.Sprintf("• %s：关闭", name)
+		}
+		return fmt.Sprintf("• %s：%s → %s", name, fmt.Sprintf(format, args...), d.action)
+	}
+	return strings.Join([]string{
+		line("刷屏", r.flood, "%s 内 %d 条消息", common.FormatDuration(r.flood.window), r.flood.threshold),
+		line("重复", r.repeat, "%s 内 %d 条相同消息", common.FormatDuration(r.repeat.window), r.repeat.threshold),
+		line("群发提及", r.mentions, "一条消息提及 %d 人", r.mentions.threshold),
+		line("新成员链接", r.links, "入群 %s 内发送 %d 个链接", common.FormatDuration(r.links.window), r.links.threshold),
+		line("频道转发", r.forwards, "%s 内转发 %d 条频道消息", common.FormatDuration(r.forwards.window), r.forwards.threshold),
+	}, "\n")
+}
@@ -0,0 +1,127 @@
+package antispam
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"yueling_tg/pkg/common"
+)
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    action
+		wantErr bool
+	}{
+		{"off", action{kind: actionOff}, false},
+		{"delete", action{kind: actionDelete}, false},
+		{"KICK", action{kind: actionKick}, false},
+		{"mute", action{kind: actionMute, duration: defaultMuteDuration}, false},
+		{"mute:30s", action{kind: actionMute, duration: 30 * time.Second}, false},
+		{"mute:forever", action{kind: actionMute, duration: common.Forever}, false},
+		{"mute:10s", action{}, true},
+		{"mute:400d", action{}, true},
+		{"mute:x", action{}, true},
+		{"delete:1h", action{}, true},
+		{"ban", action{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAction(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAction(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAction(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestSettingsMerge(t *testing.T) {
+	base := DefaultSettings()
+	tests := []struct {
+		name     string
+		override Settings
+		want     Settings
+	}{
+		{"空覆盖沿用全局", Settings{}, base},
+		{
+			"只覆盖填写的字段",
+			Settings{
+				Flood:    Detector{Threshold: 5},
+				Repeat:   Detector{Action: "mute:1h"},
+				Forwards: Detector{Action: "delete", Window: "5m"},
+			},
+			Settings{
+				Flood:    Detector{Action: "mute:10m", Threshold: 5, Window: "10s"},
+				Repeat:   Detector{Action: "mute:1h", Threshold: 3, Window: "1m"},
+				Mentions: base.Mentions,
+				Links:    base.Links,
+				Forwards: Detector{Action: "delete", Threshold: 1, Window: "5m"},
+			},
+		},
+		{
+			"关闭单个检测项",
+			Settings{Links: Detector{Action: "off"}},
+			Settings{
+				Flood:    base.Flood,
+				Repeat:   base.Repeat,
+				Mentions: base.Mentions,
+				Links:    Detector{Action: "off", Threshold: 1, Window: "1d"},
+				Forwards: base.Forwards,
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := base.merge(tt.override); got != tt.want {
+			t.Errorf("%s: merge = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSettingsCompile(t *testing.T) {
+	r, err := DefaultSettings().compile()
+	if err != nil {
+		t.Fatalf("compile defaults: %v", err)
+	}
+	want := rules{
+		flood:    detector{action: action{kind: actionMute, duration: 10 * time.Minute}, threshold: 8, window: 10 * time.Second},
+		repeat:   detector{action: action{kind: actionDelete}, threshold: 3, window: time.Minute},
+		mentions: detector{action: action{kind: actionDelete}, threshold: 5},
+		links:    detector{action: action{kind: actionDelete}, threshold: 1, window: 24 * time.Hour},
+		forwards: detector{action: action{kind: actionOff}, threshold: 1},
+	}
+	if r != want {
+		t.Errorf("compile = %+v, want %+v", r, want)
+	}
+	if r.forwards.enabled() {
+		t.Error("forwards should be disabled by default")
+	}
+	// 频道转发关闭时其时间窗口不计入
+	if got := r.history(); got != time.Minute {
+		t.Errorf("history = %v, want 1m", got)
+	}
+
+	errTests := []struct {
+		name     string
+		override Settings
+		wantErr  string
+	}{
+		{"未知动作", Settings{Flood: Detector{Action: "ban"}}, "flood"},
+		{"禁言过短", Settings{Repeat: Detector{Action: "mute:5s"}}, "repeat"},
+		{"时间窗口无效", Settings{Links: Detector{Window: "abc"}}, "links: window"},
+		{"时间窗口永久", Settings{Forwards: Detector{Action: "delete", Window: "永久"}}, "forwards: window"},
+	}
+	for _, tt := range errTests {
+		_, err := DefaultSettings().merge(tt.override).compile()
+		if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+			t.Errorf("%s: compile error = %v, want prefix %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	// 不使用时间窗口的检测项忽略 window
+	if _, err := DefaultSettings().merge(Settings{Mentions: Detector{Window: "abc"}}).compile(); err != nil {
+		t.Errorf("mentions window should be ignored: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package antispam
+
+import (
+	"sync"
+	"time"
+)
+
+// -------------------- 最近消息 --------------------
+
+// sweepInterval 清理长时间没有发言的用户记录的间隔
+const sweepInterval = time.Minute
+
+// sent 一条最近的消息
+type sent struct {
+	id      int
+	at      time.Time
+	digest  string // 内容摘要，为空时不参与重复检测
+	forward bool   // 是否转发自频道
+}
+
+type userKey struct {
+	chatID, userID int64
+}
+
+// activity 用户在一个会话中最近的消息，按时间顺序
+type activity struct {
+	messages []sent
+	keep     time.Duration
+}
+
+// tracker 记录每个会话中每个用户最近的消息，只保存在内存中，并发安全
+type tracker struct {
+	mu        sync.Mutex
+	users     map[userKey]*activity
+	lastSweep time.Time
+}
+
+func newTracker() *tracker {
+	return &tracker{users: make(map[userKey]*activity)}
+}
+
+// observe 记录一条消息，丢弃早于 keep 的记录，返回当前保留的消息（副本）
+func (t *tracker) observe(key userKey, msg sent, keep time.Duration) []sent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if msg.at.Sub(t.lastSweep) > sweepInterval {
+		t.sweep(msg.at)
+	}
+
+	a := t.users[key]
+	if a == nil {
+		a = &activity{}
+		t.users[key] = a
+	}
+	a.keep = keep
+	a.messages = append(trim(a.messages, msg.at.Add(-keep)), msg)
+	return append([]sent(nil), a.messages...)
+}
+
+// reset 处理后清空用户的记录，重新开始计数
+func (t *tracker) reset(key userKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.users, key)
+}
+
+// sweep 删除已没有有效记录的用户（需持有锁）
+func (t *tracker) sweep(now time.Time) {
+	t.lastSweep = now
+	for key, a := range t.users {
+		if len(a.messages) == 0 || now.Sub(a.messages[len(a.messages)-1].at) > a.keep {
+			delete(t.users, key)
+		}
+	}
+}
+
+// trim 去掉早于 since 的消息
+func trim(messages []sent, since time.Time) []sent {
+	i := 0
+	for i < len(messages) && messages[i].at.Before(since) {
+		i++
+	}
+	return messages[i:]
+}
+
+// within 返回 window 内满足 match 的消息编号，match 为空时匹配全部
+func within(messages []sent, now time.Time, window time.Duration, match func(sent) bool) []int {
+	var ids []int
+	for _, m := range trim(messages, now.Add(-window)) {
+		if match == nil || match(m) {
+			ids = append(ids, m.id)
+		}
+	}
+	return ids
+}
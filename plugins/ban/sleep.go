@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/moderation"
 	"yueling_tg/pkg/plugin"
 )
 
@@ -15,6 +16,7 @@ var _ plugin.Plugin = (*SleepPlugin)(nil)
 type SleepPlugin struct {
 	*plugin.Base
 	sleepWords []string
+	cases      *moderation.Store
 }
 
 var pluginInfo = &plugin.PluginInfo{
@@ -29,7 +31,9 @@ var pluginInfo = &plugin.PluginInfo{
 }
 
 func New() plugin.Plugin {
+	pctx := plugin.NewPluginContext(pluginInfo.ID)
 	p := &SleepPlugin{
+		cases: moderation.For(pctx.Config()),
 		sleepWords: []string{
 			"被梦魇抓走了",
 			"被僵尸吃掉了脑子",
@@ -42,7 +46,7 @@ func New() plugin.Plugin {
 		},
 	}
 
-	return plugin.New().OnFullMatch("我要睡觉").Do(p.handleSleep).Info(pluginInfo).Go(p)
+	return plugin.New().OnFullMatch("我要睡觉").Do(p.handleSleep).Info(pluginInfo).Context(pctx).Go(p)
 }
 
 // -------------------- 处理器 --------------------
@@ -60,7 +64,7 @@ func (p *SleepPlugin) handleSleep(ctx *context.Context) {
 	// 随机选择睡眠理由
 	sleepWord := p.sleepWords[rand.Intn(len(p.sleepWords))]
 
-	p.Log.Info().
+	p.Logger(ctx).Info().
 		Int64("user_id", userID).
 		Str("username", username).
 		Int("sleep_hours", sleepHours).
@@ -72,20 +76,36 @@ func (p *SleepPlugin) handleSleep(ctx *context.Context) {
 	}
 
 	if !ctx.IsBotAdmin() || !ctx.CanBotRestrictMembers() {
-		p.Log.Error().Msg("获取机器人权限失败")
+		p.Logger(ctx).Error().Msg("获取机器人权限失败")
 		ctx.Reply("我没有管理员权限，无法让你睡觉哦~")
 		return
 	}
 
 	// 禁言用户
-	ctx.MuteUser(ctx.GetUserID(), time.Duration(sleepSeconds)*time.Second)
+	duration := time.Duration(sleepSeconds) * time.Second
+	if err := ctx.MuteUser(ctx.GetUserID(), duration); err != nil {
+		p.Logger(ctx).Error().Err(err).Msg("禁言失败")
+		ctx.Reply("睡不着，失败了~")
+		return
+	}
 
-	p.Log.Info().
+	p.Logger(ctx).Info().
 		Int64("user_id", userID).
 		Str("username", username).
 		Int("sleep_hours", sleepHours).
 		Msg("禁言成功")
 
+	// 用户自己申请的禁言，操作人即本人
+	if _, err := p.cases.Record(ctx, moderation.Case{
+		Action:     moderation.ActionMute,
+		TargetID:   userID,
+		TargetName: username,
+		Reason:     "我要睡觉",
+		Duration:   duration,
+	}); err != nil {
+		p.Logger(ctx).Error().Err(err).Msg("保存处罚记录失败")
+	}
+
 	ctx.Replyf("%s %s，%d小时后见！😴💤", username, sleepWord, sleepHours)
 
 }
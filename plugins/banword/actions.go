@@ -0,0 +1,280 @@
+package banword
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/moderation"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// -------------------- 动作 --------------------
+
+// actionKind 命中屏蔽词后执行的动作
+type actionKind string
+
+const (
+	actionDelete actionKind = "delete" // 删除消息
+	actionNotice actionKind = "notice" // 在群里提醒
+	actionWarn   actionKind = "warn"   // 警告，按 [moderation.warnings] 升级处罚
+	actionMute   actionKind = "mute"   // 禁言，可带时长，如 mute:1h
+	actionKick   actionKind = "kick"   // 踢出
+	actionReport actionKind = "report" // 通知管理员
+)
+
+// defaultMuteDuration mute 未指定时长时的禁言时长
+const defaultMuteDuration = time.Hour
+
+// actionAliases 动作的中文写法
+var actionAliases = map[string]actionKind{
+	"删除": actionDelete,
+	"提醒": actionNotice,
+	"警告": actionWarn,
+	"禁言": actionMute,
+	"踢出": actionKick,
+	"举报": actionReport,
+}
+
+// action 一个动作，duration 只用于禁言
+type action struct {
+	kind     actionKind
+	duration time.Duration
+}
+
+// parseAction 解析动作写法：delete、notice、warn、mute[:时长]、kick、report 或对应的中文
+func parseAction(spec string) (action, error) {
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(spec), ":")
+	kind := actionKind(strings.ToLower(name))
+	if alias, ok := actionAliases[name]; ok {
+		kind = alias
+	}
+
+	switch kind {
+	case actionDelete, actionNotice, actionWarn, actionKick, actionReport:
+		if hasArg {
+			return action{}, fmt.Errorf("动作 %s 不支持参数", spec)
+		}
+		return action{kind: kind}, nil
+	case actionMute:
+		a := action{kind: kind, duration: defaultMuteDuration}
+		if hasArg {
+			d, err := common.ParseDuration(arg)
+			if err == nil {
+				err = moderation.CheckDuration(d)
+			}
+			if err != nil {
+				return action{}, fmt.Errorf("动作 %s 的时长无效: %w", spec, err)
+			}
+			a.duration = d
+		}
+		return a, nil
+	}
+	return action{}, fmt.Errorf("未知动作 %q，可用：delete、notice、warn、mute[:时长]、kick、report", spec)
+}
+
+// actionArgPrefixes 添加屏蔽时指定动作的参数前缀
+var actionArgPrefixes = []string{"动作=", "action=", "actions="}
+
+// cutActionArg 参数为动作列表（如 动作=delete,mute:1h）时返回去掉前缀的部分
+func cutActionArg(arg string) (string, bool) {
+	for _, prefix := range actionArgPrefixes {
+		if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+			return arg[len(prefix):], true
+		}
+	}
+	return "", false
+}
+
+// parseActions 解析动作列表
+func parseActions(specs []string) ([]action, error) {
+	actions := make([]action, 0, len(specs))
+	for _, spec := range specs {
+		a, err := parseAction(spec)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// String 动作的中文名称
+func (a action) String() string {
+	switch a.kind {
+	case actionDelete:
+		return "删除"
+	case actionNotice:
+		return "提醒"
+	case actionWarn:
+		return "警告"
+	case actionMute:
+		return "禁言 " + common.FormatDuration(a.duration)
+	case actionKick:
+		return "踢出"
+	case actionReport:
+		return "举报"
+	}
+	return string(a.kind)
+}
+
+// describeActions 动作列表的中文描述
+func describeActions(actions []action) string {
+	names := make([]string, len(actions))
+	for i, a := range actions {
+		names[i] = a.String()
+	}
+	return strings.Join(names, "、")
+}
+
+// punitive 需要对发送者执行的处罚，管理员无法被处罚
+func (a action) punitive() bool {
+	return a.kind == actionWarn || a.kind == actionMute || a.kind == actionKick
+}
+
+// -------------------- 执行 --------------------
+
+// actionsFor 命中的屏蔽词对应的动作：屏蔽词自身的动作（本群 > 共享词库）> 会话默认动作 > 全局默认动作
+func (bp *BanwordPlugin) actionsFor(groupID int64, keyword string) []action {
+	specs := bp.db.keywordActions(groupID, keyword)
+
+	if len(specs) == 0 {
+		specs = bp.config.ChatActions[fmt.Sprint(groupID)]
+	}
+	if len(specs) == 0 {
+		specs = bp.config.Actions
+		// 兼容旧配置 warn = true
+		if bp.config.Warn && !slices.Contains(specs, string(actionWarn)) {
+			specs = append(slices.Clone(specs), string(actionWarn))
+		}
+	}
+
+	// 配置已在加载时校验，屏蔽词的动作在添加时校验
+	actions, err := parseActions(specs)
+	if err != nil {
+		bp.Log.Warn().Err(err).Str("keyword", keyword).Msg("屏蔽词动作无效，只删除消息")
+		return []action{{kind: actionDelete}}
+	}
+	return actions
+}
+
+// execute 依次执行动作，提醒与处罚结果合并为一条消息发送到群组
+func (bp *BanwordPlugin) execute(ctx *context.Context, keyword string, actions []action) {
+	reason := "屏蔽词：" + keyword
+	userID, name := ctx.GetUserID(), ctx.GetFullName()
+
+	// 管理员不会被处罚，只在需要时查询一次
+	admin, adminChecked := false, false
+	isAdmin := func() bool {
+		if !adminChecked {
+			admin, adminChecked = ctx.IsAdmin(), true
+		}
+		return admin
+	}
+
+	var lines []string
+	notice := false
+	for _, a := range actions {
+		if a.punitive() && isAdmin() {
+			continue
+		}
+
+		switch a.kind {
+		case actionDelete:
+			if err := ctx.DeleteMessage(ctx.GetMessageID()); err != nil {
+				bp.Logger(ctx).Error().Err(err).Msg("删除消息失败")
+				continue
+			}
+			bp.cases.RecordAuto(ctx, moderation.Case{Action: moderation.ActionDelete, Reason: reason})
+
+		case actionNotice:
+			notice = true
+
+		case actionWarn:
+			res, err := bp.cases.Warn(ctx, moderation.Case{
+				ActorName:  "自动",
+				TargetID:   userID,
+				TargetName: name,
+				Reason:     reason,
+			})
+			if err != nil {
+				bp.Logger(ctx).Error().Err(err).Msg("屏蔽词警告失败")
+			}
+			if res.Case.ID != 0 {
+				lines = append(lines, res.Summary())
+			}
+
+		case actionMute:
+			if err := moderation.Mute(ctx, userID, a.duration); err != nil {
+				bp.Logger(ctx).Error().Err(err).Msg("屏蔽词禁言失败")
+				continue
+			}
+			bp.cases.RecordAuto(ctx, moderation.Case{Action: moderation.ActionMute, Duration: a.duration, Reason: reason})
+			lines = append(lines, "🔇 已禁言 "+common.FormatDuration(a.duration))
+
+		case actionKick:
+			if err := moderation.Kick(ctx, userID); err != nil {
+				bp.Logger(ctx).Error().Err(err).Msg("屏蔽词踢出失败")
+				continue
+			}
+			bp.cases.RecordAuto(ctx, moderation.Case{Action: moderation.ActionKick, Reason: reason})
+			lines = append(lines, "👢 已踢出群组")
+
+		case actionReport:
+			bp.report(ctx, keyword)
+		}
+	}
+
+	if notice || len(lines) > 0 {
+		text := fmt.Sprintf("🚫 %s 发送了屏蔽词", name)
+		if len(lines) > 0 {
+			text += "\n" + strings.Join(lines, "\n")
+		}
+		// 原消息可能已删除，直接发送到群组
+		ctx.Send(text)
+	}
+}
+
+// report 通知管理员：配置了 report_chat 时发送到该会话，否则私聊群内每位管理员
+// （管理员需先私聊过 Bot 才能收到）
+func (bp *BanwordPlugin) report(ctx *context.Context, keyword string) {
+	chat := ctx.GetChat()
+	text := fmt.Sprintf("🚨 屏蔽词举报\n群组：%s (%d)\n用户：%s (%d)\n屏蔽词：%s\n内容：%s",
+		chat.Title, chat.ID, ctx.GetFullName(), ctx.GetUserID(), keyword, excerpt(ctx.GetMessageText()+ctx.GetCaption(), 200))
+
+	if bp.config.ReportChat != 0 {
+		if _, err := ctx.Api.SendMessage(ctx.Ctx, tu.Message(tu.ID(bp.config.ReportChat), text)); err != nil {
+			bp.Logger(ctx).Error().Err(err).Int64("report_chat", bp.config.ReportChat).Msg("发送举报失败")
+		}
+		return
+	}
+
+	admins, err := ctx.Api.GetChatAdministrators(ctx.Ctx, &telego.GetChatAdministratorsParams{ChatID: ctx.GetChatID()})
+	if err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("获取管理员列表失败")
+		return
+	}
+	for _, member := range admins {
+		user := member.MemberUser()
+		if user.IsBot {
+			continue
+		}
+		if _, err := ctx.Api.SendMessage(ctx.Ctx, tu.Message(tu.ID(user.ID), text)); err != nil {
+			bp.Logger(ctx).Debug().Err(err).Int64("admin_id", user.ID).Msg("私聊管理员失败")
+		}
+	}
+}
+
+// excerpt 截取前 n 个字符
+func excerpt(s string, n int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return string(runes[:n]) + "…"
+}
@@ -0,0 +1,39 @@
+package banword
+
+import (
+	"testing"
+	"time"
+
+	"yueling_tg/pkg/common"
+)
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    action
+		wantErr bool
+	}{
+		{"delete", action{kind: actionDelete}, false},
+		{"删除", action{kind: actionDelete}, false},
+		{"mute", action{kind: actionMute, duration: defaultMuteDuration}, false},
+		{"mute:30s", action{kind: actionMute, duration: 30 * time.Second}, false},
+		{"禁言:1h", action{kind: actionMute, duration: time.Hour}, false},
+		{"mute:366d", action{kind: actionMute, duration: 366 * 24 * time.Hour}, false},
+		{"mute:永久", action{kind: actionMute, duration: common.Forever}, false},
+		{"mute:10s", action{}, true},
+		{"mute:367d", action{}, true},
+		{"mute:abc", action{}, true},
+		{"kick:1h", action{}, true},
+		{"explode", action{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAction(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseAction(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAction(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -5,13 +5,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
 	"yueling_tg/internal/core/context"
 	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/moderation"
+	"yueling_tg/pkg/paginator"
 	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/plugin/dsl/condition"
+	"yueling_tg/pkg/plugin/dsl/permission"
 	"yueling_tg/pkg/plugin/params"
+	"yueling_tg/pkg/textmatch"
 )
 
 var _ plugin.Plugin = (*BanwordPlugin)(nil)
@@ -24,27 +30,80 @@ type GroupBanwords struct {
 }
 
 type BanwordDB struct {
-	Groups map[int64][]string `json:"groups"` // group_id -> keywords
-	mu     sync.RWMutex       `json:"-"`
+	Groups        map[int64][]string            `json:"groups"`                  // group_id -> keywords
+	Actions       map[int64]map[string][]string `json:"actions,omitempty"`       // group_id -> keyword -> 动作，未设置时使用默认动作
+	Lists         map[string]*SharedList        `json:"lists,omitempty"`         // 共享词库名称 -> 词库
+	Subscriptions map[int64][]string            `json:"subscriptions,omitempty"` // group_id -> 订阅的词库名称
+	mu            sync.RWMutex                  `json:"-"`
+
+	// matchers 每个群组生效的屏蔽词编译后的匹配器，屏蔽词、词库或订阅变化时丢弃，使用时重建
+	matchers map[int64]*textmatch.Matcher
+}
+
+// newBanwordDB 创建空数据库
+func newBanwordDB() *BanwordDB {
+	return &BanwordDB{
+		Groups:        make(map[int64][]string),
+		Actions:       make(map[int64]map[string][]string),
+		Lists:         make(map[string]*SharedList),
+		Subscriptions: make(map[int64][]string),
+		matchers:      make(map[int64]*textmatch.Matcher),
+	}
+}
+
+// setActions 设置屏蔽词的动作，specs 为空时恢复默认动作（需在写锁内调用）
+func (db *BanwordDB) setActions(groupID int64, keyword string, specs []string) {
+	if len(specs) == 0 {
+		delete(db.Actions[groupID], keyword)
+		if len(db.Actions[groupID]) == 0 {
+			delete(db.Actions, groupID)
+		}
+		return
+	}
+	if db.Actions[groupID] == nil {
+		db.Actions[groupID] = make(map[string][]string)
+	}
+	db.Actions[groupID][keyword] = specs
 }
 
 // -------------------- 插件结构 --------------------
 
 type PluginConfig struct {
-	DBPath string `mapstructure:"db_path"`
+	DBPath       string              `mapstructure:"db_path" doc:"屏蔽词数据文件路径" validate:"required"`
+	Warn         bool                `mapstructure:"warn" doc:"命中屏蔽词时警告发送者，等同于在默认动作中加入 warn（保留用于兼容）"`
+	Actions      []string            `mapstructure:"actions" doc:"默认动作：delete、notice、warn、mute[:时长]、kick、report"`
+	ChatActions  map[string][]string `mapstructure:"chat_actions" doc:"按会话 ID 覆盖默认动作，如 chat_actions.\"-1001234567890\" = [\"delete\", \"warn\"]"`
+	ExemptAdmins bool                `mapstructure:"exempt_admins" doc:"不检查群主与管理员的消息（无论是否开启，管理员都不会被警告、禁言或踢出）"`
+	TrustedUsers []int64             `mapstructure:"trusted_users" doc:"不检查的用户 ID"`
+	ExemptChats  []int64             `mapstructure:"exempt_chats" doc:"不检查的会话 ID"`
+	CheckEdits   bool                `mapstructure:"check_edits" doc:"检查编辑后的消息"`
+	ReportChat   int64               `mapstructure:"report_chat" doc:"report 动作发送到的会话 ID，0 表示私聊群内管理员"`
+	Superusers   []int64             `mapstructure:"superusers" doc:"可以管理全局词库与所有共享词库的用户 ID"`
+}
+
+// Validate 检查动作写法
+func (c *PluginConfig) Validate() error {
+	if _, err := parseActions(c.Actions); err != nil {
+		return fmt.Errorf("actions: %w", err)
+	}
+	for chat, specs := range c.ChatActions {
+		if _, err := parseActions(specs); err != nil {
+			return fmt.Errorf("chat_actions.%s: %w", chat, err)
+		}
+	}
+	return nil
 }
 
 type BanwordPlugin struct {
 	*plugin.Base
 	db     *BanwordDB
 	config PluginConfig
+	cases  *moderation.Store
 }
 
 func New() plugin.Plugin {
 	bp := &BanwordPlugin{
-		db: &BanwordDB{
-			Groups: make(map[int64][]string),
-		},
+		db: newBanwordDB(),
 	}
 
 	// 插件信息
@@ -52,34 +111,58 @@ func New() plugin.Plugin {
 		ID:          "banword",
 		Name:        "关键词屏蔽",
 		Description: "管理群组屏蔽关键词的插件",
-		Version:     "1.0.0",
+		Version:     "1.3.0",
 		Author:      "月离",
-		Usage:       "添加屏蔽 <关键词1> [关键词2...]\n删除屏蔽 <关键词1> [关键词2...]\n查看屏蔽",
-		Group:       "群管",
-		Extra:       make(map[string]any),
+		Usage: "添加屏蔽 [动作=delete,mute:1h...] <关键词1> [关键词2...]\n删除屏蔽 <关键词1> [关键词2...]\n查看屏蔽\n" +
+			"匹配方式：关键词（子串）、py:关键词（同时按拼音）、word:单词（整词）、re:正则、glob:通配符\n" +
+			"动作：delete 删除、notice 提醒、warn 警告、mute[:时长] 禁言、kick 踢出、report 通知管理员，未指定时使用默认动作\n" +
+			"共享词库：屏蔽词库 / 查看词库 <名称> / 创建词库 <名称> / 删除词库 <名称>\n" +
+			"词库添加 <名称> [动作=...] <关键词...> / 词库删除 <名称> <关键词...>\n" +
+			"订阅词库 <名称...> / 退订词库 <名称...>（global 为全局词库，对所有群生效）\n" +
+			"导出屏蔽 [词库名称] [json|txt] / 导入屏蔽 [词库名称]（回复 .json 或 .txt 文件）",
+		Group: "群管",
+		Extra: make(map[string]any),
 	}
 
 	// 默认配置
+	pctx := plugin.NewPluginContext(info.ID)
+	bp.cases = moderation.For(pctx.Config())
 	defaultCfg := PluginConfig{
-		DBPath: "./data/banword.json",
+		DBPath:     pctx.DataDir("banword.json"),
+		Actions:    []string{string(actionDelete)},
+		CheckEdits: true,
 	}
 
 	// 加载或创建配置
 	if err := config.GetPluginConfigOrDefault(info.ID, &bp.config, defaultCfg); err != nil {
 		panic(fmt.Sprintf("加载插件配置失败: %v", err))
 	}
+	pctx.Migrate(config.DataPath("banword.json"), bp.config.DBPath)
 
 	// 初始化 Builder
-	builder := plugin.New().Info(info)
+	builder := plugin.New().Info(info).Context(pctx)
 
 	// 消息预处理（最高优先级，用于拦截屏蔽词）
 	builder.OnMessage().Priority(100).Do(bp.handleMessageCheck)
 
-	// 管理命令
-	builder.OnCommand("添加屏蔽").Priority(10).Do(bp.handleAddBanword)
-	builder.OnCommand("删除屏蔽", "取消屏蔽").Priority(10).Do(bp.handleDeleteBanword)
+	// 管理命令，屏蔽词可以设置禁言、踢出等动作，只允许管理员修改
+	builder.OnCommand("添加屏蔽").When(permission.GroupAdminOrOwner()).Priority(10).Do(bp.handleAddBanword)
+	builder.OnCommand("删除屏蔽", "取消屏蔽").When(permission.GroupAdminOrOwner()).Priority(10).Do(bp.handleDeleteBanword)
 	builder.OnCommand("查看屏蔽").Priority(10).Do(bp.handleListBanword)
 
+	// 共享词库，修改权限在处理器中按创建者与超级用户检查
+	admin := condition.Any(permission.SuperUser(bp.config.Superusers...), permission.GroupAdminOrOwner())
+	builder.OnCommand("屏蔽词库").Priority(10).Do(bp.handleLists)
+	builder.OnCommand("查看词库").Priority(10).Do(bp.handleShowList)
+	builder.OnCommand("创建词库").When(admin).Priority(10).Do(bp.handleCreateList)
+	builder.OnCommand("删除词库").Priority(10).Do(bp.handleDeleteList)
+	builder.OnCommand("词库添加").Priority(10).Do(bp.handleListAdd)
+	builder.OnCommand("词库删除").Priority(10).Do(bp.handleListRemove)
+	builder.OnCommand("订阅词库").When(admin).Priority(10).Do(bp.handleSubscribe)
+	builder.OnCommand("退订词库", "取消订阅词库").When(admin).Priority(10).Do(bp.handleUnsubscribe)
+	builder.OnCommand("导出屏蔽").Priority(10).Do(bp.handleExport)
+	builder.OnCommand("导入屏蔽").Priority(10).Do(bp.handleImport)
+
 	// 返回插件，并注入 Base
 	return builder.Go(bp)
 }
@@ -100,45 +183,54 @@ func (bp *BanwordPlugin) Init() error {
 
 // -------------------- 处理器 --------------------
 
-// handleMessageCheck 检查消息是否包含屏蔽词
+// handleMessageCheck 检查消息（含媒体说明与编辑后的消息）是否包含屏蔽词，命中时执行对应的动作
 func (bp *BanwordPlugin) handleMessageCheck(ctx *context.Context) {
 	// 只处理群组消息
 	if ctx.GetChat().Type != "group" && ctx.GetChat().Type != "supergroup" {
 		return
 	}
+	if ctx.IsEditedMessage() && !bp.config.CheckEdits {
+		return
+	}
 
 	groupID := ctx.GetChat().ID
-	message := strings.ToLower(strings.TrimSpace(ctx.GetMessageText()))
+	if slices.Contains(bp.config.ExemptChats, groupID) || slices.Contains(bp.config.TrustedUsers, ctx.GetUserID()) {
+		return
+	}
+
+	message := strings.TrimSpace(ctx.GetMessageText() + "\n" + ctx.GetCaption())
 
 	if message == "" {
 		return
 	}
 
-	bp.db.mu.RLock()
-	keywords, exists := bp.db.Groups[groupID]
-	bp.db.mu.RUnlock()
+	matcher, err := bp.db.matcher(groupID)
+	if err != nil {
+		bp.Logger(ctx).Warn().Err(err).Int64("group_id", groupID).Msg("部分屏蔽词无效，已跳过")
+	}
 
-	if !exists || len(keywords) == 0 {
+	// 文本与媒体说明规范化后匹配
+	rule, ok := matcher.Match(message)
+	if !ok {
 		return
 	}
+	keyword := rule.String()
 
-	// 检查是否包含屏蔽词
-	for _, keyword := range keywords {
-		if strings.Contains(message, strings.ToLower(keyword)) {
-			// 删除消息
-			if err := ctx.DeleteMessage(ctx.GetMessageID()); err != nil {
-				bp.Log.Error().Err(err).Msg("删除消息失败")
-			} else {
-				bp.Log.Info().
-					Int64("group_id", groupID).
-					Int64("user_id", ctx.GetUserID()).
-					Str("keyword", keyword).
-					Msg("检测到屏蔽词，已删除消息")
-			}
-
-			return
-		}
+	// 只在命中后查询管理员身份
+	if bp.config.ExemptAdmins && ctx.IsAdmin() {
+		return
 	}
+
+	actions := bp.actionsFor(groupID, keyword)
+	bp.Logger(ctx).Info().
+		Int64("group_id", groupID).
+		Int64("user_id", ctx.GetUserID()).
+		Str("keyword", keyword).
+		Str("mode", rule.Mode.String()).
+		Str("actions", describeActions(actions)).
+		Msg("检测到屏蔽词")
+
+	bp.execute(ctx, keyword, actions)
 }
 
 // handleAddBanword 添加屏蔽词
@@ -156,6 +248,27 @@ func (bp *BanwordPlugin) handleAddBanword(ctx *context.Context, cmdCtx params.Co
 
 	groupID := ctx.GetChat().ID
 
+	// 可选的第一个参数指定这些屏蔽词的动作
+	args := make([]string, 0, cmdCtx.Args.Len())
+	for i := 0; i < cmdCtx.Args.Len(); i++ {
+		args = append(args, cmdCtx.Args.Get(i))
+	}
+	var specs []string
+	var actions []action
+	if spec, ok := cutActionArg(args[0]); ok {
+		specs = strings.Split(spec, ",")
+		var err error
+		if actions, err = parseActions(specs); err != nil {
+			ctx.Replyf("❌ %v", err)
+			return
+		}
+		if len(args) == 1 {
+			ctx.Reply("❌ 用法：添加屏蔽 [动作=delete,mute:1h...] <关键词1> [关键词2...]")
+			return
+		}
+		args = args[1:]
+	}
+
 	bp.db.mu.Lock()
 
 	// 获取或创建群组屏蔽词列表
@@ -164,19 +277,25 @@ func (bp *BanwordPlugin) handleAddBanword(ctx *context.Context, cmdCtx params.Co
 		groupKeywords = make([]string, 0)
 	}
 
-	// 添加新关键词（去重）
+	// 添加新关键词（去重），跳过无效的正则与通配符；已存在的关键词只更新动作
 	added := make([]string, 0)
-	for i := 0; i < cmdCtx.Args.Len(); i++ {
-		kw := strings.TrimSpace(cmdCtx.Args.Get(i))
+	invalid := make([]string, 0)
+	for _, arg := range args {
+		kw := strings.TrimSpace(arg)
 		if kw == "" {
 			continue
 		}
+		if _, err := textmatch.ParseRule(kw); err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
 
 		// 检查是否已存在
 		exists := false
 		for _, existing := range groupKeywords {
 			if strings.EqualFold(existing, kw) {
 				exists = true
+				kw = existing
 				break
 			}
 		}
@@ -185,29 +304,47 @@ func (bp *BanwordPlugin) handleAddBanword(ctx *context.Context, cmdCtx params.Co
 			groupKeywords = append(groupKeywords, kw)
 			added = append(added, kw)
 		}
+		if specs != nil {
+			bp.db.setActions(groupID, kw, specs)
+		} else if !exists {
+			bp.db.setActions(groupID, kw, nil)
+		}
 	}
 
 	bp.db.Groups[groupID] = groupKeywords
+	bp.db.invalidate(groupID)
 	bp.db.mu.Unlock()
 
 	// 保存到文件（在锁外执行）
 	if err := bp.saveData(); err != nil {
-		bp.Log.Error().Err(err).Msg("保存数据失败")
+		bp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
 		ctx.Reply("❌ 添加失败")
 		return
 	}
 
 	if len(added) == 0 {
+		if len(invalid) > 0 && len(invalid) == len(args) {
+			ctx.Replyf("❌ 关键词无效\n%s", strings.Join(invalid, "\n"))
+			return
+		}
+		if specs != nil {
+			ctx.Replyf("✅ 已更新动作：%s", describeActions(actions))
+			return
+		}
 		ctx.Reply("ℹ️ 所有关键词已存在")
 		return
 	}
 
-	bp.Log.Info().
+	bp.Logger(ctx).Info().
 		Int64("group_id", groupID).
 		Strs("keywords", added).
 		Msg("添加屏蔽词成功")
 
-	ctx.Replyf("✅ 添加屏蔽成功\n新增关键词: %s", strings.Join(added, ", "))
+	text := fmt.Sprintf("✅ 添加屏蔽成功\n新增关键词: %s\n动作: %s", strings.Join(added, ", "), describeActions(bp.actionsFor(groupID, added[0])))
+	if len(invalid) > 0 {
+		text += "\n⚠️ 已跳过无效关键词\n" + strings.Join(invalid, "\n")
+	}
+	ctx.Reply(text)
 }
 
 // handleDeleteBanword 删除屏蔽词
@@ -245,6 +382,7 @@ func (bp *BanwordPlugin) handleDeleteBanword(ctx *context.Context, cmdCtx params
 			if strings.EqualFold(existing, kw) {
 				shouldDelete = true
 				deleted = append(deleted, existing)
+				bp.db.setActions(groupID, existing, nil)
 				break
 			}
 		}
@@ -259,12 +397,13 @@ func (bp *BanwordPlugin) handleDeleteBanword(ctx *context.Context, cmdCtx params
 	if len(newKeywords) == 0 {
 		delete(bp.db.Groups, groupID)
 	}
+	bp.db.invalidate(groupID)
 
 	bp.db.mu.Unlock()
 
 	// 保存到文件（在锁外执行）
 	if err := bp.saveData(); err != nil {
-		bp.Log.Error().Err(err).Msg("保存数据失败")
+		bp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
 		ctx.Reply("❌ 删除失败")
 		return
 	}
@@ -274,7 +413,7 @@ func (bp *BanwordPlugin) handleDeleteBanword(ctx *context.Context, cmdCtx params
 		return
 	}
 
-	bp.Log.Info().
+	bp.Logger(ctx).Info().
 		Int64("group_id", groupID).
 		Strs("keywords", deleted).
 		Msg("删除屏蔽词成功")
@@ -293,26 +432,55 @@ func (bp *BanwordPlugin) handleListBanword(ctx *context.Context) {
 	groupID := ctx.GetChat().ID
 
 	bp.db.mu.RLock()
-	keywords, exists := bp.db.Groups[groupID]
+	keywords := append([]string(nil), bp.db.Groups[groupID]...)
+	custom := make(map[string]bool, len(bp.db.Actions[groupID]))
+	for kw := range bp.db.Actions[groupID] {
+		custom[kw] = true
+	}
+	var shared []string
+	for _, l := range bp.db.lists(groupID) {
+		shared = append(shared, fmt.Sprintf("%s(%d)", l.Name, len(l.Keywords)))
+	}
 	bp.db.mu.RUnlock()
 
-	if !exists || len(keywords) == 0 {
+	// 单独设置了动作的屏蔽词在后面标注动作
+	for i, kw := range keywords {
+		if custom[kw] {
+			keywords[i] = fmt.Sprintf("%s → %s", kw, describeActions(bp.actionsFor(groupID, kw)))
+		}
+	}
+
+	if len(keywords) == 0 && len(shared) == 0 {
 		ctx.Reply("📝 当前群组没有屏蔽词")
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("🚫 当前群组屏蔽词列表 (共 %d 个):\n\n", len(keywords)))
-
-	for i, kw := range keywords {
-		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, kw))
+	// 共享词库的内容通过 查看词库 查看，这里只列出名称
+	header := ""
+	if len(shared) > 0 {
+		header = "📚 同时生效的词库：" + strings.Join(shared, "、") + "\n"
 	}
+	defaults := describeActions(bp.actionsFor(groupID, ""))
+	paginator.New(paginator.FromSlice(keywords), func(page paginator.Page[string]) string {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("🚫 当前群组屏蔽词列表 (共 %d 个，默认动作：%s):\n", page.Count, defaults))
+		sb.WriteString(header + "\n")
+
+		for i, kw := range page.Items {
+			sb.WriteString(fmt.Sprintf("%d. %s\n", page.Offset+i+1, kw))
+		}
 
-	ctx.Reply(sb.String())
+		return sb.String()
+	}).PageSize(20).Reply(ctx)
 }
 
 // -------------------- 数据管理 --------------------
 
+// DataPaths 实现 plugin.PluginDataProvider
+func (bp *BanwordPlugin) DataPaths() []string {
+	return []string{bp.config.DBPath}
+}
+
 // loadData 从文件加载数据
 func (bp *BanwordPlugin) loadData() error {
 	// 检查路径是否有效
@@ -336,6 +504,17 @@ func (bp *BanwordPlugin) loadData() error {
 	if err := json.Unmarshal(data, bp.db); err != nil {
 		return err
 	}
+	// 旧数据文件没有的字段
+	if bp.db.Actions == nil {
+		bp.db.Actions = make(map[int64]map[string][]string)
+	}
+	if bp.db.Lists == nil {
+		bp.db.Lists = make(map[string]*SharedList)
+	}
+	if bp.db.Subscriptions == nil {
+		bp.db.Subscriptions = make(map[int64][]string)
+	}
+	bp.db.invalidateAll()
 
 	return nil
 }
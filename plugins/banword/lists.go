@@ -0,0 +1,523 @@
+package banword
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/paginator"
+	"yueling_tg/pkg/plugin/params"
+	"yueling_tg/pkg/textmatch"
+)
+
+// -------------------- 共享词库 --------------------
+
+// GlobalList 全局词库的名称，对所有群组生效，只有超级用户可以修改
+const GlobalList = "global"
+
+// SharedList 共享词库，群组订阅后与本群屏蔽词一起生效
+type SharedList struct {
+	Name      string              `json:"name"`
+	Keywords  []string            `json:"keywords"`
+	Actions   map[string][]string `json:"actions,omitempty"` // keyword -> 动作，未设置时使用订阅群组的默认动作
+	CreatedBy int64               `json:"created_by,omitempty"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+// add 添加屏蔽词（忽略大小写去重），specs 不为空时同时设置动作，返回新增的屏蔽词
+func (l *SharedList) add(keywords []string, specs []string) []string {
+	added := make([]string, 0)
+	for _, kw := range keywords {
+		if i := indexFold(l.Keywords, kw); i >= 0 {
+			kw = l.Keywords[i]
+		} else {
+			l.Keywords = append(l.Keywords, kw)
+			added = append(added, kw)
+		}
+		if len(specs) > 0 {
+			if l.Actions == nil {
+				l.Actions = make(map[string][]string)
+			}
+			l.Actions[kw] = specs
+		}
+	}
+	l.UpdatedAt = time.Now()
+	return added
+}
+
+// remove 删除屏蔽词，返回删除的屏蔽词
+func (l *SharedList) remove(keywords []string) []string {
+	deleted := make([]string, 0)
+	l.Keywords = slices.DeleteFunc(l.Keywords, func(existing string) bool {
+		if indexFold(keywords, existing) < 0 {
+			return false
+		}
+		deleted = append(deleted, existing)
+		delete(l.Actions, existing)
+		return true
+	})
+	l.UpdatedAt = time.Now()
+	return deleted
+}
+
+// indexFold 忽略大小写查找
+func indexFold(list []string, s string) int {
+	return slices.IndexFunc(list, func(item string) bool { return strings.EqualFold(item, s) })
+}
+
+// lists 群组生效的共享词库：全局词库与订阅的词库（需持有锁）
+func (db *BanwordDB) lists(groupID int64) []*SharedList {
+	lists := make([]*SharedList, 0, len(db.Subscriptions[groupID])+1)
+	if l, ok := db.Lists[GlobalList]; ok {
+		lists = append(lists, l)
+	}
+	for _, name := range db.Subscriptions[groupID] {
+		if l, ok := db.Lists[name]; ok && name != GlobalList {
+			lists = append(lists, l)
+		}
+	}
+	return lists
+}
+
+// effective 群组生效的屏蔽词：本群的屏蔽词与共享词库的并集（需持有锁）
+func (db *BanwordDB) effective(groupID int64) []string {
+	seen := make(map[string]bool)
+	keywords := make([]string, 0, len(db.Groups[groupID]))
+	appendAll := func(list []string) {
+		for _, kw := range list {
+			if !seen[kw] {
+				seen[kw] = true
+				keywords = append(keywords, kw)
+			}
+		}
+	}
+
+	appendAll(db.Groups[groupID])
+	for _, l := range db.lists(groupID) {
+		appendAll(l.Keywords)
+	}
+	return keywords
+}
+
+// keywordActions 屏蔽词设置的动作，本群的设置优先于共享词库，都没有时返回空
+func (db *BanwordDB) keywordActions(groupID int64, keyword string) []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if specs, ok := db.Actions[groupID][keyword]; ok {
+		return specs
+	}
+	for _, l := range db.lists(groupID) {
+		if specs, ok := l.Actions[keyword]; ok {
+			return specs
+		}
+	}
+	return nil
+}
+
+// matcher 返回群组的匹配器，屏蔽词变化后首次使用时重新编译；
+// 重新编译时有无效的规则则同时返回错误，无效规则被跳过
+func (db *BanwordDB) matcher(groupID int64) (*textmatch.Matcher, error) {
+	db.mu.RLock()
+	m, ok := db.matchers[groupID]
+	db.mu.RUnlock()
+	if ok {
+		return m, nil
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if m, ok := db.matchers[groupID]; ok {
+		return m, nil
+	}
+	m, err := textmatch.Compile(db.effective(groupID))
+	db.matchers[groupID] = m
+	return m, err
+}
+
+// invalidate 群组的屏蔽词或订阅变化后丢弃其匹配器（需在写锁内调用）
+func (db *BanwordDB) invalidate(groupID int64) {
+	delete(db.matchers, groupID)
+}
+
+// invalidateAll 共享词库变化后丢弃所有匹配器（需在写锁内调用）
+func (db *BanwordDB) invalidateAll() {
+	clear(db.matchers)
+}
+
+// -------------------- 权限 --------------------
+
+// isSuperuser 是否为配置的超级用户
+func (bp *BanwordPlugin) isSuperuser(userID int64) bool {
+	return slices.Contains(bp.config.Superusers, userID)
+}
+
+// canEdit 是否可以修改词库：全局词库只有超级用户可以修改，其他词库为创建者或超级用户
+func (bp *BanwordPlugin) canEdit(l *SharedList, userID int64) bool {
+	if bp.isSuperuser(userID) {
+		return true
+	}
+	return l.Name != GlobalList && l.CreatedBy == userID
+}
+
+// -------------------- 命令处理 --------------------
+
+// listArgs 命令参数
+func listArgs(cmdCtx params.CommandContext) []string {
+	args := make([]string, 0, cmdCtx.Args.Len())
+	for i := 0; i < cmdCtx.Args.Len(); i++ {
+		if arg := strings.TrimSpace(cmdCtx.Args.Get(i)); arg != "" {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// handleCreateList 创建共享词库
+func (bp *BanwordPlugin) handleCreateList(ctx *context.Context, cmdCtx params.CommandContext) {
+	args := listArgs(cmdCtx)
+	if len(args) != 1 {
+		ctx.Reply("❌ 用法：创建词库 <名称>")
+		return
+	}
+	name := strings.ToLower(args[0])
+	if name == GlobalList && !bp.isSuperuser(ctx.GetUserID()) {
+		ctx.Reply("❌ 只有超级用户可以管理全局词库")
+		return
+	}
+
+	bp.db.mu.Lock()
+	if _, exists := bp.db.Lists[name]; exists {
+		bp.db.mu.Unlock()
+		ctx.Replyf("ℹ️ 词库 %s 已存在", name)
+		return
+	}
+	bp.db.Lists[name] = &SharedList{Name: name, CreatedBy: ctx.GetUserID(), UpdatedAt: time.Now()}
+	bp.db.mu.Unlock()
+
+	if err := bp.saveData(); err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+		ctx.Reply("❌ 创建失败")
+		return
+	}
+	ctx.Replyf("✅ 已创建词库 %s\n使用「词库添加 %s <关键词...>」添加屏蔽词，群组使用「订阅词库 %s」订阅", name, name, name)
+}
+
+// handleDeleteList 删除共享词库，同时取消所有群组的订阅
+func (bp *BanwordPlugin) handleDeleteList(ctx *context.Context, cmdCtx params.CommandContext) {
+	args := listArgs(cmdCtx)
+	if len(args) != 1 {
+		ctx.Reply("❌ 用法：删除词库 <名称>")
+		return
+	}
+	name := strings.ToLower(args[0])
+
+	bp.db.mu.Lock()
+	l, ok := bp.db.Lists[name]
+	if !ok {
+		bp.db.mu.Unlock()
+		ctx.Replyf("❌ 词库 %s 不存在", name)
+		return
+	}
+	if !bp.canEdit(l, ctx.GetUserID()) {
+		bp.db.mu.Unlock()
+		ctx.Reply("❌ 只有词库的创建者或超级用户可以删除词库")
+		return
+	}
+	delete(bp.db.Lists, name)
+	for groupID, names := range bp.db.Subscriptions {
+		bp.db.setSubscriptions(groupID, slices.DeleteFunc(names, func(n string) bool { return n == name }))
+	}
+	bp.db.invalidateAll()
+	bp.db.mu.Unlock()
+
+	if err := bp.saveData(); err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+		ctx.Reply("❌ 删除失败")
+		return
+	}
+	ctx.Replyf("✅ 已删除词库 %s", name)
+}
+
+// handleListAdd 向共享词库添加屏蔽词
+func (bp *BanwordPlugin) handleListAdd(ctx *context.Context, cmdCtx params.CommandContext) {
+	bp.editList(ctx, listArgs(cmdCtx), true)
+}
+
+// handleListRemove 从共享词库删除屏蔽词
+func (bp *BanwordPlugin) handleListRemove(ctx *context.Context, cmdCtx params.CommandContext) {
+	bp.editList(ctx, listArgs(cmdCtx), false)
+}
+
+// editList 添加或删除共享词库中的屏蔽词，args 为 <名称> [动作=...] <关键词...>
+func (bp *BanwordPlugin) editList(ctx *context.Context, args []string, add bool) {
+	usage := "❌ 用法：词库删除 <名称> <关键词...>"
+	if add {
+		usage = "❌ 用法：词库添加 <名称> [动作=delete,mute:1h...] <关键词...>"
+	}
+	if len(args) < 2 {
+		ctx.Reply(usage)
+		return
+	}
+	name, args := strings.ToLower(args[0]), args[1:]
+
+	var specs []string
+	if spec, ok := cutActionArg(args[0]); ok && add {
+		specs = strings.Split(spec, ",")
+		if _, err := parseActions(specs); err != nil {
+			ctx.Replyf("❌ %v", err)
+			return
+		}
+		args = args[1:]
+		if len(args) == 0 {
+			ctx.Reply(usage)
+			return
+		}
+	}
+
+	keywords, invalid := validKeywords(args)
+	if add && len(keywords) == 0 {
+		ctx.Replyf("❌ 关键词无效\n%s", strings.Join(invalid, "\n"))
+		return
+	}
+
+	bp.db.mu.Lock()
+	l, ok := bp.db.Lists[name]
+	if !ok && name == GlobalList && bp.isSuperuser(ctx.GetUserID()) {
+		// 全局词库在第一次添加时创建
+		l = &SharedList{Name: GlobalList, CreatedBy: ctx.GetUserID()}
+		bp.db.Lists[name], ok = l, true
+	}
+	if !ok {
+		bp.db.mu.Unlock()
+		ctx.Replyf("❌ 词库 %s 不存在", name)
+		return
+	}
+	if !bp.canEdit(l, ctx.GetUserID()) {
+		bp.db.mu.Unlock()
+		ctx.Reply("❌ 只有词库的创建者或超级用户可以修改词库")
+		return
+	}
+
+	var changed []string
+	if add {
+		changed = l.add(keywords, specs)
+	} else {
+		changed = l.remove(args)
+	}
+	bp.db.invalidateAll()
+	bp.db.mu.Unlock()
+
+	if err := bp.saveData(); err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+		ctx.Reply("❌ 保存失败")
+		return
+	}
+
+	bp.Logger(ctx).Info().
+		Str("list", name).
+		Bool("add", add).
+		Strs("keywords", changed).
+		Msg("修改共享词库")
+
+	var text string
+	switch {
+	case add && len(changed) > 0:
+		text = fmt.Sprintf("✅ 词库 %s 新增: %s", name, strings.Join(changed, ", "))
+	case add && len(specs) > 0:
+		text = fmt.Sprintf("✅ 已更新词库 %s 中屏蔽词的动作", name)
+	case add:
+		text = "ℹ️ 所有关键词已存在"
+	case len(changed) > 0:
+		text = fmt.Sprintf("✅ 词库 %s 已删除: %s", name, strings.Join(changed, ", "))
+	default:
+		text = "ℹ️ 未找到要删除的关键词"
+	}
+	if add && len(invalid) > 0 {
+		text += "\n⚠️ 已跳过无效关键词\n" + strings.Join(invalid, "\n")
+	}
+	ctx.Reply(text)
+}
+
+// handleSubscribe 订阅或取消订阅共享词库
+func (bp *BanwordPlugin) handleSubscribe(ctx *context.Context, cmdCtx params.CommandContext) {
+	bp.subscribe(ctx, listArgs(cmdCtx), true)
+}
+
+// handleUnsubscribe 取消订阅共享词库
+func (bp *BanwordPlugin) handleUnsubscribe(ctx *context.Context, cmdCtx params.CommandContext) {
+	bp.subscribe(ctx, listArgs(cmdCtx), false)
+}
+
+// subscribe 修改本群订阅的词库
+func (bp *BanwordPlugin) subscribe(ctx *context.Context, args []string, on bool) {
+	if ctx.GetChat().Type != "group" && ctx.GetChat().Type != "supergroup" {
+		ctx.Reply("❌ 此命令只能在群组中使用")
+		return
+	}
+	if len(args) == 0 {
+		ctx.Reply("❌ 用法：订阅词库 <名称...> / 退订词库 <名称...>")
+		return
+	}
+	groupID := ctx.GetChat().ID
+
+	bp.db.mu.Lock()
+	subs := slices.Clone(bp.db.Subscriptions[groupID])
+	changed, missing := make([]string, 0), make([]string, 0)
+	for _, arg := range args {
+		name := strings.ToLower(arg)
+		if name == GlobalList {
+			continue // 全局词库始终生效
+		}
+		subscribed := slices.Contains(subs, name)
+		switch {
+		case on == subscribed:
+			// 已是目标状态
+		case on:
+			if _, ok := bp.db.Lists[name]; !ok {
+				missing = append(missing, name)
+				continue
+			}
+			subs = append(subs, name)
+			changed = append(changed, name)
+		default:
+			subs = slices.DeleteFunc(subs, func(n string) bool { return n == name })
+			changed = append(changed, name)
+		}
+	}
+	bp.db.setSubscriptions(groupID, subs)
+	bp.db.invalidate(groupID)
+	bp.db.mu.Unlock()
+
+	if err := bp.saveData(); err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+		ctx.Reply("❌ 保存失败")
+		return
+	}
+
+	verb := "订阅"
+	if !on {
+		verb = "取消订阅"
+	}
+	var text string
+	if len(changed) > 0 {
+		text = fmt.Sprintf("✅ 已%s: %s", verb, strings.Join(changed, ", "))
+	} else {
+		text = fmt.Sprintf("ℹ️ 没有需要%s的词库", verb)
+	}
+	if len(missing) > 0 {
+		text += "\n❌ 词库不存在: " + strings.Join(missing, ", ")
+	}
+	ctx.Reply(text)
+}
+
+// setSubscriptions 设置群组订阅的词库，为空时删除条目（需在写锁内调用）
+func (db *BanwordDB) setSubscriptions(groupID int64, names []string) {
+	if len(names) == 0 {
+		delete(db.Subscriptions, groupID)
+		return
+	}
+	db.Subscriptions[groupID] = names
+}
+
+// handleLists 查看所有共享词库，在群组中标注本群是否订阅
+func (bp *BanwordPlugin) handleLists(ctx *context.Context) {
+	groupID := ctx.GetChat().ID
+
+	bp.db.mu.RLock()
+	names := make([]string, 0, len(bp.db.Lists))
+	for name := range bp.db.Lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		l := bp.db.Lists[name]
+		line := fmt.Sprintf("%s（%d 个）", name, len(l.Keywords))
+		switch {
+		case name == GlobalList:
+			line += " · 全局生效"
+		case slices.Contains(bp.db.Subscriptions[groupID], name):
+			line += " · ✅ 已订阅"
+		}
+		lines = append(lines, line)
+	}
+	bp.db.mu.RUnlock()
+
+	if len(lines) == 0 {
+		ctx.Reply("📝 还没有共享词库，使用「创建词库 <名称>」创建")
+		return
+	}
+
+	paginator.New(paginator.FromSlice(lines), func(page paginator.Page[string]) string {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "📚 共享词库 (共 %d 个):\n\n", page.Count)
+		for _, line := range page.Items {
+			sb.WriteString(line + "\n")
+		}
+		return sb.String()
+	}).PageSize(20).Reply(ctx)
+}
+
+// handleShowList 查看共享词库中的屏蔽词
+func (bp *BanwordPlugin) handleShowList(ctx *context.Context, cmdCtx params.CommandContext) {
+	args := listArgs(cmdCtx)
+	if len(args) != 1 {
+		ctx.Reply("❌ 用法：查看词库 <名称>")
+		return
+	}
+	name := strings.ToLower(args[0])
+
+	bp.db.mu.RLock()
+	l, ok := bp.db.Lists[name]
+	var keywords []string
+	if ok {
+		keywords = make([]string, len(l.Keywords))
+		for i, kw := range l.Keywords {
+			keywords[i] = kw
+			if specs, ok := l.Actions[kw]; ok {
+				if actions, err := parseActions(specs); err == nil {
+					keywords[i] = fmt.Sprintf("%s → %s", kw, describeActions(actions))
+				}
+			}
+		}
+	}
+	bp.db.mu.RUnlock()
+
+	if !ok {
+		ctx.Replyf("❌ 词库 %s 不存在", name)
+		return
+	}
+	if len(keywords) == 0 {
+		ctx.Replyf("📝 词库 %s 中没有屏蔽词", name)
+		return
+	}
+
+	paginator.New(paginator.FromSlice(keywords), func(page paginator.Page[string]) string {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "📚 词库 %s (共 %d 个):\n\n", name, page.Count)
+		for i, kw := range page.Items {
+			fmt.Fprintf(&sb, "%d. %s\n", page.Offset+i+1, kw)
+		}
+		return sb.String()
+	}).PageSize(20).Reply(ctx)
+}
+
+// validKeywords 拆分有效与无效的屏蔽词，无效的返回错误说明
+func validKeywords(args []string) (valid, invalid []string) {
+	for _, arg := range args {
+		kw := strings.TrimSpace(arg)
+		if kw == "" {
+			continue
+		}
+		if _, err := textmatch.ParseRule(kw); err != nil {
+			invalid = append(invalid, err.Error())
+			continue
+		}
+		valid = append(valid, kw)
+	}
+	return valid, invalid
+}
@@ -0,0 +1,156 @@
+package banword
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestParseListFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		want     listFile
+		wantErr  bool
+	}{
+		{
+			name:     "导出格式",
+			filename: "banword.json",
+			data:     `{"name":"ads","keywords":["广告","re:加.*群"],"actions":{"广告":["delete","warn"]}}`,
+			want:     listFile{Name: "ads", Keywords: []string{"广告", "re:加.*群"}, Actions: map[string][]string{"广告": {"delete", "warn"}}},
+		},
+		{"字符串数组", "list.json", ` ["a", "b"] `, listFile{Keywords: []string{"a", "b"}}, false},
+		{"无扩展名时按内容识别 JSON", "list", `["a"]`, listFile{Keywords: []string{"a"}}, false},
+		{"文本每行一个，跳过空行与注释", "list.txt", "# 注释\n广告\n\n  刷单  \r\n", listFile{Keywords: []string{"广告", "刷单"}}, false},
+		{".txt 不按 JSON 解析", "list.txt", `["a"]`, listFile{Keywords: []string{`["a"]`}}, false},
+		{"JSON 无效", "list.json", `{"keywords":`, listFile{}, true},
+		{"JSON 类型错误", "list.json", `[1, 2]`, listFile{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseListFile(tt.filename, []byte(tt.data))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	// 导出的 JSON 可以原样导入
+	file := listFile{Name: "ads", Keywords: []string{"广告"}, Actions: map[string][]string{"广告": {"mute:1h"}}}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseListFile("banword-ads-20240101.json", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, file) {
+		t.Errorf("round trip = %+v, want %+v", got, file)
+	}
+}
+
+func TestImportToGroup(t *testing.T) {
+	bp := &BanwordPlugin{db: newBanwordDB()}
+	bp.db.Groups[-100] = []string{"广告"}
+
+	added := bp.importToGroup(-100, []string{"广告", "刷单", "兼职"}, map[string][]string{"刷单": {"kick"}})
+	if !reflect.DeepEqual(added, []string{"刷单", "兼职"}) {
+		t.Errorf("added = %q", added)
+	}
+	if got := bp.db.Groups[-100]; !reflect.DeepEqual(got, []string{"广告", "刷单", "兼职"}) {
+		t.Errorf("keywords = %q", got)
+	}
+	if got := bp.db.keywordActions(-100, "刷单"); !reflect.DeepEqual(got, []string{"kick"}) {
+		t.Errorf("actions = %q", got)
+	}
+	if got := bp.importToGroup(-100, []string{"刷单"}, nil); len(got) != 0 {
+		t.Errorf("second import added %q", got)
+	}
+}
+
+func TestImportToList(t *testing.T) {
+	const owner, other, superuser = 1, 2, 9
+
+	tests := []struct {
+		name       string
+		list       string
+		user       int64
+		wantAdded  []string
+		wantReason bool
+	}{
+		{"创建者合并并设置动作", "ads", owner, []string{"刷单"}, false},
+		{"其他用户不能修改", "ads", other, nil, true},
+		{"超级用户可以修改", "ads", superuser, []string{"刷单"}, false},
+		{"词库不存在", "missing", owner, nil, true},
+		{"超级用户导入时创建全局词库", GlobalList, superuser, []string{"广告", "刷单"}, false},
+		{"普通用户不能创建全局词库", GlobalList, owner, nil, true},
+	}
+	for _, tt := range tests {
+		bp := &BanwordPlugin{db: newBanwordDB(), config: PluginConfig{Superusers: []int64{superuser}}}
+		bp.db.Lists["ads"] = &SharedList{Name: "ads", Keywords: []string{"广告"}, CreatedBy: owner}
+
+		added, reason := bp.importToList(tt.list, tt.user, []string{"广告", "刷单"}, map[string][]string{"广告": {"warn"}})
+		if (reason != "") != tt.wantReason {
+			t.Errorf("%s: reason = %q, want reason %v", tt.name, reason, tt.wantReason)
+			continue
+		}
+		if tt.wantReason {
+			continue
+		}
+		if !reflect.DeepEqual(added, tt.wantAdded) {
+			t.Errorf("%s: added = %q, want %q", tt.name, added, tt.wantAdded)
+		}
+		l := bp.db.Lists[tt.list]
+		if !reflect.DeepEqual(l.Actions["广告"], []string{"warn"}) {
+			t.Errorf("%s: actions = %v", tt.name, l.Actions)
+		}
+	}
+}
+
+func TestEffectiveKeywords(t *testing.T) {
+	db := newBanwordDB()
+	db.Groups[-100] = []string{"本群", "共有"}
+	db.Lists[GlobalList] = &SharedList{Name: GlobalList, Keywords: []string{"全局"}}
+	db.Lists["ads"] = &SharedList{Name: "ads", Keywords: []string{"广告", "共有"}, Actions: map[string][]string{"广告": {"kick"}}}
+	db.Subscriptions[-100] = []string{"ads", "missing", GlobalList}
+
+	if got := db.effective(-100); !reflect.DeepEqual(got, []string{"本群", "共有", "全局", "广告"}) {
+		t.Errorf("effective = %q", got)
+	}
+	if got := db.effective(-200); !reflect.DeepEqual(got, []string{"全局"}) {
+		t.Errorf("effective without subscriptions = %q", got)
+	}
+	if got := db.keywordActions(-100, "广告"); !slices.Equal(got, []string{"kick"}) {
+		t.Errorf("list actions = %q", got)
+	}
+
+	// 本群的动作优先于词库
+	db.setActions(-100, "广告", []string{"delete"})
+	if got := db.keywordActions(-100, "广告"); !slices.Equal(got, []string{"delete"}) {
+		t.Errorf("group actions = %q", got)
+	}
+}
+
+func TestSharedListEdit(t *testing.T) {
+	l := &SharedList{Name: "ads", Keywords: []string{"Spam"}}
+	if added := l.add([]string{"spam", "ads"}, []string{"warn"}); !reflect.DeepEqual(added, []string{"ads"}) {
+		t.Errorf("added = %q", added)
+	}
+	// 忽略大小写去重，动作设置在原有的写法上
+	if !reflect.DeepEqual(l.Actions, map[string][]string{"Spam": {"warn"}, "ads": {"warn"}}) {
+		t.Errorf("actions = %v", l.Actions)
+	}
+	if deleted := l.remove([]string{"SPAM", "missing"}); !reflect.DeepEqual(deleted, []string{"Spam"}) {
+		t.Errorf("deleted = %q", deleted)
+	}
+	if !reflect.DeepEqual(l.Keywords, []string{"ads"}) || len(l.Actions) != 1 {
+		t.Errorf("after remove: keywords %q, actions %v", l.Keywords, l.Actions)
+	}
+}
@@ -0,0 +1,288 @@
+package banword
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/internal/message"
+	"yueling_tg/pkg/plugin/params"
+
+	"github.com/mymmrac/telego"
+)
+
+// -------------------- 导入与导出 --------------------
+
+// maxImportSize 导入文件的大小上限
+const maxImportSize = 1 << 20
+
+// listFile 导出文件的 JSON 结构，导入时也接受字符串数组
+type listFile struct {
+	Name     string              `json:"name,omitempty"`
+	Keywords []string            `json:"keywords"`
+	Actions  map[string][]string `json:"actions,omitempty"`
+}
+
+// handleExport 导出本群的屏蔽词或共享词库：导出屏蔽 [名称] [json|txt]
+func (bp *BanwordPlugin) handleExport(ctx *context.Context, cmdCtx params.CommandContext) {
+	args := listArgs(cmdCtx)
+	format := "json"
+	if n := len(args); n > 0 && (strings.EqualFold(args[n-1], "json") || strings.EqualFold(args[n-1], "txt")) {
+		format, args = strings.ToLower(args[n-1]), args[:n-1]
+	}
+	if len(args) > 1 {
+		ctx.Reply("❌ 用法：导出屏蔽 [词库名称] [json|txt]")
+		return
+	}
+
+	var file listFile
+	bp.db.mu.RLock()
+	if len(args) == 1 {
+		l, ok := bp.db.Lists[strings.ToLower(args[0])]
+		if ok {
+			file = listFile{Name: l.Name, Keywords: l.Keywords, Actions: l.Actions}
+		}
+		bp.db.mu.RUnlock()
+		if !ok {
+			ctx.Replyf("❌ 词库 %s 不存在", args[0])
+			return
+		}
+	} else {
+		groupID := ctx.GetChat().ID
+		file = listFile{Keywords: bp.db.Groups[groupID], Actions: bp.db.Actions[groupID]}
+		bp.db.mu.RUnlock()
+		if ctx.GetChat().Type != "group" && ctx.GetChat().Type != "supergroup" {
+			ctx.Reply("❌ 请在群组中导出本群屏蔽词，或指定词库名称")
+			return
+		}
+	}
+
+	if len(file.Keywords) == 0 {
+		ctx.Reply("📝 没有可导出的屏蔽词")
+		return
+	}
+
+	var data []byte
+	if format == "txt" {
+		// 每行一个屏蔽词，动作无法在文本格式中保留
+		data = []byte(strings.Join(file.Keywords, "\n") + "\n")
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(file, "", "  "); err != nil {
+			bp.Logger(ctx).Error().Err(err).Msg("序列化屏蔽词失败")
+			ctx.Reply("❌ 导出失败")
+			return
+		}
+	}
+
+	name := file.Name
+	if name == "" {
+		name = fmt.Sprintf("group%d", ctx.GetChat().ID)
+	}
+	filename := fmt.Sprintf("banword-%s-%s.%s", name, time.Now().Format("20060102"), format)
+	caption := fmt.Sprintf("📤 共 %d 个屏蔽词\n回复此文件发送「导入屏蔽 [词库名称]」即可导入", len(file.Keywords))
+	if _, err := ctx.ReplyDocument(message.NewResourceFromBytesWithCaption(filename, data, caption)); err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("发送导出文件失败")
+		ctx.Reply("❌ 导出失败")
+	}
+}
+
+// handleImport 从回复的文档导入屏蔽词到本群或共享词库：导入屏蔽 [名称]
+func (bp *BanwordPlugin) handleImport(ctx *context.Context, cmdCtx params.CommandContext) {
+	args := listArgs(cmdCtx)
+	if len(args) > 1 {
+		ctx.Reply("❌ 用法：回复 .json 或 .txt 文件发送「导入屏蔽 [词库名称]」")
+		return
+	}
+
+	doc := replyDocument(ctx)
+	if doc == nil {
+		ctx.Reply("❌ 请回复要导入的 .json 或 .txt 文件")
+		return
+	}
+	if doc.FileSize > maxImportSize {
+		ctx.Replyf("❌ 文件过大，最大 %d KB", maxImportSize>>10)
+		return
+	}
+
+	data, err := bp.download(ctx, doc.FileID)
+	if err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("下载导入文件失败")
+		ctx.Reply("❌ 下载文件失败")
+		return
+	}
+	file, err := parseListFile(doc.FileName, data)
+	if err != nil {
+		ctx.Replyf("❌ %v", err)
+		return
+	}
+
+	keywords, invalid := validKeywords(file.Keywords)
+	for kw, specs := range file.Actions {
+		if _, err := parseActions(specs); err != nil {
+			invalid = append(invalid, fmt.Sprintf("%s: %v", kw, err))
+			delete(file.Actions, kw)
+		}
+	}
+	if len(keywords) == 0 {
+		ctx.Reply("❌ 文件中没有有效的屏蔽词")
+		return
+	}
+
+	var added []string
+	if len(args) == 1 {
+		var reason string
+		if added, reason = bp.importToList(strings.ToLower(args[0]), ctx.GetUserID(), keywords, file.Actions); reason != "" {
+			ctx.Reply(reason)
+			return
+		}
+	} else {
+		if ctx.GetChat().Type != "group" && ctx.GetChat().Type != "supergroup" {
+			ctx.Reply("❌ 请在群组中导入本群屏蔽词，或指定词库名称")
+			return
+		}
+		if !ctx.IsAdmin() && !bp.isSuperuser(ctx.GetUserID()) {
+			ctx.Reply("❌ 只有管理员可以导入本群屏蔽词")
+			return
+		}
+		added = bp.importToGroup(ctx.GetChat().ID, keywords, file.Actions)
+	}
+
+	if err := bp.saveData(); err != nil {
+		bp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+		ctx.Reply("❌ 导入失败")
+		return
+	}
+
+	bp.Logger(ctx).Info().
+		Int("added", len(added)).
+		Int("invalid", len(invalid)).
+		Msg("导入屏蔽词")
+
+	text := fmt.Sprintf("✅ 导入完成：新增 %d 个，已存在 %d 个", len(added), len(keywords)-len(added))
+	if len(invalid) > 0 {
+		text += fmt.Sprintf("\n⚠️ 跳过 %d 个无效项\n%s", len(invalid), strings.Join(invalid[:min(len(invalid), 10)], "\n"))
+	}
+	ctx.Reply(text)
+}
+
+// importToGroup 合并到本群的屏蔽词，返回新增的屏蔽词
+func (bp *BanwordPlugin) importToGroup(groupID int64, keywords []string, actions map[string][]string) []string {
+	bp.db.mu.Lock()
+	defer bp.db.mu.Unlock()
+
+	added := make([]string, 0)
+	for _, kw := range keywords {
+		if indexFold(bp.db.Groups[groupID], kw) < 0 {
+			bp.db.Groups[groupID] = append(bp.db.Groups[groupID], kw)
+			added = append(added, kw)
+		}
+		if specs, ok := actions[kw]; ok {
+			bp.db.setActions(groupID, kw, specs)
+		}
+	}
+	bp.db.invalidate(groupID)
+	return added
+}
+
+// importToList 合并到共享词库，返回新增的屏蔽词；没有权限或词库不存在时返回提示
+func (bp *BanwordPlugin) importToList(name string, userID int64, keywords []string, actions map[string][]string) ([]string, string) {
+	bp.db.mu.Lock()
+	defer bp.db.mu.Unlock()
+
+	l, ok := bp.db.Lists[name]
+	if !ok && name == GlobalList && bp.isSuperuser(userID) {
+		l = &SharedList{Name: GlobalList, CreatedBy: userID}
+		bp.db.Lists[name], ok = l, true
+	}
+	if !ok {
+		return nil, fmt.Sprintf("❌ 词库 %s 不存在，请先使用「创建词库 %s」", name, name)
+	}
+	if !bp.canEdit(l, userID) {
+		return nil, "❌ 只有词库的创建者或超级用户可以修改词库"
+	}
+
+	added := l.add(keywords, nil)
+	for kw, specs := range actions {
+		if i := indexFold(l.Keywords, kw); i >= 0 {
+			l.add([]string{l.Keywords[i]}, specs)
+		}
+	}
+	bp.db.invalidateAll()
+	return added, ""
+}
+
+// replyDocument 消息本身或回复的消息中的文档
+func replyDocument(ctx *context.Context) *telego.Document {
+	msg := ctx.GetMessage()
+	if msg == nil {
+		return nil
+	}
+	if msg.Document != nil {
+		return msg.Document
+	}
+	if reply := msg.ReplyToMessage; reply != nil {
+		return reply.Document
+	}
+	return nil
+}
+
+// download 下载 Telegram 文件，最多读取 maxImportSize 字节
+func (bp *BanwordPlugin) download(ctx *context.Context, fileID string) ([]byte, error) {
+	url, err := ctx.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("获取文件信息失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx.Ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载失败: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxImportSize))
+}
+
+// parseListFile 解析导入文件：JSON 为导出格式或字符串数组，其他按每行一个屏蔽词解析（# 开头为注释）
+func parseListFile(filename string, data []byte) (listFile, error) {
+	trimmed := bytes.TrimSpace(data)
+	ext := strings.ToLower(filepath.Ext(filename))
+	sniffed := bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("["))
+	if ext == ".json" || (ext != ".txt" && sniffed) {
+		var file listFile
+		if bytes.HasPrefix(trimmed, []byte("[")) {
+			if err := json.Unmarshal(trimmed, &file.Keywords); err != nil {
+				return file, fmt.Errorf("解析 JSON 失败: %w", err)
+			}
+			return file, nil
+		}
+		if err := json.Unmarshal(trimmed, &file); err != nil {
+			return file, fmt.Errorf("解析 JSON 失败: %w", err)
+		}
+		return file, nil
+	}
+
+	var file listFile
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		file.Keywords = append(file.Keywords, line)
+	}
+	return file, scanner.Err()
+}
@@ -63,5 +63,5 @@ func (cp *CalculatorPlugin) calcHandler(ctx *context.Context, commandArgs params
 
 	// 返回结果
 	ctx.Replyf("🧮 计算结果: %v", result)
-	cp.Log.Info().Str("expression", exp).Msg("计算完成")
+	cp.Logger(ctx).Info().Str("expression", exp).Msg("计算完成")
 }
@@ -0,0 +1,428 @@
+package captcha
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/moderation"
+	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/plugin/dsl/permission"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+var _ plugin.Plugin = (*CaptchaPlugin)(nil)
+
+// -------------------- 插件结构 --------------------
+
+type PluginConfig struct {
+	Verify     Settings            `mapstructure:"verify" doc:"入群验证的默认设置"`
+	ChatVerify map[string]Settings `mapstructure:"chat_verify" doc:"按会话 ID 覆盖设置，如 [plugins.captcha.chat_verify.\"-1001234567890\"]"`
+}
+
+// Validate 检查答题时间
+func (c *PluginConfig) Validate() error {
+	if _, err := c.Verify.parse(); err != nil {
+		return fmt.Errorf("verify.%w", err)
+	}
+	for chat, override := range c.ChatVerify {
+		if _, err := c.Verify.merge(override).parse(); err != nil {
+			return fmt.Errorf("chat_verify.%s.%w", chat, err)
+		}
+	}
+	return nil
+}
+
+type CaptchaPlugin struct {
+	*plugin.Base
+	config PluginConfig
+	cases  *moderation.Store
+
+	// 解析后的设置，配置已在加载时校验
+	defaults settings
+	chats    map[int64]settings
+
+	pending *registry
+}
+
+func New() plugin.Plugin {
+	cp := &CaptchaPlugin{
+		chats:   make(map[int64]settings),
+		pending: newRegistry(),
+	}
+
+	info := &plugin.PluginInfo{
+		ID:          "captcha",
+		Name:        "入群验证",
+		Description: "新成员入群或申请入群时回答验证题，通过后解除限制或批准申请",
+		Version:     "1.0.0",
+		Author:      "月离",
+		Usage: "新成员入群后被禁言，需在限定时间内点击按钮作答（点选图标、算术题或图片验证码），超时或答错按设置踢出\n" +
+			"入群申请由 Bot 私聊发送题目，通过后批准，失败时拒绝\n" +
+			"管理员可以点击验证消息上的「放行」或「拒绝」直接处理\n" +
+			"入群验证：查看本群的验证设置（仅管理员）",
+		Group: "群管",
+		Extra: make(map[string]any),
+	}
+
+	pctx := plugin.NewPluginContext(info.ID)
+	cp.cases = moderation.For(pctx.Config())
+
+	defaultCfg := PluginConfig{Verify: DefaultSettings()}
+	if err := config.GetPluginConfigOrDefault(info.ID, &cp.config, defaultCfg); err != nil {
+		panic(fmt.Sprintf("加载插件配置失败: %v", err))
+	}
+
+	cp.defaults, _ = cp.config.Verify.parse()
+	for chat, override := range cp.config.ChatVerify {
+		chatID, err := strconv.ParseInt(chat, 10, 64)
+		if err != nil {
+			continue
+		}
+		cp.chats[chatID], _ = cp.config.Verify.merge(override).parse()
+	}
+
+	builder := plugin.New().Info(info).Context(pctx)
+
+	builder.OnNotice().Priority(120).Do(cp.handleMemberUpdate)
+	builder.OnCallback().Priority(120).Do(cp.handleJoinRequest)
+	builder.OnCallbackStartsWith(info.ID + ":").Priority(9).Do(cp.handleAnswer)
+	builder.OnCommand("入群验证").When(permission.GroupAdminOrOwner()).Priority(10).Do(cp.handleStatus)
+
+	return builder.Go(cp)
+}
+
+// settingsFor 会话生效的设置
+func (cp *CaptchaPlugin) settingsFor(chatID int64) settings {
+	if s, ok := cp.chats[chatID]; ok {
+		return s
+	}
+	return cp.defaults
+}
+
+// -------------------- 处理器 --------------------
+
+// handleMemberUpdate 成员加入时出题，验证期间离开时结束验证；需要 Bot 为管理员才能收到成员状态更新
+func (cp *CaptchaPlugin) handleMemberUpdate(ctx *context.Context) {
+	update := ctx.Update.ChatMember
+	if update == nil {
+		return
+	}
+	user := update.NewChatMember.MemberUser()
+	was, is := update.OldChatMember.MemberIsMember(), update.NewChatMember.MemberIsMember()
+
+	if was && !is {
+		if p, ok := cp.pending.find(update.Chat.ID, user.ID); ok {
+			if p, ok := cp.pending.take(p.id); ok {
+				cp.cleanup(p)
+			}
+		}
+		return
+	}
+	if was || !is || user.IsBot {
+		return
+	}
+
+	// 通过入群申请加入的已在申请时验证或由管理员批准
+	if update.ViaJoinRequest {
+		return
+	}
+	// 由管理员拉入的不需要验证
+	if update.From.ID != user.ID && ctx.IsChatAdmin(tu.ID(update.Chat.ID), update.From.ID) {
+		return
+	}
+
+	s := cp.settingsFor(update.Chat.ID)
+	if !s.enabled() {
+		return
+	}
+	if !ctx.CanBotRestrictMembers() {
+		cp.Logger(ctx).Warn().Int64("chat_id", update.Chat.ID).Msg("Bot 没有限制成员的权限，跳过入群验证")
+		return
+	}
+
+	p := &pending{
+		chatID:    update.Chat.ID,
+		chatTitle: update.Chat.Title,
+		user:      user,
+		msgChat:   update.Chat.ChatID(),
+		attempts:  s.attempts,
+		cfg:       s,
+		ctx:       detach(ctx),
+	}
+	if !cp.pending.add(p) {
+		return
+	}
+
+	if err := moderation.Mute(ctx, user.ID, s.muteDuration()); err != nil {
+		cp.Logger(ctx).Error().Err(err).Int64("user_id", user.ID).Msg("限制新成员失败")
+		cp.pending.take(p.id)
+		return
+	}
+	cp.ask(ctx, p, tu.Entity("👋 欢迎 "), tu.Entity(context.FullName(&user)).TextMention(&user), tu.Entity("，"))
+}
+
+// handleJoinRequest 私聊申请人出题，Bot 需要有邀请成员的权限
+func (cp *CaptchaPlugin) handleJoinRequest(ctx *context.Context) {
+	req := ctx.Update.ChatJoinRequest
+	if req == nil || req.From.IsBot {
+		return
+	}
+	s := cp.settingsFor(req.Chat.ID)
+	if !s.enabled() || !s.joinRequests {
+		return
+	}
+
+	p := &pending{
+		chatID:    req.Chat.ID,
+		chatTitle: req.Chat.Title,
+		user:      req.From,
+		request:   true,
+		msgChat:   tu.ID(req.UserChatID),
+		attempts:  s.attempts,
+		cfg:       s,
+		ctx:       detach(ctx),
+	}
+	if !cp.pending.add(p) {
+		return
+	}
+	cp.ask(ctx, p, tu.Entityf("👋 你申请加入「%s」，", req.Chat.Title))
+}
+
+// ask 出题并发送，开始计时；发送失败时结束验证
+func (cp *CaptchaPlugin) ask(ctx *context.Context, p *pending, greeting ...tu.MessageEntityCollection) {
+	c, err := newChallenge(p.cfg.mode)
+	if err != nil {
+		cp.Logger(ctx).Warn().Err(err).Msg("生成图片验证码失败，改用点选图标")
+		c = buttonChallenge()
+	}
+	p.answer = c.answer
+
+	// 按钮：每行三个选项，群组中另有管理员操作
+	buttons := make([]telego.InlineKeyboardButton, len(c.options))
+	for i, opt := range c.options {
+		buttons[i] = tu.InlineKeyboardButton(opt).WithCallbackData(cp.callbackData(p, strconv.Itoa(i)))
+	}
+	rows := tu.InlineKeyboardCols(3, buttons...)
+	if !p.request {
+		rows = append(rows, tu.InlineKeyboardRow(
+			tu.InlineKeyboardButton("✅ 放行").WithCallbackData(cp.callbackData(p, "pass")),
+			tu.InlineKeyboardButton("🚫 拒绝").WithCallbackData(cp.callbackData(p, "deny")),
+		))
+	}
+	keyboard := tu.InlineKeyboardGrid(rows)
+
+	text, entities := tu.MessageEntities(append(greeting,
+		tu.Entityf("请在 %s 内完成验证（可作答 %d 次）：\n%s", common.FormatDuration(p.cfg.timeout), p.attempts, c.question))...)
+
+	var msg *telego.Message
+	if c.image != nil {
+		msg, err = ctx.Api.SendPhoto(ctx.Ctx, tu.Photo(p.msgChat, tu.FileFromBytes(c.image, "captcha.png")).
+			WithCaption(text).WithCaptionEntities(entities...).WithReplyMarkup(keyboard))
+	} else {
+		msg, err = ctx.Api.SendMessage(ctx.Ctx, tu.Message(p.msgChat, text).
+			WithEntities(entities...).WithReplyMarkup(keyboard))
+	}
+	if err != nil {
+		cp.Logger(ctx).Error().Err(err).Int64("user_id", p.user.ID).Bool("request", p.request).Msg("发送验证题失败")
+		// 群组中无法出题时解除限制，入群申请留给管理员审批
+		if p, ok := cp.pending.take(p.id); ok && !p.request {
+			if err := moderation.Unmute(p.ctx, p.user.ID); err != nil {
+				cp.Logger(ctx).Error().Err(err).Msg("解除限制失败")
+			}
+		}
+		return
+	}
+
+	if !cp.pending.start(p, msg.MessageID, cp.expire) {
+		_ = ctx.Api.DeleteMessage(ctx.Ctx, tu.Delete(p.msgChat, msg.MessageID))
+		return
+	}
+	cp.Logger(ctx).Info().
+		Int64("chat_id", p.chatID).
+		Int64("user_id", p.user.ID).
+		Str("mode", p.cfg.mode).
+		Bool("request", p.request).
+		Msg("开始入群验证")
+}
+
+// handleAnswer 处理作答与管理员操作，回调数据为 captcha:<编号>:<选项|pass|deny>
+func (cp *CaptchaPlugin) handleAnswer(cmd string, ctx *context.Context) error {
+	parts := strings.Split(cmd, ":")
+	if len(parts) != 3 {
+		ctx.AnswerCallback("参数错误")
+		return nil
+	}
+	p, ok := cp.pending.get(parts[1])
+	if !ok {
+		ctx.AnswerCallback("验证已结束")
+		return nil
+	}
+
+	// 管理员放行或拒绝
+	if choice := parts[2]; choice == "pass" || choice == "deny" {
+		if p.request || !ctx.IsChatAdmin(tu.ID(p.chatID), ctx.GetUserID()) {
+			ctx.AnswerCallback("只有管理员可以操作")
+			return nil
+		}
+		if p, ok := cp.pending.take(p.id); ok {
+			if choice == "pass" {
+				ctx.AnswerCallback("✅ 已放行")
+				cp.pass(p)
+			} else {
+				ctx.AnswerCallback("🚫 已拒绝")
+				cp.fail(p, "管理员拒绝")
+			}
+		}
+		return nil
+	}
+
+	if ctx.GetUserID() != p.user.ID {
+		ctx.AnswerCallback("这不是你的验证题")
+		return nil
+	}
+	choice, err := strconv.Atoi(parts[2])
+	if err != nil {
+		ctx.AnswerCallback("参数错误")
+		return nil
+	}
+
+	if choice == p.answer {
+		if p, ok := cp.pending.take(p.id); ok {
+			ctx.AnswerCallback("✅ 验证通过")
+			cp.pass(p)
+		}
+		return nil
+	}
+	if left := cp.pending.wrong(p.id); left > 0 {
+		ctx.AnswerCallbackWithAlert(fmt.Sprintf("❌ 回答错误，还可以作答 %d 次", left))
+		return nil
+	}
+	if p, ok := cp.pending.take(p.id); ok {
+		ctx.AnswerCallback("❌ 回答错误次数过多")
+		cp.fail(p, "回答错误次数过多")
+	}
+	return nil
+}
+
+// handleStatus 查看本群的验证设置
+func (cp *CaptchaPlugin) handleStatus(ctx *context.Context) {
+	if !ctx.IsGroupChat() {
+		ctx.Reply("❌ 此命令只能在群组中使用")
+		return
+	}
+	text := cp.settingsFor(ctx.GetChat().ID).String()
+	if _, ok := cp.chats[ctx.GetChat().ID]; ok {
+		text += "\n（本群单独配置）"
+	}
+	ctx.Reply(text)
+}
+
+// -------------------- 结果 --------------------
+
+// expire 超时按验证失败处理
+func (cp *CaptchaPlugin) expire(id string) {
+	if p, ok := cp.pending.take(id); ok {
+		cp.fail(p, "验证超时")
+	}
+}
+
+// pass 解除限制或批准申请，并删除题目
+func (cp *CaptchaPlugin) pass(p *pending) {
+	cp.cleanup(p)
+
+	if p.request {
+		if err := p.ctx.Api.ApproveChatJoinRequest(p.ctx.Ctx, &telego.ApproveChatJoinRequestParams{
+			ChatID: tu.ID(p.chatID),
+			UserID: p.user.ID,
+		}); err != nil {
+			cp.Logger(p.ctx).Error().Err(err).Msg("批准入群申请失败")
+			return
+		}
+		cp.notify(p, fmt.Sprintf("✅ 验证通过，已批准加入「%s」", p.chatTitle))
+	} else if err := moderation.Unmute(p.ctx, p.user.ID); err != nil {
+		cp.Logger(p.ctx).Error().Err(err).Msg("解除限制失败")
+		return
+	}
+
+	cp.Logger(p.ctx).Info().
+		Int64("chat_id", p.chatID).
+		Int64("user_id", p.user.ID).
+		Bool("request", p.request).
+		Msg("入群验证通过")
+}
+
+// fail 拒绝申请，或按设置踢出、封禁或保持禁言，并删除题目
+func (cp *CaptchaPlugin) fail(p *pending, reason string) {
+	cp.cleanup(p)
+	cp.Logger(p.ctx).Info().
+		Int64("chat_id", p.chatID).
+		Int64("user_id", p.user.ID).
+		Str("reason", reason).
+		Str("action", p.cfg.failAction).
+		Msg("入群验证失败")
+
+	if p.request {
+		if err := p.ctx.Api.DeclineChatJoinRequest(p.ctx.Ctx, &telego.DeclineChatJoinRequestParams{
+			ChatID: tu.ID(p.chatID),
+			UserID: p.user.ID,
+		}); err != nil {
+			cp.Logger(p.ctx).Error().Err(err).Msg("拒绝入群申请失败")
+			return
+		}
+		cp.notify(p, fmt.Sprintf("❌ %s，加入「%s」的申请已被拒绝", reason, p.chatTitle))
+		return
+	}
+
+	cs := moderation.Case{
+		ChatID:     p.chatID,
+		TargetID:   p.user.ID,
+		TargetName: context.FullName(&p.user),
+		Reason:     "入群验证：" + reason,
+	}
+	var err error
+	switch p.cfg.failAction {
+	case failBan:
+		cs.Action, cs.Duration = moderation.ActionBan, common.Forever
+		err = moderation.Ban(p.ctx, p.user.ID, common.Forever)
+	case failMute:
+		// 验证期间的禁言会到期解除，失败时改为永久禁言
+		cs.Action, cs.Duration = moderation.ActionMute, common.Forever
+		err = moderation.Mute(p.ctx, p.user.ID, common.Forever)
+	default:
+		cs.Action = moderation.ActionKick
+		err = moderation.Kick(p.ctx, p.user.ID)
+	}
+	if err != nil {
+		cp.Logger(p.ctx).Error().Err(err).Msg("处理验证失败的成员失败")
+		return
+	}
+	cp.cases.RecordAuto(p.ctx, cs)
+}
+
+// cleanup 删除题目消息
+func (cp *CaptchaPlugin) cleanup(p *pending) {
+	if p.msgID == 0 {
+		return
+	}
+	if err := p.ctx.Api.DeleteMessage(p.ctx.Ctx, tu.Delete(p.msgChat, p.msgID)); err != nil {
+		cp.Logger(p.ctx).Debug().Err(err).Msg("删除验证消息失败")
+	}
+}
+
+// notify 私聊申请人验证结果
+func (cp *CaptchaPlugin) notify(p *pending, text string) {
+	if _, err := p.ctx.Api.SendMessage(p.ctx.Ctx, tu.Message(p.msgChat, text)); err != nil {
+		cp.Logger(p.ctx).Debug().Err(err).Msg("发送验证结果失败")
+	}
+}
+
+// callbackData 按钮的回调数据
+func (cp *CaptchaPlugin) callbackData(p *pending, choice string) string {
+	return fmt.Sprintf("%s:%s:%s", cp.PluginInfo().ID, p.id, choice)
+}
@@ -0,0 +1,224 @@
+package captcha
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+)
+
+// -------------------- 题目 --------------------
+
+// optionCount 每道题的选项数
+const optionCount = 6
+
+// challenge 一道题：提示、可选的图片与选项，answer 为正确选项的下标
+type challenge struct {
+	question string
+	image    []byte
+	options  []string
+	answer   int
+}
+
+// newChallenge 按验证方式出题
+func newChallenge(mode string) (challenge, error) {
+	switch mode {
+	case modeMath:
+		return mathChallenge(), nil
+	case modeImage:
+		return imageChallenge()
+	default:
+		return buttonChallenge(), nil
+	}
+}
+
+// icons 点选题的图标与名称
+var icons = [][2]string{
+	{"🍎", "苹果"}, {"🍌", "香蕉"}, {"🍇", "葡萄"}, {"🍉", "西瓜"}, {"🍓", "草莓"},
+	{"🍑", "桃子"}, {"🍒", "樱桃"}, {"🍍", "菠萝"}, {"🥕", "胡萝卜"}, {"🌽", "玉米"},
+	{"🐱", "猫"}, {"🐶", "狗"}, {"🐰", "兔子"}, {"🐼", "熊猫"}, {"🚗", "汽车"}, {"✈️", "飞机"},
+}
+
+// buttonChallenge 点选名称对应的图标
+func buttonChallenge() challenge {
+	picked := rand.Perm(len(icons))[:optionCount]
+	c := challenge{answer: rand.Intn(optionCount)}
+	for _, i := range picked {
+		c.options = append(c.options, icons[i][0])
+	}
+	c.question = fmt.Sprintf("请点击「%s」", icons[picked[c.answer]][1])
+	return c
+}
+
+// mathChallenge 20 以内的加减法或乘法口诀
+func mathChallenge() challenge {
+	var a, b, result int
+	var op string
+	switch rand.Intn(3) {
+	case 0:
+		a, b, op = rand.Intn(20)+1, rand.Intn(20)+1, "+"
+		result = a + b
+	case 1:
+		a, b, op = rand.Intn(20)+1, rand.Intn(20)+1, "-"
+		if a < b {
+			a, b = b, a
+		}
+		result = a - b
+	default:
+		a, b, op = rand.Intn(8)+2, rand.Intn(8)+2, "×"
+		result = a * b
+	}
+
+	// 干扰项取答案附近的不同数字
+	seen := map[int]bool{result: true}
+	values := []int{result}
+	for len(values) < optionCount {
+		v := result + rand.Intn(21) - 10
+		if v >= 0 && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return shuffled(fmt.Sprintf("请计算 %d %s %d = ?", a, op, b), values, strconv.Itoa)
+}
+
+// codeAlphabet 图片验证码的字符，去掉了容易混淆的 0O1IL2Z5S8B
+const codeAlphabet = "ACDEFGHJKMNPQRTUVWXY34679"
+
+// codeLength 图片验证码的长度
+const codeLength = 4
+
+// imageChallenge 从选项中选出图片中的验证码，干扰项与答案只差一到两个字符
+func imageChallenge() (challenge, error) {
+	randomCode := func() string {
+		b := make([]byte, codeLength)
+		for i := range b {
+			b[i] = codeAlphabet[rand.Intn(len(codeAlphabet))]
+		}
+		return string(b)
+	}
+
+	code := randomCode()
+	seen := map[string]bool{code: true}
+	values := []string{code}
+	for len(values) < optionCount {
+		b := []byte(code)
+		for n := rand.Intn(2) + 1; n > 0; n-- {
+			b[rand.Intn(codeLength)] = codeAlphabet[rand.Intn(len(codeAlphabet))]
+		}
+		if v := string(b); !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+
+	img, err := renderCode(code)
+	if err != nil {
+		return challenge{}, err
+	}
+	c := shuffled("请选择图片中的字符", values, func(s string) string { return s })
+	c.image = img
+	return c, nil
+}
+
+// shuffled 打乱选项，values[0] 为正确答案
+func shuffled[T any](question string, values []T, format func(T) string) challenge {
+	c := challenge{question: question}
+	for i, j := range rand.Perm(len(values)) {
+		c.options = append(c.options, format(values[j]))
+		if j == 0 {
+			c.answer = i
+		}
+	}
+	return c
+}
+
+// -------------------- 图片验证码 --------------------
+
+const (
+	codeWidth    = 240
+	codeHeight   = 90
+	codeFontSize = 52
+)
+
+var (
+	codeFontOnce sync.Once
+	codeFont     *truetype.Font
+	codeFontErr  error
+)
+
+// codeFace 图片验证码使用内置的 Go Bold 字体，不依赖资源目录
+func codeFace() (font.Face, error) {
+	codeFontOnce.Do(func() {
+		codeFont, codeFontErr = truetype.Parse(gobold.TTF)
+	})
+	if codeFontErr != nil {
+		return nil, codeFontErr
+	}
+	return truetype.NewFace(codeFont, &truetype.Options{Size: codeFontSize}), nil
+}
+
+// renderCode 绘制带干扰线与噪点的验证码图片
+func renderCode(code string) ([]byte, error) {
+	face, err := codeFace()
+	if err != nil {
+		return nil, fmt.Errorf("加载字体失败: %w", err)
+	}
+	defer face.Close()
+
+	dc := gg.NewContext(codeWidth, codeHeight)
+	dc.SetRGB(0.96, 0.95, 0.92)
+	dc.Clear()
+
+	// 噪点
+	for i := 0; i < 600; i++ {
+		dc.SetRGBA(rand.Float64(), rand.Float64(), rand.Float64(), 0.5)
+		dc.DrawPoint(rand.Float64()*codeWidth, rand.Float64()*codeHeight, 1)
+		dc.Fill()
+	}
+
+	// 逐个字符随机旋转与偏移
+	dc.SetFontFace(face)
+	step := float64(codeWidth) / float64(len(code)+1)
+	for i, ch := range strings.Split(code, "") {
+		x := step*float64(i+1) + (rand.Float64()-0.5)*10
+		y := codeHeight/2 + (rand.Float64()-0.5)*16
+		dc.Push()
+		dc.RotateAbout(gg.Radians((rand.Float64()-0.5)*50), x, y)
+		dc.SetRGB(rand.Float64()*0.5, rand.Float64()*0.5, rand.Float64()*0.5)
+		dc.DrawStringAnchored(ch, x, y, 0.5, 0.35)
+		dc.Pop()
+	}
+
+	// 穿过字符的干扰曲线
+	for i := 0; i < 3; i++ {
+		dc.SetRGBA(rand.Float64()*0.6, rand.Float64()*0.6, rand.Float64()*0.6, 0.8)
+		dc.SetLineWidth(1.5 + rand.Float64()*1.5)
+		amp, phase, freq := 8+rand.Float64()*14, rand.Float64()*math.Pi*2, 1+rand.Float64()*2
+		base := codeHeight/4 + rand.Float64()*codeHeight/2
+		for x := 0.0; x <= codeWidth; x += 4 {
+			y := base + amp*math.Sin(phase+freq*2*math.Pi*x/codeWidth)
+			if x == 0 {
+				dc.MoveTo(x, y)
+			} else {
+				dc.LineTo(x, y)
+			}
+		}
+		dc.Stroke()
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dc.Image()); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,67 @@
+package captcha
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"yueling_tg/pkg/moderation"
+)
+
+func TestMathChallenge(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		c := mathChallenge()
+		var a, b int
+		var op string
+		if _, err := fmt.Sscanf(c.question, "请计算 %d %s %d = ?", &a, &op, &b); err != nil {
+			t.Fatalf("unexpected question %q: %v", c.question, err)
+		}
+		want := map[string]int{"+": a + b, "-": a - b, "×": a * b}[op]
+		if got, _ := strconv.Atoi(c.options[c.answer]); got != want {
+			t.Fatalf("%s: answer option = %s, want %d", c.question, c.options[c.answer], want)
+		}
+		if want < 0 {
+			t.Fatalf("%s: negative answer", c.question)
+		}
+
+		seen := make(map[string]bool)
+		for _, o := range c.options {
+			if seen[o] {
+				t.Fatalf("%s: duplicate option %s in %v", c.question, o, c.options)
+			}
+			seen[o] = true
+		}
+		if len(c.options) != optionCount {
+			t.Fatalf("got %d options, want %d", len(c.options), optionCount)
+		}
+	}
+}
+
+func TestButtonChallenge(t *testing.T) {
+	c := buttonChallenge()
+	if len(c.options) != optionCount || c.answer < 0 || c.answer >= optionCount {
+		t.Fatalf("bad challenge %+v", c)
+	}
+	for _, icon := range icons {
+		if icon[0] == c.options[c.answer] && c.question != "请点击「"+icon[1]+"」" {
+			t.Errorf("question %q does not name the answer %s", c.question, icon[0])
+		}
+	}
+}
+
+func TestMuteDuration(t *testing.T) {
+	tests := []struct {
+		timeout time.Duration
+		want    time.Duration
+	}{
+		{10 * time.Second, 70 * time.Second},
+		{2 * time.Minute, 3 * time.Minute},
+		{400 * 24 * time.Hour, moderation.MaxDuration},
+	}
+	for _, tt := range tests {
+		if got := (settings{timeout: tt.timeout}).muteDuration(); got != tt.want {
+			t.Errorf("muteDuration(%v) = %v, want %v", tt.timeout, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package captcha
+
+import (
+	stdctx "context"
+	"strconv"
+	"sync"
+	"time"
+
+	"yueling_tg/internal/core/context"
+
+	"github.com/mymmrac/telego"
+)
+
+// -------------------- 进行中的验证 --------------------
+
+type userKey struct {
+	chatID, userID int64
+}
+
+// pending 一次进行中的验证
+type pending struct {
+	id        string
+	chatID    int64
+	chatTitle string
+	user      telego.User
+	request   bool          // 入群申请，题目私聊发送
+	msgChat   telego.ChatID // 题目所在的会话：群组或与用户的私聊
+	msgID     int
+	answer    int
+	attempts  int // 剩余作答次数
+	cfg       settings
+	timer     *time.Timer
+
+	// ctx 入群事件的上下文，不随处理器结束而取消，用于超时与点击按钮后的处理
+	ctx *context.Context
+}
+
+// registry 进行中的验证，只保存在内存中，并发安全
+type registry struct {
+	mu     sync.Mutex
+	byID   map[string]*pending
+	byUser map[userKey]*pending
+	next   uint64
+}
+
+func newRegistry() *registry {
+	return &registry{
+		byID:   make(map[string]*pending),
+		byUser: make(map[userKey]*pending),
+	}
+}
+
+// add 登记验证并分配编号，同一用户在同一群组已有验证时返回 false
+func (r *registry) add(p *pending) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := userKey{p.chatID, p.user.ID}
+	if _, ok := r.byUser[key]; ok {
+		return false
+	}
+	r.next++
+	p.id = strconv.FormatUint(r.next, 36)
+	r.byID[p.id] = p
+	r.byUser[key] = p
+	return true
+}
+
+// start 题目发送后记录消息并开始计时，超时调用 expire；
+// 题目发送期间验证已结束（如管理员已操作）时返回 false
+func (r *registry) start(p *pending, msgID int, expire func(id string)) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byID[p.id]; !ok {
+		return false
+	}
+	p.msgID = msgID
+	id := p.id
+	p.timer = time.AfterFunc(p.cfg.timeout, func() { expire(id) })
+	return true
+}
+
+// get 按编号查找
+func (r *registry) get(id string) (*pending, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.byID[id]
+	return p, ok
+}
+
+// find 按群组与用户查找
+func (r *registry) find(chatID, userID int64) (*pending, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.byUser[userKey{chatID, userID}]
+	return p, ok
+}
+
+// take 结束验证并停止计时；答题、超时与管理员操作同时发生时只有一方能取到
+func (r *registry) take(id string) (*pending, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.byID[id]
+	if !ok {
+		return nil, false
+	}
+	delete(r.byID, id)
+	delete(r.byUser, userKey{p.chatID, p.user.ID})
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	return p, true
+}
+
+// wrong 记录一次答错，返回剩余作答次数
+func (r *registry) wrong(id string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.byID[id]
+	if !ok {
+		return 0
+	}
+	p.attempts--
+	return p.attempts
+}
+
+// detach 复制上下文，使其不随处理器结束而取消
+func detach(ctx *context.Context) *context.Context {
+	c := *ctx
+	c.Ctx = stdctx.WithoutCancel(ctx.Ctx)
+	return &c
+}
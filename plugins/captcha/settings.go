@@ -0,0 +1,128 @@
+package captcha
+
+import (
+	"fmt"
+	"time"
+
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/moderation"
+)
+
+// -------------------- 设置 --------------------
+
+// 验证方式
+const (
+	modeOff    = "off"    // 不验证
+	modeButton = "button" // 点选题目中的图标
+	modeMath   = "math"   // 算术题
+	modeImage  = "image"  // 图片验证码
+)
+
+// 验证失败的处理
+const (
+	failKick = "kick" // 踢出，可以重新加入
+	failBan  = "ban"  // 封禁
+	failMute = "mute" // 保持禁言，由管理员处理
+)
+
+// 入群申请的处理
+const (
+	requestVerify = "verify" // 私聊发送题目，通过后批准
+	requestIgnore = "ignore" // 不处理，由管理员审批
+)
+
+// Settings 入群验证设置
+type Settings struct {
+	Mode         string `mapstructure:"mode" doc:"验证方式：off 关闭、button 点选图标、math 算术题、image 图片验证码；会话覆盖中为空时沿用全局" validate:"oneof=off button math image"`
+	Timeout      string `mapstructure:"timeout" doc:"答题时间，如 90s、2m；会话覆盖中为空时沿用全局"`
+	Attempts     int    `mapstructure:"attempts" doc:"可以作答的次数，用完后按验证失败处理；会话覆盖中为 0 时沿用全局" validate:"min=0"`
+	FailAction   string `mapstructure:"fail_action" doc:"超时或答错后的处理：kick 踢出、ban 封禁、mute 保持禁言；会话覆盖中为空时沿用全局" validate:"oneof=kick ban mute"`
+	JoinRequests string `mapstructure:"join_requests" doc:"入群申请：verify 私聊验证，通过后批准，失败时拒绝；ignore 不处理；会话覆盖中为空时沿用全局" validate:"oneof=verify ignore"`
+}
+
+// DefaultSettings 默认设置：点选图标，2 分钟内作答，可以作答 3 次，失败踢出
+func DefaultSettings() Settings {
+	return Settings{
+		Mode:         modeButton,
+		Timeout:      "2m",
+		Attempts:     3,
+		FailAction:   failKick,
+		JoinRequests: requestVerify,
+	}
+}
+
+// merge 用 override 中非空的字段覆盖 s
+func (s Settings) merge(override Settings) Settings {
+	if override.Mode != "" {
+		s.Mode = override.Mode
+	}
+	if override.Timeout != "" {
+		s.Timeout = override.Timeout
+	}
+	if override.Attempts > 0 {
+		s.Attempts = override.Attempts
+	}
+	if override.FailAction != "" {
+		s.FailAction = override.FailAction
+	}
+	if override.JoinRequests != "" {
+		s.JoinRequests = override.JoinRequests
+	}
+	return s
+}
+
+// settings 解析后的设置
+type settings struct {
+	mode         string
+	timeout      time.Duration
+	attempts     int
+	failAction   string
+	joinRequests bool
+}
+
+// parse 解析答题时间，其余字段已由 validate 标签检查
+func (s Settings) parse() (settings, error) {
+	timeout, err := common.ParseDuration(s.Timeout)
+	if err != nil {
+		return settings{}, fmt.Errorf("timeout: %w", err)
+	}
+	if timeout == common.Forever || timeout < 10*time.Second {
+		return settings{}, fmt.Errorf("timeout 不能少于 10 秒或为永久")
+	}
+	return settings{
+		mode:         s.Mode,
+		timeout:      timeout,
+		attempts:     max(s.Attempts, 1),
+		failAction:   s.FailAction,
+		joinRequests: s.JoinRequests != requestIgnore,
+	}, nil
+}
+
+// muteGrace 验证期间的禁言比答题时间多出的余量
+const muteGrace = time.Minute
+
+// muteDuration 验证期间的禁言时长：进行中的验证只保存在内存中，
+// Bot 重启或崩溃后由 Telegram 到期自动解除，不会让新成员一直被禁言
+func (s settings) muteDuration() time.Duration {
+	return min(max(s.timeout+muteGrace, moderation.MinDuration), moderation.MaxDuration)
+}
+
+// enabled 是否需要验证
+func (s settings) enabled() bool {
+	return s.mode != "" && s.mode != modeOff
+}
+
+// String 设置的中文说明
+func (s settings) String() string {
+	if !s.enabled() {
+		return "入群验证：关闭"
+	}
+	modes := map[string]string{modeButton: "点选图标", modeMath: "算术题", modeImage: "图片验证码"}
+	fails := map[string]string{failKick: "踢出", failBan: "封禁", failMute: "保持禁言"}
+	requests := "不处理"
+	if s.joinRequests {
+		requests = "私聊验证"
+	}
+	return fmt.Sprintf("入群验证：%s\n答题时间：%s，可作答 %d 次\n验证失败：%s\n入群申请：%s",
+		modes[s.mode], common.FormatDuration(s.timeout), s.attempts, fails[s.failAction], requests)
+}
@@ -1,7 +1,6 @@
 package chat
 
 import (
-	"context"
 	"fmt"
 	"math/rand"
 	"os"
@@ -57,11 +56,11 @@ type ChatPlugin struct {
 
 // PluginConfig 插件配置
 type PluginConfig struct {
-	PrefsPath string `mapstructure:"prefs_path"`  // 偏好设置文件路径
-	APIKey    string `mapstructure:"api_key"`     // 接口密钥
-	BaseURL   string `mapstructure:"base_url"`    // 接口基础地址
-	BotSelfID int64  `mapstructure:"bot_self_id"` // 机器人自身ID
-	OwnerID   int64  `mapstructure:"owner_id"`    // 机器人所有者ID
+	PrefsPath string        `mapstructure:"prefs_path" doc:"偏好设置文件路径"`
+	APIKey    config.Secret `mapstructure:"api_key" doc:"接口密钥，为空时读取环境变量 DEEPSEEK_API_KEY"`
+	BaseURL   string        `mapstructure:"base_url" doc:"接口基础地址" validate:"url"`
+	BotSelfID int64         `mapstructure:"bot_self_id" doc:"机器人自身ID，为 0 时自动获取"`
+	OwnerID   int64         `mapstructure:"owner_id" doc:"机器人所有者ID"`
 }
 
 func New() plugin.Plugin {
@@ -83,27 +82,25 @@ func New() plugin.Plugin {
 	}
 
 	// 获取配置
-	defaultCfg := cp.getDefaultConfig()
+	pctx := plugin.NewPluginContext(info.ID)
+	defaultCfg := cp.getDefaultConfig(pctx)
 	if err := config.GetPluginConfigOrDefault(info.ID, &cp.config, defaultCfg); err != nil {
 		panic(fmt.Sprintf("加载插件配置失败: %v", err))
 	}
+	pctx.Migrate(config.DataPath("user_prefs.json"), cp.config.PrefsPath)
+	cp.config.APIKey = withLegacyKey(cp.config.APIKey)
 
 	// 初始化 AI 客户端
-	if cp.config.APIKey == "" {
-		fmt.Println("[chat] ⚠️ DEEPSEEK_API_KEY 未设置，AI 功能将不可用")
-	} else {
-		cfg := openai.DefaultConfig(cp.config.APIKey)
-		cfg.BaseURL = cp.config.BaseURL
-		cp.aiClient = openai.NewClientWithConfig(cfg)
+	cp.aiClient = newAIClient(cp.config)
+	if cp.aiClient == nil {
+		fmt.Println("[chat] ⚠️ 未设置 api_key（或 YUELING_PLUGINS_CHAT_API_KEY），AI 功能将不可用")
 	}
 
-	// 加载偏好设置
-	if err := cp.loadPrefs(); err != nil {
-		fmt.Printf("[chat] ⚠️ 加载用户偏好失败: %v，使用默认值\n", err)
-	}
+	// 配置热更新：密钥或接口地址修改后重建客户端
+	config.OnPluginConfigChange(info.ID, cp.onConfigChange)
 
 	// 构建插件命令
-	builder := plugin.New().Info(info)
+	builder := plugin.New().Info(info).Context(pctx)
 
 	// 聊天消息
 	builder.OnMessage().Priority(1).Do(cp.handleChat)
@@ -114,16 +111,57 @@ func New() plugin.Plugin {
 	return builder.Go(cp)
 }
 
-func (cp *ChatPlugin) getDefaultConfig() PluginConfig {
+// Init 加载偏好设置（旧路径的数据已在注册时迁移）
+func (cp *ChatPlugin) Init() error {
+	if err := cp.loadPrefs(); err != nil {
+		cp.Log.Warn().Err(err).Msg("加载用户偏好失败，使用默认值")
+	}
+	return nil
+}
+
+func (cp *ChatPlugin) getDefaultConfig(pctx *plugin.PluginContext) PluginConfig {
 	return PluginConfig{
-		PrefsPath: "./data/user_prefs.json",
-		APIKey:    os.Getenv("DEEPSEEK_API_KEY"),
+		PrefsPath: pctx.DataDir("user_prefs.json"),
 		BaseURL:   "https://api.deepseek.com/v1",
 		BotSelfID: 0,
 		OwnerID:   123456789,
 	}
 }
 
+// withLegacyKey 未配置密钥时兼容旧的 DEEPSEEK_API_KEY 环境变量
+func withLegacyKey(key config.Secret) config.Secret {
+	if key.IsSet() {
+		return key
+	}
+	return config.Secret(os.Getenv("DEEPSEEK_API_KEY"))
+}
+
+// newAIClient 根据配置创建 AI 客户端，未设置密钥时返回 nil
+func newAIClient(c PluginConfig) *openai.Client {
+	if !c.APIKey.IsSet() {
+		return nil
+	}
+	cfg := openai.DefaultConfig(c.APIKey.Value())
+	cfg.BaseURL = c.BaseURL
+	return openai.NewClientWithConfig(cfg)
+}
+
+// onConfigChange 应用热更新的配置
+func (cp *ChatPlugin) onConfigChange(_, newCfg PluginConfig) error {
+	newCfg.APIKey = withLegacyKey(newCfg.APIKey)
+	if newCfg.BotSelfID == 0 {
+		newCfg.BotSelfID = cp.config.BotSelfID
+	}
+
+	if newCfg.APIKey != cp.config.APIKey || newCfg.BaseURL != cp.config.BaseURL {
+		cp.aiClient = newAIClient(newCfg)
+	}
+	cp.config = newCfg
+
+	cp.Log.Info().Bool("ai_enabled", cp.aiClient != nil).Msg("配置已更新")
+	return nil
+}
+
 // -------------------- 处理器 --------------------
 
 // handleChat 处理聊天消息
@@ -315,7 +353,7 @@ func (cp *ChatPlugin) chatAI(content string, userID int64, username string, ctx
 
 	// 调用 API
 	resp, err := cp.aiClient.CreateChatCompletion(
-		context.Background(),
+		ctx.Ctx,
 		openai.ChatCompletionRequest{
 			Model: "deepseek-chat",
 			Messages: []openai.ChatCompletionMessage{
@@ -8,6 +8,11 @@ import (
 
 // -------------------- 数据持久化 --------------------
 
+// DataPaths 实现 plugin.PluginDataProvider
+func (cp *ChatPlugin) DataPaths() []string {
+	return []string{cp.config.PrefsPath}
+}
+
 func (cp *ChatPlugin) loadPrefs() error {
 	data, err := os.ReadFile(cp.config.PrefsPath)
 	if err != nil {
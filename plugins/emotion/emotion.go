@@ -1,6 +1,7 @@
 package emotion
 
 import (
+	"fmt"
 	"io/fs"
 	"math/rand"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"yueling_tg/internal/core/context"
 	"yueling_tg/internal/message"
 	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/paginator"
 	"yueling_tg/pkg/plugin"
 
 	"github.com/mymmrac/telego"
@@ -17,7 +19,7 @@ import (
 var _ plugin.Plugin = (*EmotePlugin)(nil)
 
 type PluginConfig struct {
-	DataPath string `mapstructure:"data_path"`
+	DataPath string `mapstructure:"data_path" doc:"表情包目录" validate:"required"`
 }
 
 type EmotePlugin struct {
@@ -39,19 +41,21 @@ func New() plugin.Plugin {
 		Group:       "图库",
 		Extra:       make(map[string]any),
 	}
-	ep := &EmotePlugin{
-		path: "data/images/表情",
-	}
+	ep := &EmotePlugin{}
 
+	// 表情包放在资源目录
+	pctx := plugin.NewPluginContext(info.ID)
 	if err := config.GetPluginConfigOrDefault(info.ID, &ep.config, PluginConfig{
-		DataPath: "data/images/表情",
+		DataPath: pctx.AssetsDir(),
 	}); err != nil {
-		ep.Log.Error().Err(err).Msg("加载插件配置失败")
-		return nil
+		panic(fmt.Sprintf("加载插件配置失败: %v", err))
 	}
+	ep.path = ep.config.DataPath
+	pctx.Migrate(config.DataPath("images", "表情"), ep.path)
 
 	builder := plugin.New().
-		Info(info)
+		Info(info).
+		Context(pctx)
 
 	// 消息匹配器
 	builder.OnMessage().
@@ -98,11 +102,10 @@ func (ep *EmotePlugin) emoteHandler(c *context.Context) {
 		for _, f := range files {
 			names = append(names, strings.TrimSuffix(filepath.Base(f), filepath.Ext(f)))
 		}
-		limit := len(names)
-		if limit > 20 {
-			limit = 20
-		}
-		c.Reply("匹配到的表情包列表:\n" + strings.Join(names[:limit], "\n"))
+
+		paginator.New(paginator.FromSlice(names), func(page paginator.Page[string]) string {
+			return fmt.Sprintf("匹配到的表情包列表 (共 %d 个):\n%s", page.Count, strings.Join(page.Items, "\n"))
+		}).PageSize(20).Reply(c)
 
 	case strings.HasPrefix(m, "#"): // #关键词 → 随机匹配
 		query := strings.TrimSpace(strings.TrimPrefix(m, "#"))
@@ -124,7 +127,7 @@ func (ep *EmotePlugin) emoteHandler(c *context.Context) {
 func (ep *EmotePlugin) another(cmd string, c *context.Context) error {
 	parts := strings.Split(cmd, "_")
 	if len(parts) != 2 {
-		ep.Log.Error().Str("cmd", cmd).Msg("按钮点击格式错误")
+		ep.Logger(c).Error().Str("cmd", cmd).Msg("按钮点击格式错误")
 		return nil
 	}
 	query := parts[1]
@@ -144,7 +147,7 @@ func (ep *EmotePlugin) another(cmd string, c *context.Context) error {
 	// 获取原消息
 	msg := c.GetCallbackQuery().Message
 	if msg == nil {
-		ep.Log.Error().Msg("callback没有原消息")
+		ep.Logger(c).Error().Msg("callback没有原消息")
 		return nil
 	}
 	choice := files[rand.Intn(len(files))]
@@ -161,7 +164,7 @@ func (ep *EmotePlugin) another(cmd string, c *context.Context) error {
 
 	_, err := c.Api.EditMessageMedia(c.Ctx, params)
 	if err != nil {
-		ep.Log.Error().Err(err).Msg("编辑消息失败")
+		ep.Logger(c).Error().Err(err).Msg("编辑消息失败")
 		c.AnswerCallback("换图失败 😢")
 		return err
 	}
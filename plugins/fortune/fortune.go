@@ -1,6 +1,7 @@
 package fortune
 
 import (
+	"embed"
 	"encoding/json"
 	"fmt"
 	"image"
@@ -25,6 +26,11 @@ import (
 	"github.com/golang/freetype/truetype"
 )
 
+// 默认资源：文案随程序发布，主题图片与字体需自行放入资源目录
+//
+//go:embed assets
+var assets embed.FS
+
 var _ plugin.Plugin = (*FortuneGenerator)(nil)
 
 type FortuneConfig struct {
@@ -36,7 +42,7 @@ type FortuneConfig struct {
 }
 
 type PluginConfig struct {
-	Storage string `mapstructure:"storage"`
+	Storage string `mapstructure:"storage" doc:"旧版数据目录（包含 themes、fonts、cache 与 copywriting.json），为空时使用插件的资源与缓存目录"`
 }
 
 type FortuneGenerator struct {
@@ -62,22 +68,46 @@ func New() plugin.Plugin {
 		Base: plugin.NewBase(info),
 	}
 
-	if err := config.GetPluginConfigOrDefault(info.ID, &p.config, PluginConfig{
-		Storage: "./data/fortune",
-	}); err != nil {
+	if err := config.GetPluginConfigOrDefault(info.ID, &p.config, PluginConfig{}); err != nil {
 		panic(err)
 	}
 
-	basePath := p.config.Storage
+	pctx := p.PluginContext()
+	assetsBase := "" // 资源路径相对 AssetsDir，沿用旧版目录时为绝对路径
+	if basePath := p.config.Storage; basePath != "" {
+		// 沿用旧版目录结构
+		abs, err := filepath.Abs(basePath)
+		if err != nil {
+			panic(err)
+		}
+		assetsBase = abs
+		p.cfg = FortuneConfig{
+			BasePath:    abs,
+			CacheDir:    filepath.Join(abs, "cache"),
+			ThemesDir:   filepath.Join(abs, "themes"),
+			FontsDir:    filepath.Join(abs, "fonts"),
+			Copywriting: filepath.Join(abs, "copywriting.json"),
+		}
+	} else {
+		p.cfg = FortuneConfig{
+			BasePath:    pctx.AssetsDir(),
+			CacheDir:    pctx.CacheDir(),
+			ThemesDir:   pctx.AssetsDir("themes"),
+			FontsDir:    pctx.AssetsDir("fonts"),
+			Copywriting: pctx.AssetsDir("copywriting.json"),
+		}
 
-	cfg := FortuneConfig{
-		BasePath:    basePath,
-		CacheDir:    filepath.Join(basePath, "cache"),
-		ThemesDir:   filepath.Join(basePath, "themes"),
-		FontsDir:    filepath.Join(basePath, "fonts"),
-		Copywriting: filepath.Join(basePath, "copywriting.json"),
+		// 迁移旧版默认目录 data/fortune 中的资源
+		legacy := config.DataPath("fortune")
+		for _, name := range []string{"themes", "fonts", "copywriting.json"} {
+			pctx.Migrate(filepath.Join(legacy, name), pctx.AssetsDir(name))
+		}
+		pctx.EmbedAssets(assets, "assets")
 	}
-	p.cfg = cfg
+
+	pctx.RequireAsset(filepath.Join(assetsBase, "fonts", "sakura.ttf"), "抽签字体").
+		RequireAsset(filepath.Join(assetsBase, "themes"), "主题目录，每个子目录为一个主题，包含若干背景图片").
+		RequireAsset(filepath.Join(assetsBase, "copywriting.json"), "运势文案")
 
 	cmdMatcher := plugin.OnCommand([]string{"抽签"}, true, handler.NewHandler(p.divine))
 
@@ -160,6 +190,9 @@ func (fm *FortuneGenerator) AvailableThemes() ([]string, error) {
 			themes = append(themes, dir.Name())
 		}
 	}
+	if len(themes) == 0 {
+		return nil, fmt.Errorf("主题目录 %s 中没有主题", fm.cfg.ThemesDir)
+	}
 	return themes, nil
 }
 
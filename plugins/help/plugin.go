@@ -1,17 +1,23 @@
 package help
 
 import (
-	"fmt"
+	"embed"
 	"sort"
 	"strconv"
 	"strings"
 	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/i18n"
+	"yueling_tg/pkg/paginator"
 	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/plugin/dsl/permission"
 	"yueling_tg/pkg/plugin/params"
 )
 
 var _ plugin.Plugin = (*helper)(nil)
 
+//go:embed locales
+var locales embed.FS
+
 type helper struct {
 	*plugin.Base
 }
@@ -24,88 +30,150 @@ func New() plugin.Plugin {
 		Description: "提供帮助信息",
 		Version:     "0.1.0",
 		Author:      "月离",
-		Usage:       "help [插件ID]",
+		Usage:       "help [插件ID]\nlanguage [语言代码|auto]",
 		Group:       "系统",
 		Extra:       make(map[string]any),
 	}
 
+	// 加载内置翻译
+	i18n.MustRegister(info.ID, locales, "locales")
+
 	// 初始化 helper 插件实例
 	h := &helper{}
 
 	// 返回插件，并注入 Base
-	return plugin.New().Info(info).OnCommand("help", "帮助").Do(h.listPlugins).Go(h)
+	return plugin.New().Info(info).
+		OnCommand("help", "帮助").Do(h.listPlugins).
+		OnCommand("language", "语言").Do(h.setLanguage).
+		Go(h)
 }
 
 func (h *helper) listPlugins(ctx *context.Context, cmdCtx params.CommandContext, plugins []plugin.Plugin) {
 	if plugins == nil {
-		h.Log.Warn().Msg("Plugins() 返回 nil")
-		ctx.Send("❌ 当前没有可用的插件")
+		h.Logger(ctx).Warn().Msg("Plugins() 返回 nil")
+		ctx.Send(ctx.T("list.empty"))
 		return
 	}
 
-	// 按插件名排序，保证顺序固定
-	var sortedPlugins []plugin.Plugin
+	// 按当前语言本地化插件信息，并按名称排序，保证顺序固定
+	locale := ctx.Locale()
+	var raw []*plugin.PluginInfo
 	for _, p := range plugins {
 		if p != nil && p.PluginInfo() != nil {
-			sortedPlugins = append(sortedPlugins, p)
+			raw = append(raw, p.PluginInfo())
 		}
 	}
-	sort.Slice(sortedPlugins, func(i, j int) bool {
-		return sortedPlugins[i].PluginInfo().Name < sortedPlugins[j].PluginInfo().Name
+	sort.Slice(raw, func(i, j int) bool {
+		return raw[i].Localize(locale).Name < raw[j].Localize(locale).Name
 	})
+	infos := make([]*plugin.PluginInfo, len(raw))
+	for i, info := range raw {
+		infos[i] = info.Localize(locale)
+	}
 
 	// 处理命令参数
 	if cmdCtx.Args.Len() != 0 {
 		arg := cmdCtx.Args.Get(0)
 		// 尝试将参数解析为数字 ID
 		if id, err := strconv.Atoi(arg); err == nil {
-			if id >= 1 && id <= len(sortedPlugins) {
-				target := sortedPlugins[id-1]
-				info := target.PluginInfo()
-				ctx.Send(fmt.Sprintf(
-					"📖 插件 #%d '%s'\n描述: %s\n用法: %s",
-					id, info.Name, info.Description, info.Usage,
-				))
+			if id >= 1 && id <= len(infos) {
+				info := infos[id-1]
+				ctx.Send(ctx.T("detail.numbered", i18n.Args{
+					"index":       id,
+					"name":        info.Name,
+					"description": info.Description,
+					"usage":       info.Usage,
+				}))
 				return
 			} else {
-				ctx.Send(fmt.Sprintf("❌ 插件 ID '%d' 不存在", id))
+				ctx.Send(ctx.T("detail.not_found_id", i18n.Args{"id": id}))
 				return
 			}
 		}
 
-		// 非数字 → 按名称查找
-		var target plugin.Plugin
-		for _, p := range sortedPlugins {
-			if p.PluginInfo().Name == arg {
-				target = p
+		// 非数字 → 按名称（本地化名称或 ID）查找
+		var target *plugin.PluginInfo
+		for _, info := range infos {
+			if info.Name == arg || info.ID == arg {
+				target = info
 				break
 			}
 		}
 		if target != nil {
-			info := target.PluginInfo()
-			ctx.Send(fmt.Sprintf(
-				"📖 插件 '%s'\n描述: %s\n用法: %s",
-				info.Name, info.Description, info.Usage,
-			))
+			ctx.Send(ctx.T("detail.named", i18n.Args{
+				"name":        target.Name,
+				"description": target.Description,
+				"usage":       target.Usage,
+			}))
 		} else {
-			ctx.Sendf("❌ 未找到名为『%s』的插件", arg)
+			ctx.Send(ctx.T("detail.not_found_name", i18n.Args{"name": arg}))
 		}
 		return
 	}
 
-	// 没有参数 → 列出插件列表并显示 ID
-	var msgs strings.Builder
-	msgs.WriteString("✨ 可用插件列表:\n")
-	msgs.WriteString("使用help <插件ID> 获取插件详细信息\n")
-	for i, p := range sortedPlugins {
-		info := p.PluginInfo()
-		name := "<未知>"
-		if info != nil && info.Name != "" {
-			name = info.Name
+	// 没有参数 → 分页列出插件列表并显示 ID。
+	// 翻页可能来自其他用户或群组，按触发翻页的更新的语言渲染；
+	// 翻页回调不经过插件匹配，需指定翻译的命名空间
+	id := h.PluginInfo().ID
+	paginator.New(paginator.FromSlice(raw), func(page paginator.Page[*plugin.PluginInfo]) string {
+		c := page.Ctx
+		locale := c.Locale()
+
+		var msgs strings.Builder
+		msgs.WriteString(c.TNamespace(id, "list.title") + "\n")
+		msgs.WriteString(c.TNamespace(id, "list.hint") + "\n")
+		for i, info := range page.Items {
+			name := info.Localize(locale).Name
+			if name == "" {
+				name = c.TNamespace(id, "list.unknown")
+			}
+
+			msgs.WriteString(c.TNamespace(id, "list.item", i18n.Args{"index": page.Offset + i + 1, "name": name}) + "\n")
+		}
+		msgs.WriteString(c.TNamespace(id, "list.count", i18n.Args{"count": page.Count}))
+		return msgs.String()
+	}).Send(ctx)
+}
+
+// setLanguage 查看或设置当前聊天的语言
+func (h *helper) setLanguage(ctx *context.Context, cmdCtx params.CommandContext) {
+	if cmdCtx.Args.Len() == 0 {
+		ctx.Send(ctx.T("language.current", i18n.Args{
+			"locale":    ctx.Locale(),
+			"available": strings.Join(i18n.Default().Locales(), ", "),
+		}))
+		return
+	}
+
+	// 群组中仅管理员可以修改
+	if !ctx.IsPrivate() && !permission.GroupAdminOrOwner().Match(ctx) {
+		ctx.Send(ctx.T("language.no_permission"))
+		return
+	}
+
+	arg := cmdCtx.Args.Get(0)
+
+	if strings.EqualFold(arg, "auto") {
+		if err := ctx.SetChatLocale(""); err != nil {
+			h.Logger(ctx).Error().Err(err).Msg("保存语言设置失败")
+			ctx.Send(ctx.T("language.failed"))
+			return
 		}
+		ctx.Send(ctx.T("language.reset"))
+		return
+	}
 
-		msgs.WriteString(fmt.Sprintf("🔹 #%d %s \n", i+1, name))
+	locale := i18n.Default().Match(arg)
+	if locale == "" {
+		ctx.Send(ctx.T("language.unsupported", i18n.Args{"locale": arg}))
+		return
+	}
+
+	if err := ctx.SetChatLocale(locale); err != nil {
+		h.Logger(ctx).Error().Err(err).Msg("保存语言设置失败")
+		ctx.Send(ctx.T("language.failed"))
+		return
 	}
 
-	ctx.Send(msgs.String())
+	ctx.Send(ctx.T("language.set", i18n.Args{"locale": locale}))
 }
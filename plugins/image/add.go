@@ -14,7 +14,7 @@ import (
 // -------------------- 添加图片逻辑 --------------------
 func (rg *RandomGenerator) handleAddImage(c *context.Context, cmdCtx params.CommandContext, commandArgs params.CommandArgs) {
 	cmd := cmdCtx.Command
-	rg.Log.Info().
+	rg.Logger(c).Info().
 		Str("from", c.GetUsername()).
 		Str("cmd", string(cmd)).
 		Msg("收到添加图片命令")
@@ -62,7 +62,7 @@ func (rg *RandomGenerator) handleAddImage(c *context.Context, cmdCtx params.Comm
 	// 构建文件夹路径
 	folder := filepath.Join(rg.config.ImagesFolder, folderName)
 	if err := os.MkdirAll(folder, 0755); err != nil {
-		rg.Log.Error().Err(err).Msg("创建文件夹失败")
+		rg.Logger(c).Error().Err(err).Msg("创建文件夹失败")
 		c.Reply("保存图片失败，无法创建文件夹 😢")
 		return
 	}
@@ -83,14 +83,14 @@ func (rg *RandomGenerator) handleAddImage(c *context.Context, cmdCtx params.Comm
 	for i, fileID := range photos {
 		url, err := c.GetFileDirectURL(fileID)
 		if err != nil {
-			rg.Log.Error().Err(err).Msg("获取文件链接失败")
+			rg.Logger(c).Error().Err(err).Msg("获取文件链接失败")
 			c.Replyf("第 %d 张图片获取失败 😭", i+1)
 			continue
 		}
 
-		data, err := common.FetchFile(url)
+		data, err := common.FetchFile(c.Ctx, url)
 		if err != nil {
-			rg.Log.Error().Err(err).Msg("下载文件失败")
+			rg.Logger(c).Error().Err(err).Msg("下载文件失败")
 			c.Replyf("第 %d 张下载失败 😭", i+1)
 			continue
 		}
@@ -126,7 +126,7 @@ func (rg *RandomGenerator) handleAddImage(c *context.Context, cmdCtx params.Comm
 
 		// 保存文件
 		if err := os.WriteFile(savePath, data, 0644); err != nil {
-			rg.Log.Error().Err(err).Msg("保存文件失败")
+			rg.Logger(c).Error().Err(err).Msg("保存文件失败")
 			c.Replyf("第 %d 张保存失败 😭", i+1)
 			continue
 		}
@@ -149,7 +149,7 @@ func (rg *RandomGenerator) handleAddImage(c *context.Context, cmdCtx params.Comm
 		}
 
 		success++
-		rg.Log.Info().
+		rg.Logger(c).Info().
 			Str("path", savePath).
 			Str("hash", hash).
 			Msg("图片已保存")
@@ -158,7 +158,7 @@ func (rg *RandomGenerator) handleAddImage(c *context.Context, cmdCtx params.Comm
 	// 保存索引
 	if success > 0 {
 		if err := rg.saveIndex(); err != nil {
-			rg.Log.Error().Err(err).Msg("保存索引失败")
+			rg.Logger(c).Error().Err(err).Msg("保存索引失败")
 		}
 	}
 
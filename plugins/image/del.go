@@ -29,7 +29,7 @@ func (rg *RandomGenerator) handleDeleteImage(c *context.Context, cmdCtx params.C
 	}
 
 	if err := os.Remove(imgIndex.Path); err != nil {
-		rg.Log.Error().Err(err).Str("path", imgIndex.Path).Msg("删除文件失败")
+		rg.Logger(c).Error().Err(err).Str("path", imgIndex.Path).Msg("删除文件失败")
 		c.Reply("删除文件失败 😭")
 		return
 	}
@@ -23,19 +23,19 @@ var _ plugin.Plugin = (*RandomGenerator)(nil)
 
 // PluginConfig 插件整体配置
 type PluginConfig struct {
-	DBPath       string           `mapstructure:"db_path"`
-	ImagesFolder string           `mapstructure:"images_folder"`
-	Categories   []CategoryConfig `mapstructure:"categories"`
+	DBPath       string           `mapstructure:"db_path" doc:"图片索引文件路径" validate:"required"`
+	ImagesFolder string           `mapstructure:"images_folder" doc:"图片根目录"`
+	Categories   []CategoryConfig `mapstructure:"categories" doc:"图片分类，每个分类对应一组触发命令"`
 }
 
 // CategoryConfig 单个分类配置
 type CategoryConfig struct {
-	Commands        []string `mapstructure:"commands"`         // 触发命令列表，如 ["吃什么", "今天吃啥"]
-	Folder          string   `mapstructure:"folder"`           // 对应文件夹
-	Caption         string   `mapstructure:"caption"`          // 图片说明
-	GridWidth       int      `mapstructure:"grid_width"`       // 宫格宽度
-	Count           int      `mapstructure:"count"`            // 抽取数量（1=单图，≥4=宫格）
-	MessageTemplate string   `mapstructure:"message_template"` // 消息模板
+	Commands        []string `mapstructure:"commands" doc:"触发命令列表，如 [\"吃什么\", \"今天吃啥\"]" validate:"min=1"`
+	Folder          string   `mapstructure:"folder" doc:"对应文件夹" validate:"required"`
+	Caption         string   `mapstructure:"caption" doc:"图片说明"`
+	GridWidth       int      `mapstructure:"grid_width" doc:"宫格宽度" validate:"min=0"`
+	Count           int      `mapstructure:"count" doc:"抽取数量（1=单图，≥4=宫格）" validate:"min=1"`
+	MessageTemplate string   `mapstructure:"message_template" doc:"消息模板"`
 }
 
 // -------------------- 图片哈希索引 --------------------
@@ -82,12 +82,14 @@ func New() plugin.Plugin {
 	}
 
 	// 获取配置
-	defaultConfig := rg.getDefaultConfig()
+	pctx := plugin.NewPluginContext(info.ID)
+	defaultConfig := rg.getDefaultConfig(pctx)
 	if err := config.GetPluginConfigOrDefault(info.ID, &rg.config, defaultConfig); err != nil {
 		panic(fmt.Sprintf("加载插件配置失败: %v", err))
 	}
+	pctx.Migrate(config.DataPath("images", "index.json"), rg.config.DBPath)
 
-	builder := plugin.New().Info(info)
+	builder := plugin.New().Info(info).Context(pctx)
 
 	// 注册随机图片命令
 	for _, cat := range rg.config.Categories {
@@ -122,10 +124,10 @@ func (rg *RandomGenerator) Init() error {
 }
 
 // getDefaultConfig 获取默认配置
-func (rg *RandomGenerator) getDefaultConfig() PluginConfig {
+func (rg *RandomGenerator) getDefaultConfig(pctx *plugin.PluginContext) PluginConfig {
 
 	return PluginConfig{
-		DBPath: "./data/images/index.json",
+		DBPath: pctx.DataDir("index.json"),
 		Categories: []CategoryConfig{
 			{
 				Commands:        []string{"吃什么", "今天吃啥"},
@@ -217,7 +219,7 @@ func (rg *RandomGenerator) createButton(folder string) telego.InlineKeyboardMark
 // -------------------- 逻辑核心 --------------------
 
 func (rg *RandomGenerator) handleCommand(c *context.Context, cfg CategoryConfig) {
-	rg.Log.Debug().
+	rg.Logger(c).Debug().
 		Str("from", c.GetUsername()).
 		Strs("commands", cfg.Commands).
 		Str("folder", cfg.Folder).
@@ -226,7 +228,7 @@ func (rg *RandomGenerator) handleCommand(c *context.Context, cfg CategoryConfig)
 	folder := filepath.Join(rg.config.ImagesFolder, cfg.Folder)
 	imgPaths, err := rg.selectImages(folder, cfg.Count)
 	if err != nil {
-		rg.Log.Error().Err(err).Str("folder", folder).Msg("无法读取图片")
+		rg.Logger(c).Error().Err(err).Str("folder", folder).Msg("无法读取图片")
 		c.Reply("还没准备好图片哦～ 📂")
 		return
 	}
@@ -274,14 +276,14 @@ func (rg *RandomGenerator) selectImages(folder string, count int) ([]string, err
 }
 
 func (rg *RandomGenerator) sendSinglePhoto(c *context.Context, cfg CategoryConfig, imgPath string) {
-	rg.Log.Debug().Str("file", imgPath).Msg("选取单图发送")
+	rg.Logger(c).Debug().Str("file", imgPath).Msg("选取单图发送")
 
 	photo := message.NewResource(imgPath).WithCaption(cfg.Caption)
 	buttons := rg.createButton(cfg.Folder)
 
 	msg, err := c.SendPhotoWithMarkup(photo, buttons)
 	if err != nil {
-		rg.Log.Error().Err(err).Msg("发送图片失败")
+		rg.Logger(c).Error().Err(err).Msg("发送图片失败")
 		return
 	}
 
@@ -47,7 +47,7 @@ func (rg *RandomGenerator) scanAllCategories() (updated bool, err error) {
 }
 
 func (rg *RandomGenerator) handleRebuildIndex(ctx *context.Context) {
-	rg.Log.Info().Msg("开始重建图片索引，无条件扫描所有图片...")
+	rg.Logger(ctx).Info().Msg("开始重建图片索引，无条件扫描所有图片...")
 
 	// 清空当前索引
 	rg.indexDB.mu.Lock()
@@ -57,15 +57,15 @@ func (rg *RandomGenerator) handleRebuildIndex(ctx *context.Context) {
 	// 扫描所有分类
 	updated, err := rg.scanAllCategories()
 	if err != nil {
-		rg.Log.Error().Err(err).Msg("扫描所有图片失败")
+		rg.Logger(ctx).Error().Err(err).Msg("扫描所有图片失败")
 		ctx.Replyf("扫描所有图片失败: %v", err)
 		return
 	}
 
 	if updated {
-		rg.Log.Info().Msg("索引重建完成，正在保存索引...")
+		rg.Logger(ctx).Info().Msg("索引重建完成，正在保存索引...")
 		if err := rg.saveIndex(); err != nil {
-			rg.Log.Error().Err(err).Msg("保存索引失败")
+			rg.Logger(ctx).Error().Err(err).Msg("保存索引失败")
 			ctx.Replyf("保存索引失败: %v", err)
 			return
 		}
@@ -73,10 +73,15 @@ func (rg *RandomGenerator) handleRebuildIndex(ctx *context.Context) {
 		return
 	}
 
-	rg.Log.Info().Msg("未发现图片，索引已清空")
+	rg.Logger(ctx).Info().Msg("未发现图片，索引已清空")
 	ctx.Reply("未发现图片，索引已清空")
 }
 
+// DataPaths 实现 plugin.PluginDataProvider
+func (rg *RandomGenerator) DataPaths() []string {
+	return []string{rg.config.DBPath}
+}
+
 // 加载或创建图片索引
 func (rg *RandomGenerator) loadOrCreateIndex() error {
 	if err := rg.loadIndex(); err != nil {
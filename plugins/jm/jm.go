@@ -38,8 +38,8 @@ type JMPlugin struct {
 }
 
 type PluginConfig struct {
-	ProxyURL string `json:"proxy_url"`
-	SaveDir  string `json:"save_dir"`
+	ProxyURL string `mapstructure:"proxy_url" doc:"下载使用的代理地址" validate:"url"`
+	SaveDir  string `mapstructure:"save_dir" doc:"下载保存目录" validate:"required"`
 }
 
 func New() *JMPlugin {
@@ -60,7 +60,7 @@ func New() *JMPlugin {
 	// 默认配置
 	defaultCfg := PluginConfig{
 		ProxyURL: "http://127.0.0.1:7890",
-		SaveDir:  "./data/downloads",
+		SaveDir:  config.DataPath("downloads"),
 	}
 
 	// 加载或创建配置
@@ -115,7 +115,7 @@ func (jm *JMPlugin) handleJM(c *context.Context, cmdCtx params.CommandContext) {
 		}
 	}
 
-	jm.Log.Info().
+	jm.Logger(c).Info().
 		Int("bookID", bookID).
 		Int("chapter index", chapterIndex).
 		Msg("开始处理 JM 下载")
@@ -123,16 +123,16 @@ func (jm *JMPlugin) handleJM(c *context.Context, cmdCtx params.CommandContext) {
 	// 创建保存目录
 	tmpFolder := filepath.Join(SaveDir, args[0])
 	if err := os.MkdirAll(tmpFolder, 0755); err != nil {
-		jm.Log.Error().Err(err).Msg("创建目录失败")
+		jm.Logger(c).Error().Err(err).Msg("创建目录失败")
 		c.Reply("创建目录失败")
 		return
 	}
 
 	// 解析章节
-	jm.Log.Info().Msg("正在解析章节...")
+	jm.Logger(c).Info().Msg("正在解析章节...")
 	comic, err := jm.client.GetComic(bookID)
 	if err != nil || len(comic.Series) == 0 {
-		jm.Log.Error().Err(err).Msg("解析章节失败")
+		jm.Logger(c).Error().Err(err).Msg("解析章节失败")
 		c.Reply("网络错误(请稍后重试)/未找到任何章节")
 		return
 	}
@@ -147,7 +147,7 @@ func (jm *JMPlugin) handleJM(c *context.Context, cmdCtx params.CommandContext) {
 	pdfFile := filepath.Join(tmpFolder, fmt.Sprintf("%d_%d.pdf", bookID, chapterIndex))
 
 	if _, err := os.Stat(pdfFile); err == nil {
-		jm.Log.Info().Str("pdf文件路径", pdfFile).Msg("PDF 文件已存在")
+		jm.Logger(c).Info().Str("pdf文件路径", pdfFile).Msg("PDF 文件已存在")
 		c.SendDocument(message.NewResource(pdfFile))
 		return
 	}
@@ -158,15 +158,15 @@ func (jm *JMPlugin) handleJM(c *context.Context, cmdCtx params.CommandContext) {
 
 	// 下载图片
 	if err := jm.client.DownloadChapterImages(int(cid), ImageFormatPNG); err != nil {
-		jm.Log.Error().Err(err).Msg("下载图片失败")
+		jm.Logger(c).Error().Err(err).Msg("下载图片失败")
 		c.Reply(fmt.Sprintf("下载出错: %v", err))
 		return
 	}
 
 	// 生成 PDF
-	jm.Log.Info().Msg("正在生成 PDF...")
+	jm.Logger(c).Info().Msg("正在生成 PDF...")
 	if err := jm.convertImagesToPDF(tmpFolder, pdfFile); err != nil {
-		jm.Log.Error().Err(err).Msg("生成 PDF 失败")
+		jm.Logger(c).Error().Err(err).Msg("生成 PDF 失败")
 		c.Reply("生成 PDF 失败")
 		return
 	}
@@ -1,6 +1,7 @@
 package music
 
 import (
+	stdctx "context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -134,7 +135,7 @@ func (mp *MusicPlugin) handleSearch(c *context.Context) {
 	keyword := strings.Join(parts[1:], " ")
 
 	// 默认使用网易云搜索
-	results, err := mp.searchMusic("netease", keyword, 5)
+	results, err := mp.searchMusic(c.Ctx, "netease", keyword, 5)
 	if err != nil {
 		c.Reply(fmt.Sprintf("搜索失败：%v", err))
 		return
@@ -186,7 +187,7 @@ func (mp *MusicPlugin) handleSourceChange(cmd string, c *context.Context) error
 
 	keyword := cache.Keyword
 
-	results, err := mp.searchMusic(source, keyword, 5)
+	results, err := mp.searchMusic(c.Ctx, source, keyword, 5)
 	if err != nil {
 		c.AnswerCallback(fmt.Sprintf("搜索失败：%v", err))
 		return nil
@@ -251,10 +252,10 @@ func (mp *MusicPlugin) handlePlay(cmd string, c *context.Context) error {
 	artist := strings.Join(song.Artist, ", ")
 	source := song.Source
 
-	mp.Log.Debug().Msgf("Playing: source=%s, id=%s, name=%s", source, trackID, songName)
+	mp.Logger(c).Debug().Msgf("Playing: source=%s, id=%s, name=%s", source, trackID, songName)
 
 	// 获取音乐URL
-	urlResult, err := mp.getMusicURL(source, trackID)
+	urlResult, err := mp.getMusicURL(c.Ctx, source, trackID)
 	if err != nil || urlResult.URL == "" {
 		c.AnswerCallback("获取音乐链接失败 😢")
 		return nil
@@ -434,11 +435,20 @@ func (mp *MusicPlugin) updateSearchResults(c *context.Context, msg *telego.Messa
 
 // -------------------- API调用 --------------------
 
-func (mp *MusicPlugin) searchMusic(source, keyword string, count int) ([]SearchResult, error) {
+// get 发起 GET 请求，随处理器的截止时间取消
+func (mp *MusicPlugin) get(ctx stdctx.Context, apiURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return mp.httpClient.Do(req)
+}
+
+func (mp *MusicPlugin) searchMusic(ctx stdctx.Context, source, keyword string, count int) ([]SearchResult, error) {
 	apiURL := fmt.Sprintf("%s?types=search&source=%s&name=%s&count=%d",
 		mp.apiBase, source, url.QueryEscape(keyword), count)
 
-	resp, err := mp.httpClient.Get(apiURL)
+	resp, err := mp.get(ctx, apiURL)
 	if err != nil {
 		return nil, err
 	}
@@ -515,13 +525,13 @@ func getStringValue(m map[string]interface{}, key string) string {
 	return ""
 }
 
-func (mp *MusicPlugin) getMusicURL(source, trackID string) (*URLResult, error) {
+func (mp *MusicPlugin) getMusicURL(ctx stdctx.Context, source, trackID string) (*URLResult, error) {
 	apiURL := fmt.Sprintf("%s?types=url&source=%s&id=%s&br=320",
 		mp.apiBase, source, trackID)
 
 	mp.Log.Debug().Msg(apiURL)
 
-	resp, err := mp.httpClient.Get(apiURL)
+	resp, err := mp.get(ctx, apiURL)
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +46,7 @@ type RollPlugin struct {
 // ----------------------------------------------
 
 func (rp *RollPlugin) rollHandler(c *context.Context, commandArgs params.CommandArgs) {
-	rp.Log.Info().Msgf("Roll 指令参数: %v", commandArgs)
+	rp.Logger(c).Info().Msgf("Roll 指令参数: %v", commandArgs)
 
 	// 	// 处理图片/视频
 	// if photos, ok := c.GetMedias(); ok {
@@ -12,6 +12,7 @@ import (
 	"yueling_tg/internal/core/context"
 	"yueling_tg/pkg/config"
 	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/userdir"
 
 	"github.com/mymmrac/telego"
 	tu "github.com/mymmrac/telego/telegoutil"
@@ -40,15 +41,16 @@ type GroupMembers struct {
 // -------------------- 插件结构 --------------------
 
 type PluginConfig struct {
-	DBPath      string `mapstructure:"db_path"`
-	MaxMembers  int    `mapstructure:"max_members"`  // 每个群最多保留多少活跃成员
-	ActiveLimit int    `mapstructure:"active_limit"` // 从最近多少活跃成员中抽取
-	AllowBots   bool   `mapstructure:"allow_bots"`   // 是否允许抽到机器人
+	DBPath      string `mapstructure:"db_path" doc:"数据文件路径" validate:"required"`
+	MaxMembers  int    `mapstructure:"max_members" doc:"每个群最多保留多少活跃成员" validate:"min=1"`
+	ActiveLimit int    `mapstructure:"active_limit" doc:"从最近多少活跃成员中抽取" validate:"min=1"`
+	AllowBots   bool   `mapstructure:"allow_bots" doc:"是否允许抽到机器人"`
 }
 
 type RandomMemberPlugin struct {
 	*plugin.Base
 	data   *GroupMembers
+	users  *userdir.Directory
 	config PluginConfig
 }
 
@@ -64,7 +66,7 @@ func New() plugin.Plugin {
 		ID:          "random_member",
 		Name:        "随机群友",
 		Description: "随机抽取群友（从最近活跃成员中）",
-		Version:     "1.0.0",
+		Version:     "1.0.1",
 		Author:      "月离",
 		Usage:       "抽群友 / 来点群友 / 随机群友",
 		Group:       "随机",
@@ -72,8 +74,10 @@ func New() plugin.Plugin {
 	}
 
 	// 设置默认配置
+	pctx := plugin.NewPluginContext(info.ID)
+	rmp.users = userdir.For(pctx.Config())
 	rmp.config = PluginConfig{
-		DBPath:      "./data/random_member.json",
+		DBPath:      pctx.DataDir("random_member.json"),
 		MaxMembers:  100,  // 每个群最多保留100个活跃成员
 		ActiveLimit: 25,   // 从最近25个活跃成员中抽取
 		AllowBots:   true, // 允许抽到机器人
@@ -81,7 +85,7 @@ func New() plugin.Plugin {
 
 	// 尝试加载配置
 	if err := config.GetPluginConfigOrDefault(info.ID, &rmp.config, rmp.config); err != nil {
-		rmp.config.DBPath = "./data/random_member.json"
+		rmp.config.DBPath = pctx.DataDir("random_member.json")
 		rmp.config.MaxMembers = 100
 		rmp.config.ActiveLimit = 25
 		rmp.config.AllowBots = true
@@ -89,11 +93,12 @@ func New() plugin.Plugin {
 
 	// 确保路径不为空
 	if rmp.config.DBPath == "" {
-		rmp.config.DBPath = "./data/random_member.json"
+		rmp.config.DBPath = pctx.DataDir("random_member.json")
 	}
+	pctx.Migrate(config.DataPath("random_member.json"), rmp.config.DBPath)
 
 	// 初始化 Builder
-	builder := plugin.New().Info(info)
+	builder := plugin.New().Info(info).Context(pctx)
 
 	// 追踪所有消息（用于记录活跃成员）
 	builder.OnMessage().Priority(1).Do(rmp.trackMember)
@@ -161,7 +166,7 @@ func (rmp *RandomMemberPlugin) trackMember(ctx *context.Context) {
 		// 随机延迟，避免频繁保存
 		if rand.Intn(10) == 0 { // 10% 概率保存
 			if err := rmp.saveData(); err != nil {
-				rmp.Log.Error().Err(err).Msg("保存成员数据失败")
+				rmp.Logger(ctx).Error().Err(err).Msg("保存成员数据失败")
 			}
 		}
 	}()
@@ -258,7 +263,7 @@ func (rmp *RandomMemberPlugin) handleRandomMember(ctx *context.Context) {
 	rand.Seed(time.Now().UnixNano())
 	selected := activeMembers[rand.Intn(len(activeMembers))]
 
-	// 构建名称
+	// 构建名称，优先使用用户目录中的最新用户名与名称
 	name := selected.FirstName
 	if selected.LastName != "" {
 		name += " " + selected.LastName
@@ -266,6 +271,9 @@ func (rmp *RandomMemberPlugin) handleRandomMember(ctx *context.Context) {
 	if selected.Username != "" {
 		name = "@" + selected.Username
 	}
+	if u, ok := rmp.users.Get(selected.UserID); ok {
+		name = u.Mention()
+	}
 
 	// 添加机器人标识
 	botTag := ""
@@ -302,7 +310,7 @@ func (rmp *RandomMemberPlugin) handleRandomMember(ctx *context.Context) {
 			},
 		})
 		if err != nil {
-			rmp.Log.Error().Err(err).Msg("发送头像失败")
+			rmp.Logger(ctx).Error().Err(err).Msg("发送头像失败")
 			// 降级为纯文本
 			ctx.Reply(text)
 		}
@@ -311,7 +319,7 @@ func (rmp *RandomMemberPlugin) handleRandomMember(ctx *context.Context) {
 		ctx.Reply(text)
 	}
 
-	rmp.Log.Info().
+	rmp.Logger(ctx).Info().
 		Int64("chat_id", chatID).
 		Int64("selected_user_id", selected.UserID).
 		Str("selected_user_name", name).
@@ -321,6 +329,11 @@ func (rmp *RandomMemberPlugin) handleRandomMember(ctx *context.Context) {
 
 // -------------------- 数据管理 --------------------
 
+// DataPaths 实现 plugin.PluginDataProvider
+func (rmp *RandomMemberPlugin) DataPaths() []string {
+	return []string{rmp.config.DBPath}
+}
+
 // loadData 从文件加载数据
 func (rmp *RandomMemberPlugin) loadData() error {
 	if rmp.config.DBPath == "" {
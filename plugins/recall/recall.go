@@ -53,19 +53,19 @@ func (rp *RecallPlugin) handleRecall(ctx *context.Context) {
 	// 群组中检查机器人权限
 	if ctx.IsGroup() || ctx.IsSuperGroup() {
 		if !ctx.CanBotDeleteMessage() {
-			rp.Log.Error().Msg("获取机器人权限失败")
+			rp.Logger(ctx).Error().Msg("获取机器人权限失败")
 			ctx.Reply("❌ 尚未取得管理员权限，撤回失败~")
 			return
 		}
 
 		err := ctx.DeleteMessage(targetMsg.MessageID)
 		if err != nil {
-			rp.Log.Error().Err(err).Msg("撤回消息失败")
+			rp.Logger(ctx).Error().Err(err).Msg("撤回消息失败")
 			ctx.Reply("❌ 撤回失败了，可能权限不足~")
 			return
 		}
 
-		rp.Log.Info().
+		rp.Logger(ctx).Info().
 			Str("username", username).
 			Int("message_id", targetMsg.MessageID).
 			Msg("消息撤回成功")
@@ -10,6 +10,7 @@ import (
 
 	"yueling_tg/internal/core/context"
 	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/paginator"
 	"yueling_tg/pkg/plugin"
 	"yueling_tg/pkg/plugin/params"
 )
@@ -36,7 +37,7 @@ type ReplyDB struct {
 // -------------------- 插件结构 --------------------
 
 type PluginConfig struct {
-	DBPath string `mapstructure:"db_path"`
+	DBPath string `mapstructure:"db_path" doc:"回复数据文件路径" validate:"required"`
 }
 
 type ReplyPlugin struct {
@@ -67,17 +68,19 @@ func New() plugin.Plugin {
 	}
 
 	// 默认配置
+	pctx := plugin.NewPluginContext(info.ID)
 	defaultCfg := PluginConfig{
-		DBPath: "./data/reply.json",
+		DBPath: pctx.DataDir("reply.json"),
 	}
 
 	// 加载或创建配置
 	if err := config.GetPluginConfigOrDefault(info.ID, &rp.config, defaultCfg); err != nil {
 		panic(fmt.Sprintf("加载插件配置失败: %v", err))
 	}
+	pctx.Migrate(config.DataPath("reply.json"), rp.config.DBPath)
 
 	// 初始化 Builder
-	builder := plugin.New().Info(info)
+	builder := plugin.New().Info(info).Context(pctx)
 
 	// 普通消息匹配（低优先级）
 	builder.OnMessage().Priority(1).Do(rp.handleReply)
@@ -158,7 +161,7 @@ func (rp *ReplyPlugin) handleAddReply(ctx *context.Context, cmdCtx params.Comman
 
 	// 保存到文件
 	if err := rp.saveData(); err != nil {
-		rp.Log.Error().Err(err).Msg("保存数据失败")
+		rp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
 		ctx.Reply("❌ 添加失败了喵~")
 		return
 	}
@@ -166,7 +169,7 @@ func (rp *ReplyPlugin) handleAddReply(ctx *context.Context, cmdCtx params.Comman
 	// 更新索引
 	rp.updateIndex()
 
-	rp.Log.Info().
+	rp.Logger(ctx).Info().
 		Int("id", newReply.ID).
 		Str("keyword", keyword).
 		Msg("添加回复成功")
@@ -208,7 +211,7 @@ func (rp *ReplyPlugin) handleDeleteReply(ctx *context.Context, cmdCtx params.Com
 
 	// 保存到文件
 	if err := rp.saveData(); err != nil {
-		rp.Log.Error().Err(err).Msg("保存数据失败")
+		rp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
 		ctx.Reply("❌ 删除失败")
 		return
 	}
@@ -216,14 +219,14 @@ func (rp *ReplyPlugin) handleDeleteReply(ctx *context.Context, cmdCtx params.Com
 	// 更新索引
 	rp.updateIndex()
 
-	rp.Log.Info().Int("id", id).Msg("删除回复成功")
+	rp.Logger(ctx).Info().Int("id", id).Msg("删除回复成功")
 	ctx.Reply("✅ 删除成功")
 }
 
 // handleUpdateReply 更新索引
 func (rp *ReplyPlugin) handleUpdateReply(ctx *context.Context) {
 	if err := rp.loadData(); err != nil {
-		rp.Log.Error().Err(err).Msg("重新加载数据失败")
+		rp.Logger(ctx).Error().Err(err).Msg("重新加载数据失败")
 		ctx.Reply("❌ 更新失败")
 		return
 	}
@@ -235,33 +238,43 @@ func (rp *ReplyPlugin) handleUpdateReply(ctx *context.Context) {
 // handleListReply 查看回复列表
 func (rp *ReplyPlugin) handleListReply(ctx *context.Context) {
 	rp.db.mu.RLock()
-	defer rp.db.mu.RUnlock()
+	replies := make([]*ReplyData, len(rp.db.Replies))
+	copy(replies, rp.db.Replies)
+	rp.db.mu.RUnlock()
 
-	if len(rp.db.Replies) == 0 {
+	if len(replies) == 0 {
 		ctx.Reply("📝 当前没有任何回复")
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString("📝 回复列表:\n\n")
+	paginator.New(paginator.FromSlice(replies), func(page paginator.Page[*ReplyData]) string {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("📝 回复列表 (共 %d 条):\n\n", page.Count))
 
-	for _, reply := range rp.db.Replies {
-		content := reply.Reply
-		if len(content) > 30 {
-			content = content[:30] + "..."
+		for _, reply := range page.Items {
+			content := []rune(reply.Reply)
+			preview := string(content)
+			if len(content) > 30 {
+				preview = string(content[:30]) + "..."
+			}
+			sb.WriteString(fmt.Sprintf("#%d [%s]\n  → %s\n\n",
+				reply.ID,
+				reply.Keyword,
+				preview,
+			))
 		}
-		sb.WriteString(fmt.Sprintf("#%d [%s]\n  → %s\n\n",
-			reply.ID,
-			reply.Keyword,
-			content,
-		))
-	}
 
-	ctx.Reply(sb.String())
+		return sb.String()
+	}).PageSize(10).Reply(ctx)
 }
 
 // -------------------- 数据管理 --------------------
 
+// DataPaths 实现 plugin.PluginDataProvider
+func (rp *ReplyPlugin) DataPaths() []string {
+	return []string{rp.config.DBPath}
+}
+
 // updateIndex 更新关键词索引
 func (rp *ReplyPlugin) updateIndex() {
 	rp.db.mu.Lock()
@@ -9,6 +9,7 @@ import (
 
 	"yueling_tg/internal/core/context"
 	"yueling_tg/internal/message"
+	"yueling_tg/pkg/paginator"
 	"yueling_tg/pkg/plugin/params"
 
 	"github.com/mymmrac/telego"
@@ -138,24 +139,35 @@ func (sp *StickerPlugin) handleDeleteSet(ctx *context.Context, cmdCtx params.Com
 }
 
 func (sp *StickerPlugin) handleListSet(ctx *context.Context) {
-	if len(sp.db.Sets) == 0 {
+	// 翻页时读取副本，不受之后的添加与删除影响
+	sets := make([]*StickerSetData, len(sp.db.Sets))
+	copy(sets, sp.db.Sets)
+
+	if len(sets) == 0 {
 		ctx.Reply("当前没有任何贴纸集")
 		return
 	}
 
-	var sb strings.Builder
-	sb.WriteString("📝 当前 Bot 管理的贴纸集：\n\n")
+	paginator.New(paginator.FromSlice(sets), func(page paginator.Page[*StickerSetData]) string {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("📝 当前 Bot 管理的贴纸集 (共 %d 个)：\n\n", page.Count))
 
-	for i, s := range sp.db.Sets {
-		link := fmt.Sprintf("https://t.me/addstickers/%s", s.Name)
-		sb.WriteString(fmt.Sprintf("%d. %s\n🔗 [%s](%s)\n\n", i+1, s.Title, s.Name, link))
-	}
+		for i, s := range page.Items {
+			link := fmt.Sprintf("https://t.me/addstickers/%s", s.Name)
+			sb.WriteString(fmt.Sprintf("%d. %s\n🔗 [%s](%s)\n\n", page.Offset+i+1, s.Title, s.Name, link))
+		}
 
-	ctx.ReplyMarkdown(sb.String())
+		return sb.String()
+	}).PageSize(10).ParseMode(telego.ModeMarkdown).DisablePreview(true).Reply(ctx)
 }
 
 // -------------------- 数据管理 --------------------
 
+// DataPaths 实现 plugin.PluginDataProvider
+func (sp *StickerPlugin) DataPaths() []string {
+	return []string{sp.config.DBPath}
+}
+
 func (sp *StickerPlugin) loadData() error {
 	data, err := os.ReadFile(sp.config.DBPath)
 	if err != nil {
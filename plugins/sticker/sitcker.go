@@ -41,7 +41,7 @@ type StickerPlugin struct {
 
 // 配置结构体
 type PluginConfig struct {
-	DBPath string `mapstructure:"db_path"` // 数据文件路径
+	DBPath string `mapstructure:"db_path" doc:"数据文件路径" validate:"required"`
 }
 
 func New() plugin.Plugin {
@@ -64,17 +64,19 @@ func New() plugin.Plugin {
 	}
 
 	// 默认配置
+	pctx := plugin.NewPluginContext(info.ID)
 	defaultCfg := PluginConfig{
-		DBPath: "./data/botsticker.json",
+		DBPath: pctx.DataDir("botsticker.json"),
 	}
 
 	// 加载或创建配置
 	if err := config.GetPluginConfigOrDefault(info.ID, &sp.config, defaultCfg); err != nil {
 		panic(fmt.Sprintf("加载插件配置失败: %v", err))
 	}
+	pctx.Migrate(config.DataPath("botsticker.json"), sp.config.DBPath)
 
 	// 初始化 Builder
-	builder := plugin.New().Info(info)
+	builder := plugin.New().Info(info).Context(pctx)
 
 	// 命令注册
 	builder.OnCommand("创建贴纸集").Block(true).Do(sp.handleCreateSet)
@@ -358,7 +358,7 @@ func (sp *StickerPlugin) downloadAndConvertToWebP(c *context.Context, fileID str
 	if err != nil {
 		return nil, fmt.Errorf("解码图片失败: %v", err)
 	}
-	sp.Log.Debug().Msgf("原始图片格式: %s, 尺寸: %dx%d", format, img.Bounds().Dx(), img.Bounds().Dy())
+	sp.Logger(c).Debug().Msgf("原始图片格式: %s, 尺寸: %dx%d", format, img.Bounds().Dx(), img.Bounds().Dy())
 
 	resizedImg := sp.resizeImageForSticker(img)
 
@@ -367,7 +367,7 @@ func (sp *StickerPlugin) downloadAndConvertToWebP(c *context.Context, fileID str
 	if err != nil {
 		return nil, fmt.Errorf("WebP编码失败: %v", err)
 	}
-	sp.Log.Debug().Msgf("WebP转换完成, 大小: %d bytes", buf.Len())
+	sp.Logger(c).Debug().Msgf("WebP转换完成, 大小: %d bytes", buf.Len())
 
 	return buf.Bytes(), nil
 }
@@ -406,7 +406,7 @@ func (sp *StickerPlugin) addStickerToSet(c *context.Context, userID int64, stick
 	}
 
 	if err := c.Api.AddStickerToSet(c.Ctx, params); err != nil {
-		sp.Log.Error().Err(err).Msg("添加贴纸失败")
+		sp.Logger(c).Error().Err(err).Msg("添加贴纸失败")
 		return err
 	}
 	return nil
@@ -0,0 +1,155 @@
+package welcome
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"yueling_tg/internal/core/context"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+// -------------------- 模板 --------------------
+
+// 媒体类型
+const (
+	mediaPhoto     = "photo"
+	mediaAnimation = "animation"
+	mediaVideo     = "video"
+)
+
+// maxCaptionLen 带媒体时说明文字的长度上限（UTF-16）
+const maxCaptionLen = 1024
+
+// Media 模板附带的图片、动图或视频
+type Media struct {
+	Type   string `json:"type"`
+	FileID string `json:"file_id"`
+}
+
+// Button 链接按钮
+type Button struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
+// Template 欢迎或告别模板，文字与媒体都为空表示关闭
+type Template struct {
+	Text    string     `json:"text"`
+	Media   *Media     `json:"media,omitempty"`
+	Buttons [][]Button `json:"buttons,omitempty"`
+}
+
+// empty 模板是否为关闭状态
+func (t *Template) empty() bool {
+	return t.Text == "" && t.Media == nil
+}
+
+// buttonPattern 按钮写法 [文字](链接)
+var buttonPattern = regexp.MustCompile(`\[([^\[\]]+)\]\(((?:https?|tg)://[^()\s]+)\)`)
+
+// parseTemplate 解析模板：只由按钮组成的行作为一行按钮，其余为文字
+func parseTemplate(raw string) *Template {
+	t := &Template{}
+	var lines []string
+	for _, line := range strings.Split(raw, "\n") {
+		trimmed := strings.TrimSpace(line)
+		matches := buttonPattern.FindAllStringSubmatch(trimmed, -1)
+		if len(matches) == 0 || strings.TrimSpace(buttonPattern.ReplaceAllString(trimmed, "")) != "" {
+			lines = append(lines, line)
+			continue
+		}
+		row := make([]Button, 0, len(matches))
+		for _, m := range matches {
+			row = append(row, Button{Text: strings.TrimSpace(m[1]), URL: m[2]})
+		}
+		t.Buttons = append(t.Buttons, row)
+	}
+	t.Text = strings.TrimSpace(strings.Join(lines, "\n"))
+	return t
+}
+
+// -------------------- 渲染 --------------------
+
+// vars 占位符的值
+type vars struct {
+	users []telego.User
+	chat  string
+	count int // 群成员数，-1 表示未获取
+}
+
+// placeholderPattern 支持的占位符
+var placeholderPattern = regexp.MustCompile(`\{(name|mention|chat|count)\}`)
+
+// needsCount 模板是否使用了 {count}，需要额外查询成员数
+func (t *Template) needsCount() bool {
+	return strings.Contains(t.Text, "{count}")
+}
+
+// render 替换占位符，{mention} 生成可点击的提及；多位成员时用顿号连接
+func (t *Template) render(v vars) (string, []telego.MessageEntity) {
+	var parts []tu.MessageEntityCollection
+	last := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(t.Text, -1) {
+		if loc[0] > last {
+			parts = append(parts, tu.Entity(t.Text[last:loc[0]]))
+		}
+		last = loc[1]
+
+		switch t.Text[loc[2]:loc[3]] {
+		case "name":
+			names := make([]string, len(v.users))
+			for i, u := range v.users {
+				names[i] = context.FullName(&u)
+			}
+			parts = append(parts, tu.Entity(strings.Join(names, "、")))
+		case "mention":
+			for i := range v.users {
+				if i > 0 {
+					parts = append(parts, tu.Entity("、"))
+				}
+				parts = append(parts, tu.Entity(context.FullName(&v.users[i])).TextMention(&v.users[i]))
+			}
+		case "chat":
+			parts = append(parts, tu.Entity(v.chat))
+		case "count":
+			count := "?"
+			if v.count >= 0 {
+				count = fmt.Sprint(v.count)
+			}
+			parts = append(parts, tu.Entity(count))
+		}
+	}
+	if last < len(t.Text) {
+		parts = append(parts, tu.Entity(t.Text[last:]))
+	}
+	return tu.MessageEntities(parts...)
+}
+
+// keyboard 链接按钮，没有按钮时为 nil
+func (t *Template) keyboard() *telego.InlineKeyboardMarkup {
+	if len(t.Buttons) == 0 {
+		return nil
+	}
+	rows := make([][]telego.InlineKeyboardButton, len(t.Buttons))
+	for i, row := range t.Buttons {
+		for _, b := range row {
+			rows[i] = append(rows[i], tu.InlineKeyboardButton(b.Text).WithURL(b.URL))
+		}
+	}
+	return tu.InlineKeyboardGrid(rows)
+}
+
+// describe 模板的文字说明，用于预览前提示媒体与按钮
+func (t *Template) describe() string {
+	var notes []string
+	if t.Media != nil {
+		notes = append(notes, "媒体："+t.Media.Type)
+	}
+	if n := len(t.Buttons); n > 0 {
+		notes = append(notes, fmt.Sprintf("按钮：%d 行", n))
+	}
+	return strings.Join(notes, "，")
+}
@@ -0,0 +1,113 @@
+package welcome
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mymmrac/telego"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantText    string
+		wantButtons [][]Button
+	}{
+		{"只有文字", "欢迎 {mention}！\n请先阅读群规", "欢迎 {mention}！\n请先阅读群规", nil},
+		{
+			name:     "按钮行",
+			raw:      "欢迎 {name}\n[群规](https://t.me/rules) [频道](tg://resolve?domain=x)\n[官网](http://example.com)",
+			wantText: "欢迎 {name}",
+			wantButtons: [][]Button{
+				{{"群规", "https://t.me/rules"}, {"频道", "tg://resolve?domain=x"}},
+				{{"官网", "http://example.com"}},
+			},
+		},
+		{
+			name:        "按钮与文字混排时作为文字",
+			raw:         "详见 [群规](https://t.me/rules)\n[ 官网 ](https://example.com)",
+			wantText:    "详见 [群规](https://t.me/rules)",
+			wantButtons: [][]Button{{{"官网", "https://example.com"}}},
+		},
+		{"不支持的链接", "[点我](javascript:alert(1))", "[点我](javascript:alert(1))", nil},
+		{"首尾空行", "\n\n欢迎\n\n", "欢迎", nil},
+	}
+	for _, tt := range tests {
+		got := parseTemplate(tt.raw)
+		if got.Text != tt.wantText {
+			t.Errorf("%s: text = %q, want %q", tt.name, got.Text, tt.wantText)
+		}
+		if !reflect.DeepEqual(got.Buttons, tt.wantButtons) {
+			t.Errorf("%s: buttons = %+v, want %+v", tt.name, got.Buttons, tt.wantButtons)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	alice := telego.User{ID: 1, FirstName: "Alice", LastName: "L"}
+	bob := telego.User{ID: 2, FirstName: "鲍勃"}
+
+	tests := []struct {
+		name         string
+		text         string
+		v            vars
+		wantText     string
+		wantMentions []int64 // 提及实体对应的用户
+		wantOffsets  []int   // 提及实体的 UTF-16 偏移
+	}{
+		{"名称与群名", "欢迎 {name} 加入 {chat}", vars{users: []telego.User{alice}, chat: "测试群"}, "欢迎 Alice L 加入 测试群", nil, nil},
+		{"多位成员", "{name}", vars{users: []telego.User{alice, bob}}, "Alice L、鲍勃", nil, nil},
+		{"提及", "👋 {mention}", vars{users: []telego.User{alice, bob}}, "👋 Alice L、鲍勃", []int64{1, 2}, []int{3, 11}},
+		{"成员数", "第 {count} 位", vars{count: 42}, "第 42 位", nil, nil},
+		{"成员数未知", "第 {count} 位", vars{count: -1}, "第 ? 位", nil, nil},
+		{"未知占位符保留", "{foo} {chat}", vars{chat: "群"}, "{foo} 群", nil, nil},
+	}
+	for _, tt := range tests {
+		text, entities := (&Template{Text: tt.text}).render(tt.v)
+		if text != tt.wantText {
+			t.Errorf("%s: text = %q, want %q", tt.name, text, tt.wantText)
+		}
+		var mentions []int64
+		var offsets []int
+		for _, e := range entities {
+			if e.Type == telego.EntityTypeTextMention {
+				mentions = append(mentions, e.User.ID)
+				offsets = append(offsets, e.Offset)
+			}
+		}
+		if !reflect.DeepEqual(mentions, tt.wantMentions) || !reflect.DeepEqual(offsets, tt.wantOffsets) {
+			t.Errorf("%s: mentions = %v at %v, want %v at %v", tt.name, mentions, offsets, tt.wantMentions, tt.wantOffsets)
+		}
+	}
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	tpl := parseTemplate("共 {count} 人\n[群规](https://t.me/rules)\n[官网](https://example.com) [频道](https://t.me/x)")
+	if !tpl.needsCount() {
+		t.Error("needsCount = false, want true")
+	}
+	if tpl.empty() {
+		t.Error("empty = true, want false")
+	}
+
+	kb := tpl.keyboard()
+	if kb == nil || len(kb.InlineKeyboard) != 2 || len(kb.InlineKeyboard[1]) != 2 {
+		t.Fatalf("keyboard = %+v, want rows of 1 and 2 buttons", kb)
+	}
+	if b := kb.InlineKeyboard[1][1]; b.Text != "频道" || b.URL != "https://t.me/x" {
+		t.Errorf("button = %+v", b)
+	}
+	if got := tpl.describe(); got != "按钮：2 行" {
+		t.Errorf("describe = %q", got)
+	}
+
+	empty := &Template{}
+	if !empty.empty() || empty.keyboard() != nil || empty.describe() != "" {
+		t.Error("empty template should have no keyboard or notes")
+	}
+	withMedia := &Template{Media: &Media{Type: mediaPhoto, FileID: "x"}}
+	if withMedia.empty() || withMedia.describe() != "媒体：photo" {
+		t.Errorf("media template: empty %v, describe %q", withMedia.empty(), withMedia.describe())
+	}
+}
@@ -0,0 +1,502 @@
+package welcome
+
+import (
+	stdctx "context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"yueling_tg/internal/core/context"
+	"yueling_tg/pkg/common"
+	"yueling_tg/pkg/config"
+	"yueling_tg/pkg/plugin"
+	"yueling_tg/pkg/plugin/dsl/permission"
+	"yueling_tg/pkg/plugin/params"
+
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+)
+
+var _ plugin.Plugin = (*WelcomePlugin)(nil)
+
+// -------------------- 数据结构 --------------------
+
+// 模板种类
+const (
+	kindWelcome = "welcome"
+	kindGoodbye = "goodbye"
+)
+
+// kindNames 模板种类的中文名称
+var kindNames = map[string]string{
+	kindWelcome: "欢迎",
+	kindGoodbye: "告别",
+}
+
+// ChatSettings 一个群的模板与自动删除时间
+type ChatSettings struct {
+	Templates   map[string]*Template `json:"templates,omitempty"`    // 种类 -> 模板，未设置时使用默认模板
+	DeleteAfter *int                 `json:"delete_after,omitempty"` // 自动删除的秒数，0 表示不删除，未设置时使用默认
+}
+
+type WelcomeDB struct {
+	Chats map[int64]*ChatSettings `json:"chats"` // chat_id -> 设置
+	mu    sync.RWMutex            `json:"-"`
+}
+
+// chat 返回群的设置，不存在时创建（需在写锁内调用）
+func (db *WelcomeDB) chat(chatID int64) *ChatSettings {
+	cs, ok := db.Chats[chatID]
+	if !ok {
+		cs = &ChatSettings{Templates: make(map[string]*Template)}
+		db.Chats[chatID] = cs
+	}
+	if cs.Templates == nil {
+		cs.Templates = make(map[string]*Template)
+	}
+	return cs
+}
+
+// -------------------- 插件结构 --------------------
+
+type PluginConfig struct {
+	DBPath      string `mapstructure:"db_path" doc:"欢迎设置数据文件路径" validate:"required"`
+	Welcome     string `mapstructure:"welcome" doc:"没有单独设置的群使用的欢迎模板，为空表示不发送"`
+	Goodbye     string `mapstructure:"goodbye" doc:"没有单独设置的群使用的告别模板，为空表示不发送"`
+	DeleteAfter string `mapstructure:"delete_after" doc:"欢迎与告别消息自动删除的时间，如 5m，为空表示不删除"`
+}
+
+// Validate 检查自动删除时间
+func (c *PluginConfig) Validate() error {
+	if c.DeleteAfter == "" {
+		return nil
+	}
+	d, err := common.ParseDuration(c.DeleteAfter)
+	if err != nil {
+		return fmt.Errorf("delete_after: %w", err)
+	}
+	if d == common.Forever {
+		return fmt.Errorf("delete_after: 不能为永久，不删除时留空")
+	}
+	return nil
+}
+
+type WelcomePlugin struct {
+	*plugin.Base
+	db     *WelcomeDB
+	config PluginConfig
+
+	// defaults 配置中的默认模板
+	defaults    map[string]*Template
+	deleteAfter time.Duration
+}
+
+// placeholderHelp 占位符说明
+const placeholderHelp = "占位符：{name} 名称、{mention} 提及、{chat} 群名、{count} 群成员数\n" +
+	"按钮：单独一行写 [文字](https://链接)，同一行的多个按钮并排显示\n" +
+	"媒体：回复一张图片、动图或视频发送命令"
+
+func New() plugin.Plugin {
+	wp := &WelcomePlugin{
+		db:       &WelcomeDB{Chats: make(map[int64]*ChatSettings)},
+		defaults: make(map[string]*Template),
+	}
+
+	info := &plugin.PluginInfo{
+		ID:          "welcome",
+		Name:        "欢迎",
+		Description: "新成员加入与成员离开时发送欢迎、告别消息，支持模板、媒体与按钮",
+		Version:     "1.0.0",
+		Author:      "月离",
+		Usage: "设置欢迎 <模板> / 设置告别 <模板>\n" +
+			"预览欢迎 / 预览告别\n" +
+			"关闭欢迎 / 关闭告别（不发送）\n" +
+			"重置欢迎 / 重置告别（使用默认模板）\n" +
+			"欢迎自动删除 <时长|关闭|默认>\n" +
+			placeholderHelp,
+		Group: "群管",
+		Extra: make(map[string]any),
+	}
+
+	// 默认配置
+	pctx := plugin.NewPluginContext(info.ID)
+	defaultCfg := PluginConfig{
+		DBPath: pctx.DataDir("welcome.json"),
+	}
+	if err := config.GetPluginConfigOrDefault(info.ID, &wp.config, defaultCfg); err != nil {
+		panic(fmt.Sprintf("加载插件配置失败: %v", err))
+	}
+	if wp.config.Welcome != "" {
+		wp.defaults[kindWelcome] = parseTemplate(wp.config.Welcome)
+	}
+	if wp.config.Goodbye != "" {
+		wp.defaults[kindGoodbye] = parseTemplate(wp.config.Goodbye)
+	}
+	if wp.config.DeleteAfter != "" {
+		wp.deleteAfter, _ = common.ParseDuration(wp.config.DeleteAfter)
+	}
+
+	builder := plugin.New().Info(info).Context(pctx)
+
+	builder.OnNotice().Priority(130).Do(wp.handleMemberChange)
+
+	// 管理命令
+	admin := permission.GroupAdminOrOwner()
+	for kind, name := range kindNames {
+		builder.OnCommand("设置" + name).When(admin).Priority(10).Do(wp.setHandler(kind))
+		builder.OnCommand("预览" + name).When(admin).Priority(10).Do(wp.previewHandler(kind))
+		builder.OnCommand("关闭" + name).When(admin).Priority(10).Do(wp.resetHandler(kind, &Template{}))
+		builder.OnCommand("重置" + name).When(admin).Priority(10).Do(wp.resetHandler(kind, nil))
+	}
+	builder.OnCommand("欢迎自动删除").When(admin).Priority(10).Do(wp.handleDeleteAfter)
+
+	return builder.Go(wp)
+}
+
+func (wp *WelcomePlugin) Init() error {
+	if err := wp.loadData(); err != nil {
+		wp.Log.Warn().Msgf("⚠️ 加载欢迎设置失败，使用空数据库: %v", err)
+	} else {
+		wp.Log.Info().Msgf("已加载 %d 个群组的欢迎设置", len(wp.db.Chats))
+	}
+	return nil
+}
+
+// DataPaths 实现 plugin.PluginDataProvider
+func (wp *WelcomePlugin) DataPaths() []string {
+	return []string{wp.config.DBPath}
+}
+
+// template 群生效的模板，没有或已关闭时返回 nil
+func (wp *WelcomePlugin) template(chatID int64, kind string) *Template {
+	wp.db.mu.RLock()
+	t, ok := (*Template)(nil), false
+	if cs := wp.db.Chats[chatID]; cs != nil {
+		t, ok = cs.Templates[kind]
+	}
+	wp.db.mu.RUnlock()
+
+	if !ok {
+		t = wp.defaults[kind]
+	}
+	if t == nil || t.empty() {
+		return nil
+	}
+	return t
+}
+
+// deleteAfterFor 群的自动删除时间，0 表示不删除
+func (wp *WelcomePlugin) deleteAfterFor(chatID int64) time.Duration {
+	wp.db.mu.RLock()
+	defer wp.db.mu.RUnlock()
+	if cs := wp.db.Chats[chatID]; cs != nil && cs.DeleteAfter != nil {
+		return time.Duration(*cs.DeleteAfter) * time.Second
+	}
+	return wp.deleteAfter
+}
+
+// -------------------- 处理器 --------------------
+
+// handleMemberChange 新成员加入时发送欢迎消息，成员离开时发送告别消息
+func (wp *WelcomePlugin) handleMemberChange(ctx *context.Context) {
+	if !ctx.IsGroupChat() {
+		return
+	}
+
+	kind := kindWelcome
+	var users []telego.User
+	for _, u := range ctx.GetNewChatMembers() {
+		if !u.IsBot {
+			users = append(users, u)
+		}
+	}
+	if left := ctx.GetLeftChatMember(); left != nil && !left.IsBot {
+		kind, users = kindGoodbye, []telego.User{*left}
+	}
+	if len(users) == 0 {
+		return
+	}
+
+	chatID := ctx.GetChat().ID
+	t := wp.template(chatID, kind)
+	if t == nil {
+		return
+	}
+
+	msg, err := wp.send(ctx, t, users)
+	if err != nil {
+		wp.Logger(ctx).Error().Err(err).Str("kind", kind).Msg("发送欢迎消息失败")
+		return
+	}
+
+	// 到期删除，处理器返回后 ctx.Ctx 可能已取消
+	if d := wp.deleteAfterFor(chatID); d > 0 {
+		api, chat := ctx.Api, ctx.GetChatID()
+		time.AfterFunc(d, func() {
+			_ = api.DeleteMessage(stdctx.Background(), tu.Delete(chat, msg.MessageID))
+		})
+	}
+}
+
+// setHandler 设置模板：命令后的文字为模板，回复的图片、动图或视频作为媒体
+func (wp *WelcomePlugin) setHandler(kind string) func(*context.Context, params.CommandContext) {
+	return func(ctx *context.Context, cmdCtx params.CommandContext) {
+		if !ctx.IsGroupChat() {
+			ctx.Reply("❌ 此命令只能在群组中使用")
+			return
+		}
+
+		raw := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cmdCtx.RawText), cmdCtx.RawCommand))
+		t := parseTemplate(raw)
+		t.Media = replyMedia(ctx)
+		if t.empty() {
+			ctx.Replyf("❌ 用法：设置%s <模板>\n%s", kindNames[kind], placeholderHelp)
+			return
+		}
+		if t.Media != nil && tu.UTF16TextLen(t.Text) > maxCaptionLen {
+			ctx.Replyf("❌ 带媒体时文字不能超过 %d 个字符", maxCaptionLen)
+			return
+		}
+
+		if err := wp.update(ctx.GetChat().ID, func(cs *ChatSettings) { cs.Templates[kind] = t }); err != nil {
+			wp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+			ctx.Reply("❌ 保存失败")
+			return
+		}
+
+		text := fmt.Sprintf("✅ 已设置%s消息，预览如下", kindNames[kind])
+		if notes := t.describe(); notes != "" {
+			text += "（" + notes + "）"
+		}
+		ctx.Reply(text)
+		wp.preview(ctx, t)
+	}
+}
+
+// previewHandler 以发送者作为成员预览模板
+func (wp *WelcomePlugin) previewHandler(kind string) func(*context.Context) {
+	return func(ctx *context.Context) {
+		if !ctx.IsGroupChat() {
+			ctx.Reply("❌ 此命令只能在群组中使用")
+			return
+		}
+		t := wp.template(ctx.GetChat().ID, kind)
+		if t == nil {
+			ctx.Replyf("📝 本群没有%s消息", kindNames[kind])
+			return
+		}
+		wp.preview(ctx, t)
+	}
+}
+
+// resetHandler 关闭（t 为空模板）或恢复默认模板（t 为 nil）
+func (wp *WelcomePlugin) resetHandler(kind string, t *Template) func(*context.Context) {
+	return func(ctx *context.Context) {
+		if !ctx.IsGroupChat() {
+			ctx.Reply("❌ 此命令只能在群组中使用")
+			return
+		}
+
+		err := wp.update(ctx.GetChat().ID, func(cs *ChatSettings) {
+			if t == nil {
+				delete(cs.Templates, kind)
+			} else {
+				cs.Templates[kind] = t
+			}
+		})
+		if err != nil {
+			wp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+			ctx.Reply("❌ 保存失败")
+			return
+		}
+
+		switch {
+		case t != nil:
+			ctx.Replyf("✅ 已关闭%s消息", kindNames[kind])
+		case wp.defaults[kind] != nil:
+			ctx.Replyf("✅ 已恢复默认%s消息", kindNames[kind])
+		default:
+			ctx.Replyf("✅ 已恢复默认设置，当前没有默认%s消息", kindNames[kind])
+		}
+	}
+}
+
+// handleDeleteAfter 设置欢迎与告别消息的自动删除时间：欢迎自动删除 <时长|关闭|默认>
+func (wp *WelcomePlugin) handleDeleteAfter(ctx *context.Context, cmdCtx params.CommandContext) {
+	if !ctx.IsGroupChat() {
+		ctx.Reply("❌ 此命令只能在群组中使用")
+		return
+	}
+
+	arg := cmdCtx.Args.Get(0)
+	var seconds *int
+	var text string
+	switch arg {
+	case "":
+		ctx.Reply("❌ 用法：欢迎自动删除 <时长|关闭|默认>，如 欢迎自动删除 5m")
+		return
+	case "默认", "default":
+		text = "✅ 已恢复默认的自动删除时间"
+	case "关闭", "off", "0":
+		seconds = new(int)
+		text = "✅ 欢迎与告别消息不再自动删除"
+	default:
+		d, err := common.ParseDuration(arg)
+		if err != nil || d == common.Forever || d < time.Second {
+			ctx.Reply("❌ 时长无效，如 30s、5m、1h")
+			return
+		}
+		n := int(d / time.Second)
+		seconds = &n
+		text = fmt.Sprintf("✅ 欢迎与告别消息将在 %s 后自动删除", common.FormatDuration(d))
+	}
+
+	if err := wp.update(ctx.GetChat().ID, func(cs *ChatSettings) { cs.DeleteAfter = seconds }); err != nil {
+		wp.Logger(ctx).Error().Err(err).Msg("保存数据失败")
+		ctx.Reply("❌ 保存失败")
+		return
+	}
+	ctx.Reply(text)
+}
+
+// -------------------- 发送 --------------------
+
+// preview 以发送者作为成员发送模板
+func (wp *WelcomePlugin) preview(ctx *context.Context, t *Template) {
+	user := ctx.GetUser()
+	if user == nil {
+		return
+	}
+	if _, err := wp.send(ctx, t, []telego.User{*user}); err != nil {
+		wp.Logger(ctx).Error().Err(err).Msg("发送预览失败")
+		ctx.Replyf("❌ 发送失败：%v", err)
+	}
+}
+
+// send 渲染模板并发送到当前群组
+func (wp *WelcomePlugin) send(ctx *context.Context, t *Template, users []telego.User) (*telego.Message, error) {
+	chat := ctx.GetChat()
+	v := vars{users: users, chat: chat.Title, count: -1}
+	if t.needsCount() {
+		if n, err := ctx.Api.GetChatMemberCount(ctx.Ctx, &telego.GetChatMemberCountParams{ChatID: ctx.GetChatID()}); err == nil {
+			v.count = *n
+		}
+	}
+	text, entities := t.render(v)
+	keyboard := t.keyboard()
+	chatID := ctx.GetChatID()
+
+	if t.Media == nil {
+		p := tu.Message(chatID, text).WithEntities(entities...)
+		if keyboard != nil {
+			p.WithReplyMarkup(keyboard)
+		}
+		return ctx.Api.SendMessage(ctx.Ctx, p)
+	}
+
+	file := tu.FileFromID(t.Media.FileID)
+	switch t.Media.Type {
+	case mediaAnimation:
+		p := tu.Animation(chatID, file).WithCaption(text).WithCaptionEntities(entities...)
+		if keyboard != nil {
+			p.WithReplyMarkup(keyboard)
+		}
+		return ctx.Api.SendAnimation(ctx.Ctx, p)
+	case mediaVideo:
+		p := tu.Video(chatID, file).WithCaption(text).WithCaptionEntities(entities...)
+		if keyboard != nil {
+			p.WithReplyMarkup(keyboard)
+		}
+		return ctx.Api.SendVideo(ctx.Ctx, p)
+	default:
+		p := tu.Photo(chatID, file).WithCaption(text).WithCaptionEntities(entities...)
+		if keyboard != nil {
+			p.WithReplyMarkup(keyboard)
+		}
+		return ctx.Api.SendPhoto(ctx.Ctx, p)
+	}
+}
+
+// replyMedia 回复的消息中的图片、动图或视频
+func replyMedia(ctx *context.Context) *Media {
+	reply := ctx.GetReplyToMessage()
+	if reply == nil {
+		return nil
+	}
+	switch {
+	case reply.Animation != nil:
+		return &Media{Type: mediaAnimation, FileID: reply.Animation.FileID}
+	case reply.Video != nil:
+		return &Media{Type: mediaVideo, FileID: reply.Video.FileID}
+	case len(reply.Photo) > 0:
+		return &Media{Type: mediaPhoto, FileID: reply.Photo[len(reply.Photo)-1].FileID}
+	}
+	return nil
+}
+
+// -------------------- 数据管理 --------------------
+
+// update 修改群的设置并保存
+func (wp *WelcomePlugin) update(chatID int64, fn func(cs *ChatSettings)) error {
+	wp.db.mu.Lock()
+	cs := wp.db.chat(chatID)
+	fn(cs)
+	if len(cs.Templates) == 0 && cs.DeleteAfter == nil {
+		delete(wp.db.Chats, chatID)
+	}
+	wp.db.mu.Unlock()
+
+	return wp.saveData()
+}
+
+// loadData 从文件加载数据
+func (wp *WelcomePlugin) loadData() error {
+	data, err := os.ReadFile(wp.config.DBPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil // 文件不存在，使用空数据库
+		}
+		return err
+	}
+
+	wp.db.mu.Lock()
+	defer wp.db.mu.Unlock()
+	if err := json.Unmarshal(data, wp.db); err != nil {
+		return err
+	}
+	if wp.db.Chats == nil {
+		wp.db.Chats = make(map[int64]*ChatSettings)
+	}
+	return nil
+}
+
+// saveData 保存数据到文件
+func (wp *WelcomePlugin) saveData() error {
+	if dir := filepath.Dir(wp.config.DBPath); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+	}
+
+	wp.db.mu.RLock()
+	data, err := json.MarshalIndent(wp.db, "", "  ")
+	wp.db.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("序列化数据失败: %w", err)
+	}
+
+	// 使用临时文件 + 原子重命名，避免写入失败导致数据损坏
+	tmpFile := wp.config.DBPath + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Rename(tmpFile, wp.config.DBPath); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("重命名文件失败: %w", err)
+	}
+	return nil
+}